@@ -0,0 +1,81 @@
+// activitypub.go exposes the ActivityPub actor's RSA signing key to the
+// admin UI, the one key generation/management surface this repo requires
+// to sit behind Argon2id+2FA (see authMiddleware) rather than being
+// generated silently by the build like the build's own Ed25519 signing key.
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"secureblog/plugins/activitypub"
+)
+
+// activitypubKeyStatusHandler reports whether the actor key exists yet and,
+// if so, a fingerprint an admin can compare against what's published in
+// actor.json without ever exposing the private key itself.
+func (s *Server) activitypubKeyStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(Response{Success: false, Message: "Method not allowed"})
+		return
+	}
+
+	if _, err := os.Stat(activitypub.KeyPath); os.IsNotExist(err) {
+		json.NewEncoder(w).Encode(Response{Success: true, Data: map[string]interface{}{"exists": false}})
+		return
+	}
+
+	key, err := activitypub.LoadOrGenerateKey(activitypub.KeyPath)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(Response{Success: false, Message: err.Error()})
+		return
+	}
+	pubPem, err := activitypub.EncodePublicKeyPEM(&key.PublicKey)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(Response{Success: false, Message: err.Error()})
+		return
+	}
+	sum := sha256.Sum256([]byte(pubPem))
+
+	json.NewEncoder(w).Encode(Response{Success: true, Data: map[string]interface{}{
+		"exists":      true,
+		"fingerprint": hex.EncodeToString(sum[:]),
+	}})
+}
+
+// activitypubKeyRotateHandler discards the current actor key and generates
+// a fresh one. Remote followers keep their subscription (the follower
+// store is untouched), but every signature this actor makes afterward
+// needs their server to re-fetch actor.json to pick up the new public key.
+// Key rotation requires the session's 2FA challenge to have been satisfied
+// by a WebAuthn credential specifically (see requireWebAuthnFactor): a
+// stolen TOTP code or backup code is enough to pass authMiddleware, but
+// rotating the actor's signing key is irreversible enough -- every remote
+// follower must refetch actor.json -- that it's worth requiring the
+// phishing-resistant factor.
+func (s *Server) activitypubKeyRotateHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(Response{Success: false, Message: "Method not allowed"})
+		return
+	}
+
+	if err := os.Remove(activitypub.KeyPath); err != nil && !os.IsNotExist(err) {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(Response{Success: false, Message: err.Error()})
+		return
+	}
+
+	if _, err := activitypub.LoadOrGenerateKey(activitypub.KeyPath); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(Response{Success: false, Message: err.Error()})
+		return
+	}
+
+	json.NewEncoder(w).Encode(Response{Success: true, Message: "Actor key rotated; republish to refresh actor.json"})
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+)
+
+func TestPasswordHashPHCStringRoundTrip(t *testing.T) {
+	stored, err := CreatePasswordHash("correct-horse-battery-staple-1!")
+	if err != nil {
+		t.Fatalf("CreatePasswordHash() error = %v", err)
+	}
+
+	parsed, err := ParsePasswordHash(stored.String())
+	if err != nil {
+		t.Fatalf("ParsePasswordHash() error = %v", err)
+	}
+
+	ok, needsRehash := VerifyPasswordHash("correct-horse-battery-staple-1!", parsed)
+	if !ok {
+		t.Fatalf("VerifyPasswordHash() = false, want true for the correct password")
+	}
+	if needsRehash {
+		t.Errorf("needsRehash = true, want false right after CreatePasswordHash")
+	}
+}
+
+func TestVerifyPasswordHashRejectsWrongPassword(t *testing.T) {
+	stored, err := CreatePasswordHash("correct-horse-battery-staple-1!")
+	if err != nil {
+		t.Fatalf("CreatePasswordHash() error = %v", err)
+	}
+
+	if ok, _ := VerifyPasswordHash("wrong-password", stored); ok {
+		t.Errorf("VerifyPasswordHash() = true, want false for the wrong password")
+	}
+}
+
+func TestVerifyPasswordHashFlagsOutdatedCost(t *testing.T) {
+	stored, err := CreatePasswordHash("correct-horse-battery-staple-1!")
+	if err != nil {
+		t.Fatalf("CreatePasswordHash() error = %v", err)
+	}
+
+	original := *argonParams
+	argonParams.Iterations++
+	defer func() { *argonParams = original }()
+
+	ok, needsRehash := VerifyPasswordHash("correct-horse-battery-staple-1!", stored)
+	if !ok {
+		t.Fatalf("VerifyPasswordHash() = false, want true: raising cost shouldn't break an existing hash")
+	}
+	if !needsRehash {
+		t.Errorf("needsRehash = false, want true once argonParams's cost exceeds stored's")
+	}
+}
+
+func TestParseLegacyPasswordHashMigrates(t *testing.T) {
+	stored, err := CreatePasswordHash("correct-horse-battery-staple-1!")
+	if err != nil {
+		t.Fatalf("CreatePasswordHash() error = %v", err)
+	}
+
+	legacyJSON := fmt.Sprintf(`{"hash":%q,"salt":%q,"params":"argon2id$v=19$m=%d,t=%d,p=%d"}`,
+		base64.StdEncoding.EncodeToString(stored.Hash),
+		base64.StdEncoding.EncodeToString(stored.Salt),
+		stored.Memory, stored.Iterations, stored.Parallelism)
+
+	parsed, err := ParsePasswordHashAny([]byte(legacyJSON))
+	if err != nil {
+		t.Fatalf("ParsePasswordHashAny() error = %v", err)
+	}
+
+	if ok, _ := VerifyPasswordHash("correct-horse-battery-staple-1!", parsed); !ok {
+		t.Errorf("VerifyPasswordHash() = false after migrating a legacy hash, want true")
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/securecookie"
+)
+
+// newCSRFTestRouter builds a minimal router that mirrors how main() wires
+// the CSRF middleware and the /login route, so tests exercise the same
+// configuration the real server runs with.
+func newCSRFTestRouter(t *testing.T) *mux.Router {
+	t.Helper()
+
+	hashKey := securecookie.GenerateRandomKey(64)
+	blockKey := securecookie.GenerateRandomKey(32)
+	passwordHash, err := CreatePasswordHash("correct-horse-battery-staple-1!")
+	if err != nil {
+		t.Fatalf("CreatePasswordHash() error = %v", err)
+	}
+
+	server := &Server{
+		secureCookie: securecookie.New(hashKey, blockKey),
+		passwordHash: passwordHash,
+		totpLimiter:  newTOTPRateLimiter(),
+		lastActivity: time.Now(),
+		shutdownChan: make(chan os.Signal, 1),
+	}
+
+	secret := make([]byte, csrfSecretLength)
+	for i := range secret {
+		secret[i] = byte(i)
+	}
+
+	r := mux.NewRouter()
+	r.Use(plaintextHTTPMiddleware)
+	r.Use(csrf.Protect(
+		secret,
+		csrf.Secure(false),
+		csrf.HttpOnly(true),
+		csrf.ErrorHandler(http.HandlerFunc(csrfFailureHandler)),
+	))
+	r.HandleFunc("/login", server.loginHandler).Methods("GET", "POST")
+
+	return r
+}
+
+var csrfTokenFieldPattern = regexp.MustCompile(`name="gorilla\.csrf\.Token" value="([^"]+)"`)
+
+func TestCSRFRejectsForgedPOSTWithoutToken(t *testing.T) {
+	ts := httptest.NewServer(newCSRFTestRouter(t))
+	defer ts.Close()
+
+	// A forged request from a different origin/referer, carrying no CSRF
+	// cookie or token at all.
+	req, err := http.NewRequest("POST", ts.URL+"/login",
+		strings.NewReader(url.Values{"username": {"admin"}, "password": {"x"}}.Encode()))
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Origin", "http://evil.example")
+	req.Header.Set("Referer", "http://evil.example/attack")
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("POST /login error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.Contains(ct, "application/json") {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+}
+
+func TestCSRFAllowsRequestCarryingAValidToken(t *testing.T) {
+	ts := httptest.NewServer(newCSRFTestRouter(t))
+	defer ts.Close()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+
+	getResp, err := client.Get(ts.URL + "/login")
+	if err != nil {
+		t.Fatalf("GET /login error = %v", err)
+	}
+	defer getResp.Body.Close()
+
+	var cookie *http.Cookie
+	for _, c := range getResp.Cookies() {
+		if c.Name == "_gorilla_csrf" {
+			cookie = c
+		}
+	}
+	if cookie == nil {
+		t.Fatal("GET /login did not set the CSRF cookie")
+	}
+
+	body := make([]byte, 0)
+	buf := make([]byte, 4096)
+	for {
+		n, err := getResp.Body.Read(buf)
+		body = append(body, buf[:n]...)
+		if err != nil {
+			break
+		}
+	}
+	match := csrfTokenFieldPattern.FindSubmatch(body)
+	if match == nil {
+		t.Fatal("GET /login did not embed a gorilla.csrf.Token field")
+	}
+	token := string(match[1])
+
+	req, err := http.NewRequest("POST", ts.URL+"/login",
+		strings.NewReader(url.Values{
+			"username":           {"admin"},
+			"password":           {"wrong-password"},
+			"gorilla.csrf.Token": {token},
+		}.Encode()))
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(cookie)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("POST /login error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	// A valid CSRF token must not be rejected with 403; the wrong password
+	// is expected to redirect back to the login page instead.
+	if resp.StatusCode == http.StatusForbidden {
+		t.Errorf("request with a valid CSRF token was rejected as forged")
+	}
+}
@@ -0,0 +1,302 @@
+// jobs.go - background command execution with live log streaming, so a
+// multi-minute deploy/build doesn't block the HTTP request that started it.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// ringBufferLimit bounds how much log output a Job keeps in memory; older
+// bytes are dropped once a job's output exceeds this, so a runaway command
+// can't exhaust memory.
+const ringBufferLimit = 1 << 20 // 1MB
+
+// ringBuffer is an append-only byte buffer that discards its oldest content
+// once it grows past ringBufferLimit.
+type ringBuffer struct {
+	mu   sync.Mutex
+	data []byte
+}
+
+func (b *ringBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = append(b.data, p...)
+	if len(b.data) > ringBufferLimit {
+		b.data = b.data[len(b.data)-ringBufferLimit:]
+	}
+	return len(p), nil
+}
+
+// Bytes returns a copy of the buffer's current contents.
+func (b *ringBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]byte, len(b.data))
+	copy(out, b.data)
+	return out
+}
+
+// Job tracks one background command execution: its live output, status, and
+// any subscribers currently streaming that output over SSE.
+type Job struct {
+	ID       string    `json:"id"`
+	Kind     string    `json:"kind"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished,omitempty"`
+	Status   string    `json:"status"` // "running", "succeeded", "failed", "canceled"
+	ExitCode int       `json:"exitCode"`
+
+	mu          sync.Mutex
+	logBuf      *ringBuffer
+	subscribers map[chan []byte]struct{}
+	cmd         *exec.Cmd
+	done        chan struct{}
+}
+
+// JobStatus constants.
+const (
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+	JobStatusCanceled  = "canceled"
+)
+
+func newJob(kind string) *Job {
+	return &Job{
+		ID:          generateSessionID(),
+		Kind:        kind,
+		Started:     time.Now(),
+		Status:      JobStatusRunning,
+		logBuf:      &ringBuffer{},
+		subscribers: make(map[chan []byte]struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// subscribe registers a channel that receives every line published after
+// this call. The caller must call the returned unsubscribe func when done.
+func (j *Job) subscribe() (ch chan []byte, unsubscribe func()) {
+	ch = make(chan []byte, 64)
+	j.mu.Lock()
+	j.subscribers[ch] = struct{}{}
+	j.mu.Unlock()
+
+	return ch, func() {
+		j.mu.Lock()
+		if _, ok := j.subscribers[ch]; ok {
+			delete(j.subscribers, ch)
+			close(ch)
+		}
+		j.mu.Unlock()
+	}
+}
+
+// publish appends line to the job's log buffer and fans it out to every
+// current subscriber. Subscribers that aren't keeping up are dropped rather
+// than blocking the command's output loop.
+func (j *Job) publish(line []byte) {
+	j.logBuf.Write(line)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for ch := range j.subscribers {
+		select {
+		case ch <- line:
+		default:
+		}
+	}
+}
+
+// finish marks the job complete and closes every subscriber channel so
+// their SSE handlers can send a terminal event and return.
+func (j *Job) finish(status string, exitCode int) {
+	j.mu.Lock()
+	j.Status = status
+	j.ExitCode = exitCode
+	j.Finished = time.Now()
+	for ch := range j.subscribers {
+		delete(j.subscribers, ch)
+		close(ch)
+	}
+	j.mu.Unlock()
+	close(j.done)
+}
+
+// startJob runs name/args in the background under the job subsystem,
+// streaming combined stdout/stderr line-by-line into the job's log buffer
+// and registering it on the server so it can be looked up by ID.
+func (s *Server) startJob(kind, name string, args ...string) (*Job, error) {
+	return s.startJobCmd(kind, exec.Command(name, args...))
+}
+
+// startJobCmd runs an already-built cmd (e.g. one wrapping a script inside
+// the sandbox runtime) under the job subsystem. Everything downstream --
+// output streaming, status tracking, SSE -- works unchanged regardless of
+// whether cmd runs on the host or inside a container.
+func (s *Server) startJobCmd(kind string, cmd *exec.Cmd) (*Job, error) {
+	job := newJob(kind)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("creating stdout pipe: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("creating stderr pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting command: %w", err)
+	}
+	job.cmd = cmd
+
+	s.jobsMutex.Lock()
+	s.jobs[job.ID] = job
+	s.jobsMutex.Unlock()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go job.scanInto(stdout, &wg)
+	go job.scanInto(stderr, &wg)
+
+	go func() {
+		wg.Wait()
+		err := cmd.Wait()
+		switch {
+		case err == nil:
+			job.finish(JobStatusSucceeded, 0)
+		case job.wasCanceled():
+			job.finish(JobStatusCanceled, -1)
+		default:
+			exitCode := -1
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				exitCode = exitErr.ExitCode()
+			}
+			job.finish(JobStatusFailed, exitCode)
+		}
+	}()
+
+	return job, nil
+}
+
+func (j *Job) scanInto(r io.Reader, wg *sync.WaitGroup) {
+	defer wg.Done()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		j.publish(append(scanner.Bytes(), '\n'))
+	}
+}
+
+func (j *Job) wasCanceled() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.Status == JobStatusCanceled
+}
+
+// cancel sends SIGTERM to the job's process, if it's still running.
+func (j *Job) cancel() error {
+	j.mu.Lock()
+	cmd := j.cmd
+	running := j.Status == JobStatusRunning
+	if running {
+		j.Status = JobStatusCanceled
+	}
+	j.mu.Unlock()
+
+	if !running || cmd == nil || cmd.Process == nil {
+		return nil
+	}
+	return cmd.Process.Signal(syscall.SIGTERM)
+}
+
+// jobByID looks up a tracked job, reporting whether it exists.
+func (s *Server) jobByID(id string) (*Job, bool) {
+	s.jobsMutex.RLock()
+	defer s.jobsMutex.RUnlock()
+	job, ok := s.jobs[id]
+	return job, ok
+}
+
+func (s *Server) listJobsHandler(w http.ResponseWriter, r *http.Request) {
+	s.jobsMutex.RLock()
+	jobs := make([]*Job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+	s.jobsMutex.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Response{Success: true, Data: jobs})
+}
+
+func (s *Server) cancelJobHandler(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.jobByID(mux.Vars(r)["id"])
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(Response{Success: false, Message: "Job not found"})
+		return
+	}
+
+	if err := job.cancel(); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(Response{Success: false, Message: "Failed to cancel job"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Response{Success: true, Message: "Job canceled"})
+}
+
+// jobEventsHandler streams a job's output as Server-Sent Events: the
+// buffered log seen so far, then each new line as it's published, followed
+// by a terminal "done" event once the job finishes.
+func (s *Server) jobEventsHandler(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.jobByID(mux.Vars(r)["id"])
+	if !ok {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	fmt.Fprintf(w, "data: %s\n\n", job.logBuf.Bytes())
+	flusher.Flush()
+
+	ch, unsubscribe := job.subscribe()
+	defer unsubscribe()
+
+	for {
+		select {
+		case line, ok := <-ch:
+			if !ok {
+				fmt.Fprintf(w, "event: done\ndata: {\"exitCode\":%d,\"status\":%q}\n\n", job.ExitCode, job.Status)
+				flusher.Flush()
+				return
+			}
+			fmt.Fprintf(w, "data: %s\n\n", line)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestServerForJobs() *Server {
+	return &Server{jobs: make(map[string]*Job)}
+}
+
+func waitForJob(t *testing.T, job *Job) {
+	t.Helper()
+	select {
+	case <-job.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job did not finish within 5s")
+	}
+}
+
+func TestStartJobStreamsOutputAndSucceeds(t *testing.T) {
+	s := newTestServerForJobs()
+
+	job, err := s.startJob("test", "sh", "-c", "echo hello; echo world")
+	if err != nil {
+		t.Fatalf("startJob() error = %v", err)
+	}
+	waitForJob(t, job)
+
+	if job.Status != JobStatusSucceeded {
+		t.Errorf("Status = %q, want %q", job.Status, JobStatusSucceeded)
+	}
+	log := string(job.logBuf.Bytes())
+	if !strings.Contains(log, "hello") || !strings.Contains(log, "world") {
+		t.Errorf("log = %q, want it to contain both output lines", log)
+	}
+
+	if _, ok := s.jobByID(job.ID); !ok {
+		t.Error("jobByID() did not find the started job")
+	}
+}
+
+func TestStartJobRecordsFailureExitCode(t *testing.T) {
+	s := newTestServerForJobs()
+
+	job, err := s.startJob("test", "sh", "-c", "exit 3")
+	if err != nil {
+		t.Fatalf("startJob() error = %v", err)
+	}
+	waitForJob(t, job)
+
+	if job.Status != JobStatusFailed {
+		t.Errorf("Status = %q, want %q", job.Status, JobStatusFailed)
+	}
+	if job.ExitCode != 3 {
+		t.Errorf("ExitCode = %d, want 3", job.ExitCode)
+	}
+}
+
+func TestJobCancelSendsSIGTERM(t *testing.T) {
+	s := newTestServerForJobs()
+
+	job, err := s.startJob("test", "sh", "-c", "sleep 30")
+	if err != nil {
+		t.Fatalf("startJob() error = %v", err)
+	}
+
+	if err := job.cancel(); err != nil {
+		t.Fatalf("cancel() error = %v", err)
+	}
+	waitForJob(t, job)
+
+	if job.Status != JobStatusCanceled {
+		t.Errorf("Status = %q, want %q", job.Status, JobStatusCanceled)
+	}
+}
+
+func TestRingBufferEvictsOldestBytes(t *testing.T) {
+	b := &ringBuffer{}
+	chunk := make([]byte, ringBufferLimit)
+	for i := range chunk {
+		chunk[i] = 'a'
+	}
+
+	b.Write(chunk)
+	b.Write([]byte("marker"))
+	b.Write(chunk)
+
+	if len(b.Bytes()) > ringBufferLimit {
+		t.Errorf("buffer length = %d, want at most %d", len(b.Bytes()), ringBufferLimit)
+	}
+	if strings.Contains(string(b.Bytes()), "marker") {
+		t.Error("ring buffer retained content that should have been evicted")
+	}
+}
+
+func TestJobSubscribeReceivesPublishedLines(t *testing.T) {
+	job := newJob("test")
+	ch, unsubscribe := job.subscribe()
+	defer unsubscribe()
+
+	job.publish([]byte("line one\n"))
+
+	select {
+	case line := <-ch:
+		if string(line) != "line one\n" {
+			t.Errorf("line = %q, want %q", line, "line one\n")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscriber did not receive the published line")
+	}
+
+	job.finish(JobStatusSucceeded, 0)
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Error("channel should have been closed on finish")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscriber channel was not closed on finish")
+	}
+}
@@ -1,54 +1,68 @@
-// admin-server - WordPress-easy, Fort Knox secure blog admin  
+// admin-server - WordPress-easy, Fort Knox secure blog admin
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"html/template"
-	"io"
+	"image/png"
 	"log"
 	"net/http"
 	"os"
-	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/gorilla/csrf"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/securecookie"
+
+	"secureblog/internal/sandbox"
+	"secureblog/internal/session"
 )
 
 const (
-	maxUploadSize   = 10 << 20      // 10MB
+	maxUploadSize   = 10 << 20 // 10MB
 	serverPort      = "3000"
 	adminUser       = "admin"
-	sessionTimeout  = 30 * time.Minute  // 30 minutes
-	inactivityLimit = 2 * time.Hour     // Auto-kill after 2 hours inactivity
+	sessionTimeout  = 30 * time.Minute // 30 minutes
+	inactivityLimit = 2 * time.Hour    // Auto-kill after 2 hours inactivity
 )
 
 type Server struct {
-	secureCookie    *securecookie.SecureCookie
-	templates       *template.Template
-	passwordHash    PasswordHash
-	twoFAConfig     TwoFAConfig
-	lastActivity    time.Time
-	activeSessions  map[string]Session
-	sessionMutex    sync.RWMutex
-	shutdownChan    chan os.Signal
+	secureCookie *securecookie.SecureCookie
+	templates    *template.Template
+	passwordHash PasswordHash
+	twoFAConfig  TwoFAConfig
+	twoFAMutex   sync.RWMutex
+	totpLimiter  *totpRateLimiter
+	lastActivity time.Time
+	sessionStore *session.Store
+	sandbox      sandbox.Config
+	jobs         map[string]*Job
+	jobsMutex    sync.RWMutex
+	shutdownChan chan os.Signal
+	webAuthn     *webauthn.WebAuthn
 }
 
 type Post struct {
-	Title     string    `json:"title"`
-	Slug      string    `json:"slug"`
-	Content   string    `json:"content"`
-	Tags      []string  `json:"tags"`
-	Date      time.Time `json:"date"`
-	Draft     bool      `json:"draft"`
-	Filename  string    `json:"filename"`
+	Title    string    `json:"title"`
+	Slug     string    `json:"slug"`
+	Content  string    `json:"content"`
+	Tags     []string  `json:"tags"`
+	Date     time.Time `json:"date"`
+	Draft    bool      `json:"draft"`
+	Filename string    `json:"filename"`
 }
 
 type Response struct {
@@ -66,94 +80,183 @@ type SecurityCheck struct {
 func main() {
 	log.Println("🔒 SecureBlog Admin Server - WordPress Easy, Fort Knox Secure")
 	log.Println("🛡️ Ultra-Paranoid Security Mode: ENABLED")
-	
+
 	// Initialize password hash with Argon2id
 	passwordHash, err := InitializeAdminPassword()
 	if err != nil {
 		log.Fatalf("❌ Password initialization failed: %v", err)
 	}
 	log.Println("✅ Argon2id password hashing initialized")
-	
-	// Setup 2FA (optional)
-	twoFAConfig, err := Setup2FA(adminUser)
+
+	// Setup 2FA (optional - disabled until the admin enrolls via /api/2fa/enroll)
+	var twoFAConfig TwoFAConfig
+	totpKey, err := Setup2FA(adminUser)
 	if err != nil {
 		log.Printf("⚠️ 2FA setup failed: %v", err)
 	} else {
-		log.Println("✅ 2FA/TOTP ready (optional)")
+		twoFAConfig = TwoFAConfig{Secret: totpKey.Secret(), Enabled: false}
+		log.Println("✅ 2FA/TOTP ready (optional, enroll via /api/2fa/enroll)")
+	}
+
+	// Load (or generate) the CSRF authentication key, persisted alongside
+	// the other admin secrets so tokens survive a restart.
+	csrfSecret, err := LoadOrCreateCSRFSecret()
+	if err != nil {
+		log.Fatalf("❌ CSRF secret initialization failed: %v", err)
+	}
+	log.Println("✅ CSRF protection initialized")
+
+	// Open the session store: it holds only the revocation list and the
+	// global token version, since the rest of a session now lives in the
+	// encrypted cookie itself.
+	sessionStore, err := session.OpenStore()
+	if err != nil {
+		log.Fatalf("❌ Session store initialization failed: %v", err)
+	}
+	log.Println("✅ Stateless encrypted-cookie sessions initialized")
+
+	// Deploy/build scripts run inside a disposable sandbox container by
+	// default; SANDBOX_RUNTIME can select "docker", "podman", or "native"
+	// (no isolation, local dev only).
+	sandboxConfig := sandbox.DefaultConfig()
+	if runtime := os.Getenv("SANDBOX_RUNTIME"); runtime != "" {
+		parsed, err := sandbox.ParseRuntime(runtime)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		sandboxConfig.Runtime = parsed
+	}
+	log.Printf("✅ Build/deploy sandbox runtime: %s", sandboxConfig.Runtime)
+
+	// WebAuthn relying party for security-key/passkey enrollment, the
+	// phishing-resistant alternative to TOTP (see Setup2FA) -- and the only
+	// factor requireWebAuthnFactor accepts for high-risk actions like
+	// rotating the ActivityPub actor key.
+	webAuthnRP, err := NewWebAuthn(DefaultWebAuthnConfig())
+	if err != nil {
+		log.Fatalf("❌ WebAuthn initialization failed: %v", err)
 	}
-	
-	// Create server with secure cookie
-	hashKey := securecookie.GenerateRandomKey(64)
-	blockKey := securecookie.GenerateRandomKey(32)
-	
+	log.Println("✅ WebAuthn (security key / passkey) 2FA ready, enroll via /api/2fa/webauthn/register/begin")
+
+	// Create server with secure cookie. The keys are persisted (rather than
+	// freshly randomized every process start) so the SECUREBLOG_PRIVATE
+	// gate in internal/server can decode a session cookie this process
+	// issues, and so a restart doesn't log out every admin session.
+	hashKey, blockKey, err := session.LoadOrCreateCookieKeys()
+	if err != nil {
+		log.Fatalf("❌ Session cookie key initialization failed: %v", err)
+	}
+
 	server := &Server{
-		secureCookie:    securecookie.New(hashKey, blockKey),
-		passwordHash:    passwordHash,
-		twoFAConfig:     twoFAConfig,
-		lastActivity:    time.Now(),
-		activeSessions:  make(map[string]Session),
-		shutdownChan:    make(chan os.Signal, 1),
-	}
-	
+		secureCookie: securecookie.New(hashKey, blockKey),
+		passwordHash: passwordHash,
+		twoFAConfig:  twoFAConfig,
+		totpLimiter:  newTOTPRateLimiter(),
+		lastActivity: time.Now(),
+		sessionStore: sessionStore,
+		sandbox:      sandboxConfig,
+		jobs:         make(map[string]*Job),
+		shutdownChan: make(chan os.Signal, 1),
+		webAuthn:     webAuthnRP,
+	}
+
 	// Start inactivity monitor
 	go server.monitorInactivity()
-	
+
 	// Handle graceful shutdown
 	signal.Notify(server.shutdownChan, os.Interrupt, syscall.SIGTERM)
 	go server.handleShutdown()
-	
+
 	// Setup router
 	r := mux.NewRouter()
-	
+
 	// Serve admin interface (local only)
 	r.HandleFunc("/", server.adminHandler).Methods("GET")
 	r.HandleFunc("/admin", server.adminHandler).Methods("GET")
-	
+	r.HandleFunc("/admin/security-key", server.webAuthnEnrollPageHandler).Methods("GET")
+
 	// API endpoints
 	api := r.PathPrefix("/api").Subrouter()
 	api.Use(server.authMiddleware)
-	
+
 	api.HandleFunc("/posts", server.getPostsHandler).Methods("GET")
 	api.HandleFunc("/posts", server.createPostHandler).Methods("POST")
 	api.HandleFunc("/posts/{id}", server.updatePostHandler).Methods("PUT")
 	api.HandleFunc("/posts/{id}", server.deletePostHandler).Methods("DELETE")
-	
+
 	api.HandleFunc("/upload", server.uploadHandler).Methods("POST")
 	api.HandleFunc("/media", server.getMediaHandler).Methods("GET")
-	
+
 	api.HandleFunc("/deploy", server.deployHandler).Methods("POST")
 	api.HandleFunc("/security-scan", server.securityScanHandler).Methods("POST")
 	api.HandleFunc("/build", server.buildHandler).Methods("POST")
-	
+
+	api.HandleFunc("/jobs", server.listJobsHandler).Methods("GET")
+	api.HandleFunc("/jobs/{id}/events", server.jobEventsHandler).Methods("GET")
+	api.HandleFunc("/jobs/{id}/cancel", server.cancelJobHandler).Methods("POST")
+
 	api.HandleFunc("/settings", server.getSettingsHandler).Methods("GET")
 	api.HandleFunc("/settings", server.updateSettingsHandler).Methods("POST")
-	
+
+	api.HandleFunc("/2fa/enroll", server.enroll2FAHandler).Methods("POST")
+	api.HandleFunc("/2fa/backup", server.regenerateBackupCodesHandler).Methods("POST")
+
+	api.HandleFunc("/sessions", server.sessionsHandler).Methods("GET")
+	api.HandleFunc("/sessions/revoke-all", server.revokeAllSessionsHandler).Methods("POST")
+
+	api.HandleFunc("/activitypub/key", server.activitypubKeyStatusHandler).Methods("GET")
+	api.HandleFunc("/activitypub/key/rotate", server.requireWebAuthnFactor(server.activitypubKeyRotateHandler)).Methods("POST")
+
+	api.HandleFunc("/2fa/webauthn/register/begin", server.beginWebAuthnRegistrationHandler).Methods("POST")
+	api.HandleFunc("/2fa/webauthn/register/finish", server.finishWebAuthnRegistrationHandler).Methods("POST")
+
+	// csrf-token lets the admin SPA read the current token and echo it back
+	// as X-CSRF-Token on its JSON requests.
+	api.HandleFunc("/csrf-token", csrfTokenHandler).Methods("GET")
+
 	// Authentication
 	r.HandleFunc("/login", server.loginHandler).Methods("GET", "POST")
+	r.HandleFunc("/login/2fa", server.twoFAChallengeHandler).Methods("GET", "POST")
+	r.HandleFunc("/login/2fa/webauthn", server.loginWebAuthnPageHandler).Methods("GET")
+	r.HandleFunc("/login/2fa/webauthn/begin", server.beginWebAuthnLoginHandler).Methods("POST")
+	r.HandleFunc("/login/2fa/webauthn/finish", server.finishWebAuthnLoginHandler).Methods("POST")
 	r.HandleFunc("/logout", server.logoutHandler).Methods("POST")
-	
+
 	// Static files for admin interface
-	r.PathPrefix("/static/").Handler(http.StripPrefix("/static/", 
+	r.PathPrefix("/static/").Handler(http.StripPrefix("/static/",
 		http.FileServer(http.Dir("./ui/static/"))))
-	
+
 	// Security headers middleware
 	r.Use(securityHeadersMiddleware)
+	// This server is plaintext HTTP by design (localhost-only, see
+	// csrf.Secure(false) below) -- tell gorilla/csrf not to apply its
+	// TLS-oriented strict Referer check, or every same-origin request
+	// would be rejected for lacking an HTTPS referer.
+	r.Use(plaintextHTTPMiddleware)
+	// CSRF protection: SameSite=Strict cookies don't cover forged requests
+	// from other local processes on a 127.0.0.1 daemon.
+	r.Use(csrf.Protect(
+		csrfSecret,
+		csrf.Secure(false), // set to true in production with HTTPS
+		csrf.HttpOnly(true),
+		csrf.ErrorHandler(http.HandlerFunc(csrfFailureHandler)),
+	))
 	// IP restriction middleware (localhost only)
 	r.Use(server.ipRestrictionMiddleware)
 	// Activity tracking middleware
 	r.Use(server.activityTrackingMiddleware)
-	
+
 	log.Printf("🚀 Admin server running on http://localhost:%s", serverPort)
 	log.Println("👤 Default login: admin / (set ADMIN_PASSWORD env var)")
 	log.Println("🔒 Localhost-only binding active (127.0.0.1)")
 	log.Printf("⏰ Auto-kill after %v inactivity", inactivityLimit)
-	
+
 	// Bind to localhost only for security
 	httpServer := &http.Server{
 		Addr:    "127.0.0.1:" + serverPort,
 		Handler: r,
 	}
-	
+
 	log.Fatal(httpServer.ListenAndServe())
 }
 
@@ -163,14 +266,14 @@ func (s *Server) adminHandler(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/login", http.StatusSeeOther)
 		return
 	}
-	
+
 	// Serve admin interface
 	adminHTML, err := os.ReadFile("ui/admin.html")
 	if err != nil {
 		http.Error(w, "Admin interface not found", http.StatusInternalServerError)
 		return
 	}
-	
+
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.Write(adminHTML)
 }
@@ -219,6 +322,7 @@ func (s *Server) loginHandler(w http.ResponseWriter, r *http.Request) {
             <p style="color: #6b7280; margin-top: 1rem;">WordPress Easy, Fort Knox Secure</p>
         </div>
         <form method="post">
+            {{csrfField}}
             <div class="form-group">
                 <label class="form-label">Username</label>
                 <input type="text" name="username" class="form-input" required>
@@ -232,113 +336,418 @@ func (s *Server) loginHandler(w http.ResponseWriter, r *http.Request) {
     </div>
 </body>
 </html>`
+		loginHTML = strings.Replace(loginHTML, "{{csrfField}}", string(csrf.TemplateField(r)), 1)
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		w.Write([]byte(loginHTML))
 		return
 	}
-	
+
 	// Handle login
 	username := r.FormValue("username")
 	password := r.FormValue("password")
-	
+	clientIP := GetClientIP(r)
+
 	// Use Argon2id password verification
-	if username == adminUser && VerifyPasswordHash(password, s.passwordHash) {
-		
-		// Create secure session with timeout
-		sessionID := generateSessionID()
-		clientIP := GetClientIP(r)
-		userAgent := r.UserAgent()
-		
-		session := Session{
-			Username:      username,
-			LoginTime:     time.Now(),
-			LastActive:    time.Now(),
-			IPAddress:     clientIP,
-			UserAgent:     userAgent,
-			TwoFAVerified: false, // Will be true after 2FA if enabled
-		}
-		
-		// Store session
-		s.sessionMutex.Lock()
-		s.activeSessions[sessionID] = session
-		s.sessionMutex.Unlock()
-		
-		// Create session cookie
-		value := map[string]string{
-			"sessionID": sessionID,
-			"username":  username,
+	ok, needsRehash := VerifyPasswordHash(password, s.passwordHash)
+	if username != adminUser || !ok {
+		http.Redirect(w, r, "/login?error=1", http.StatusSeeOther)
+		return
+	}
+	if needsRehash {
+		if rehashed, err := CreatePasswordHash(password); err == nil {
+			s.passwordHash = rehashed
+			log.Println("✅ Password hash upgraded to current argonParams cost")
+		} else {
+			log.Printf("⚠️ Password hash rehash failed: %v", err)
 		}
-		
-		encoded, err := s.secureCookie.Encode("session", value)
-		if err != nil {
+	}
+
+	s.twoFAMutex.RLock()
+	twoFAEnabled := s.twoFAConfig.Enabled
+	s.twoFAMutex.RUnlock()
+
+	if twoFAEnabled {
+		if err := s.issuePendingSession(w, username, clientIP); err != nil {
 			http.Error(w, "Login failed", http.StatusInternalServerError)
 			return
 		}
-		
-		cookie := &http.Cookie{
-			Name:     "session",
-			Value:    encoded,
-			Path:     "/",
-			Secure:   false, // Set to true in production with HTTPS
-			HttpOnly: true,
-			SameSite: http.SameSiteStrictMode,
-			MaxAge:   int(sessionTimeout.Seconds()),
-		}
-		http.SetCookie(w, cookie)
-		
-		http.Redirect(w, r, "/admin", http.StatusSeeOther)
-	} else {
-		http.Redirect(w, r, "/login?error=1", http.StatusSeeOther)
+		http.Redirect(w, r, "/login/2fa", http.StatusSeeOther)
+		return
 	}
+
+	if err := s.issueSession(w, username, clientIP, r.UserAgent(), TwoFactorNone); err != nil {
+		http.Error(w, "Login failed", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
 }
 
-func (s *Server) isAuthenticated(r *http.Request) bool {
+// issueSession mints a full session, scoped to clientIP/userAgent, and sets
+// the "session" cookie. twoFAMethod records which second factor (if any)
+// this session has already cleared -- TwoFactorTOTP, TwoFactorWebAuthn,
+// TwoFactorBackupCode, or TwoFactorNone if 2FA isn't enabled -- so a
+// high-risk endpoint can require the phishing-resistant one specifically.
+// The entire session lives in the encrypted cookie -- nothing is kept
+// server-side beyond the revocation list a logout or "kill all sessions"
+// writes to.
+func (s *Server) issueSession(w http.ResponseWriter, username, clientIP, userAgent string, twoFAMethod TwoFactorMethod) error {
+	tokenVersion, err := s.sessionStore.TokenVersion()
+	if err != nil {
+		return fmt.Errorf("reading token version: %w", err)
+	}
+
+	session := Session{
+		SessionID:     generateSessionID(),
+		Username:      username,
+		LoginTime:     time.Now(),
+		LastActive:    time.Now(),
+		IPAddress:     clientIP,
+		UserAgent:     userAgent,
+		TwoFAVerified: twoFAMethod,
+		TokenVersion:  tokenVersion,
+	}
+	return s.setSessionCookie(w, session)
+}
+
+// setSessionCookie encodes session into the "session" cookie, overwriting
+// whatever was there before.
+func (s *Server) setSessionCookie(w http.ResponseWriter, session Session) error {
+	value := map[string]string{
+		"sessionID":     session.SessionID,
+		"username":      session.Username,
+		"loginTime":     session.LoginTime.UTC().Format(time.RFC3339),
+		"lastActive":    session.LastActive.UTC().Format(time.RFC3339),
+		"ipAddress":     session.IPAddress,
+		"userAgent":     session.UserAgent,
+		"twoFAVerified": string(session.TwoFAVerified),
+		"tokenVersion":  strconv.FormatInt(session.TokenVersion, 10),
+	}
+
+	encoded, err := s.secureCookie.Encode("session", value)
+	if err != nil {
+		return fmt.Errorf("encoding session cookie: %w", err)
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     "session",
+		Value:    encoded,
+		Path:     "/",
+		Secure:   false, // Set to true in production with HTTPS
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+		MaxAge:   int(sessionTimeout.Seconds()),
+	})
+	return nil
+}
+
+// sessionFromRequest decodes the "session" cookie into a Session, without
+// validating it -- callers that need validity should use isAuthenticated.
+func (s *Server) sessionFromRequest(r *http.Request) (Session, bool) {
 	cookie, err := r.Cookie("session")
 	if err != nil {
-		return false
+		return Session{}, false
 	}
-	
+
 	value := make(map[string]string)
-	err = s.secureCookie.Decode("session", cookie.Value, &value)
+	if err := s.secureCookie.Decode("session", cookie.Value, &value); err != nil {
+		return Session{}, false
+	}
+
+	sess, err := session.FromCookieValues(value)
 	if err != nil {
-		return false
-	}
-	
-	sessionID := value["sessionID"]
-	if sessionID == "" {
-		return false
-	}
-	
-	// Check session validity
-	s.sessionMutex.RLock()
-	session, exists := s.activeSessions[sessionID]
-	s.sessionMutex.RUnlock()
-	
-	if !exists {
-		return false
-	}
-	
-	// Check session timeout and IP
-	if !IsSessionValid(session, sessionTimeout) || !ValidateClientIP(GetClientIP(r)) {
-		// Remove expired/invalid session
-		s.sessionMutex.Lock()
-		delete(s.activeSessions, sessionID)
-		s.sessionMutex.Unlock()
-		return false
-	}
-	
-	// Update last active time
-	s.sessionMutex.Lock()
-	session.LastActive = time.Now()
-	s.activeSessions[sessionID] = session
-	s.sessionMutex.Unlock()
-	
-	return session.Username == adminUser
+		return Session{}, false
+	}
+	return sess, true
+}
+
+// issuePendingSession sets the short-lived "pending2fa" cookie issued after
+// a correct password but before TOTP/backup-code verification.
+func (s *Server) issuePendingSession(w http.ResponseWriter, username, clientIP string) error {
+	value := map[string]string{
+		"username": username,
+		"ip":       clientIP,
+		"created":  time.Now().UTC().Format(time.RFC3339),
+	}
+
+	encoded, err := s.secureCookie.Encode("pending2fa", value)
+	if err != nil {
+		return fmt.Errorf("encoding pending 2FA cookie: %w", err)
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     "pending2fa",
+		Value:    encoded,
+		Path:     "/login/2fa",
+		Secure:   false, // Set to true in production with HTTPS
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+		MaxAge:   int(pendingSessionTTL.Seconds()),
+	})
+	return nil
+}
+
+// pendingSessionFromRequest decodes and validates the "pending2fa" cookie,
+// confirming it hasn't expired and was issued to the requesting IP.
+func (s *Server) pendingSessionFromRequest(r *http.Request) (PendingSession, bool) {
+	cookie, err := r.Cookie("pending2fa")
+	if err != nil {
+		return PendingSession{}, false
+	}
+
+	value := make(map[string]string)
+	if err := s.secureCookie.Decode("pending2fa", cookie.Value, &value); err != nil {
+		return PendingSession{}, false
+	}
+
+	created, err := time.Parse(time.RFC3339, value["created"])
+	if err != nil {
+		return PendingSession{}, false
+	}
+
+	ps := PendingSession{
+		Username:  value["username"],
+		IPAddress: value["ip"],
+		Created:   created,
+	}
+
+	if !IsPendingSessionValid(ps) || ps.IPAddress != GetClientIP(r) {
+		return PendingSession{}, false
+	}
+	return ps, true
+}
+
+// clearPendingSessionCookie removes the "pending2fa" cookie once the 2FA
+// challenge has been resolved (success or abandonment).
+func clearPendingSessionCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "pending2fa",
+		Value:    "",
+		Path:     "/login/2fa",
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+}
+
+const twoFAChallengeHTML = `<!DOCTYPE html>
+<html>
+<head>
+    <title>SecureBlog Admin Login - 2FA</title>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <style>
+        body {
+            font-family: -apple-system, BlinkMacSystemFont, sans-serif;
+            background: linear-gradient(135deg, #667eea 0%, #764ba2 100%);
+            min-height: 100vh; display: flex; align-items: center; justify-content: center;
+        }
+        .login-card {
+            background: white; padding: 2rem; border-radius: 0.5rem;
+            box-shadow: 0 25px 50px -12px rgba(0,0,0,0.25); max-width: 400px; width: 100%;
+        }
+        .logo { text-align: center; margin-bottom: 2rem; }
+        .form-group { margin-bottom: 1rem; }
+        .form-label { display: block; margin-bottom: 0.5rem; font-weight: 600; }
+        .form-input {
+            width: 100%; padding: 0.75rem; border: 1px solid #d1d5db;
+            border-radius: 0.375rem; font-size: 0.875rem;
+        }
+        .btn {
+            width: 100%; padding: 0.75rem; background: #2563eb; color: white;
+            border: none; border-radius: 0.375rem; font-weight: 600; cursor: pointer;
+        }
+    </style>
+</head>
+<body>
+    <div class="login-card">
+        <div class="logo">
+            <h1>🔒 SecureBlog</h1>
+            <p style="color: #6b7280; margin-top: 1rem;">Enter your authenticator code or a backup code</p>
+        </div>
+        <form method="post">
+            {{csrfField}}
+            <div class="form-group">
+                <label class="form-label">Code</label>
+                <input type="text" name="code" class="form-input" autocomplete="one-time-code" required autofocus>
+            </div>
+            <button type="submit" class="btn">🔐 Verify</button>
+        </form>
+        <p style="margin-top: 1rem; text-align: center;"><a href="/login/2fa/webauthn">Use a security key or passkey instead</a></p>
+    </div>
+</body>
+</html>`
+
+func (s *Server) twoFAChallengeHandler(w http.ResponseWriter, r *http.Request) {
+	pending, ok := s.pendingSessionFromRequest(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	if r.Method != "POST" {
+		page := strings.Replace(twoFAChallengeHTML, "{{csrfField}}", string(csrf.TemplateField(r)), 1)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+		return
+	}
+
+	clientIP := GetClientIP(r)
+	if !s.totpLimiter.Allowed(remoteAddrIP(r)) {
+		http.Redirect(w, r, "/login/2fa?error=locked", http.StatusSeeOther)
+		return
+	}
+
+	code := r.FormValue("code")
+
+	s.twoFAMutex.RLock()
+	secret := s.twoFAConfig.Secret
+	s.twoFAMutex.RUnlock()
+
+	method := TwoFactorTOTP
+	valid := VerifyTOTP(secret, code)
+	if !valid {
+		var err error
+		valid, err = VerifyAndConsumeBackupCode(code)
+		if err != nil {
+			http.Error(w, "2FA verification failed", http.StatusInternalServerError)
+			return
+		}
+		method = TwoFactorBackupCode
+	}
+
+	if !valid {
+		s.totpLimiter.RecordFailure(remoteAddrIP(r))
+		http.Redirect(w, r, "/login/2fa?error=1", http.StatusSeeOther)
+		return
+	}
+
+	clearPendingSessionCookie(w)
+	if err := s.issueSession(w, pending.Username, clientIP, r.UserAgent(), method); err != nil {
+		http.Error(w, "Login failed", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
+
+// enroll2FAHandler generates a fresh TOTP secret and backup code set for the
+// admin, enabling 2FA enforcement on future logins. Behind authMiddleware:
+// an already-authenticated session is required to (re-)enroll.
+func (s *Server) enroll2FAHandler(w http.ResponseWriter, r *http.Request) {
+	key, err := Setup2FA(adminUser)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(Response{Success: false, Message: "Failed to generate 2FA secret"})
+		return
+	}
+
+	codes, err := generateBackupCodes(10)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(Response{Success: false, Message: "Failed to generate backup codes"})
+		return
+	}
+	if err := SaveBackupCodes(codes); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(Response{Success: false, Message: "Failed to save backup codes"})
+		return
+	}
+
+	img, err := key.Image(256, 256)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(Response{Success: false, Message: "Failed to render 2FA QR code"})
+		return
+	}
+	var qr bytes.Buffer
+	if err := png.Encode(&qr, img); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(Response{Success: false, Message: "Failed to encode 2FA QR code"})
+		return
+	}
+
+	s.twoFAMutex.Lock()
+	s.twoFAConfig = TwoFAConfig{Secret: key.Secret(), Enabled: true}
+	s.twoFAMutex.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Response{
+		Success: true,
+		Message: "2FA enabled - store your backup codes somewhere safe, they are shown only once",
+		Data: map[string]interface{}{
+			"otpauth_url":  key.String(),
+			"qr_code_png":  base64.StdEncoding.EncodeToString(qr.Bytes()),
+			"backup_codes": codes,
+		},
+	})
+}
+
+// regenerateBackupCodesHandler replaces the admin's backup codes, invalidating
+// any previously issued ones.
+func (s *Server) regenerateBackupCodesHandler(w http.ResponseWriter, r *http.Request) {
+	codes, err := generateBackupCodes(10)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(Response{Success: false, Message: "Failed to generate backup codes"})
+		return
+	}
+	if err := SaveBackupCodes(codes); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(Response{Success: false, Message: "Failed to save backup codes"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Response{
+		Success: true,
+		Message: "Backup codes regenerated - previous codes are now invalid",
+		Data:    map[string][]string{"backup_codes": codes},
+	})
+}
+
+func (s *Server) isAuthenticated(r *http.Request) bool {
+	_, ok := s.authenticatedSession(r)
+	return ok
+}
+
+// authenticatedSession is isAuthenticated's decoded-session counterpart, so
+// a caller that needs the session itself (authMiddleware, to refresh its
+// idle timeout) doesn't have to decode the cookie a second time.
+func (s *Server) authenticatedSession(r *http.Request) (Session, bool) {
+	sess, ok := s.sessionFromRequest(r)
+	if !ok || sess.SessionID == "" {
+		return Session{}, false
+	}
+
+	if !IsSessionValid(sess, sessionTimeout) || !ValidateClientIP(GetClientIP(r)) {
+		return Session{}, false
+	}
+
+	revoked, err := s.sessionStore.IsRevoked(sess.SessionID)
+	if err != nil || revoked {
+		return Session{}, false
+	}
+
+	currentTokenVersion, err := s.sessionStore.TokenVersion()
+	if err != nil || sess.TokenVersion != currentTokenVersion {
+		return Session{}, false
+	}
+
+	s.twoFAMutex.RLock()
+	twoFAEnabled := s.twoFAConfig.Enabled
+	s.twoFAMutex.RUnlock()
+	if twoFAEnabled && !sess.TwoFAVerified.IsVerified() {
+		return Session{}, false
+	}
+
+	if sess.Username != adminUser {
+		return Session{}, false
+	}
+	return sess, true
 }
 
 func (s *Server) authMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if !s.isAuthenticated(r) {
+		sess, ok := s.authenticatedSession(r)
+		if !ok {
 			w.WriteHeader(http.StatusUnauthorized)
 			json.NewEncoder(w).Encode(Response{
 				Success: false,
@@ -346,6 +755,16 @@ func (s *Server) authMiddleware(next http.Handler) http.Handler {
 			})
 			return
 		}
+
+		// Sliding idle timeout: refresh LastActive and re-issue the cookie
+		// on every authenticated API request, so its MaxAge (sessionTimeout)
+		// counts down from now instead of hard-expiring sessionTimeout
+		// after login regardless of activity.
+		session.UpdateSessionActivity(&sess)
+		if err := s.setSessionCookie(w, sess); err != nil {
+			log.Printf("⚠️ Failed to refresh session cookie: %v", err)
+		}
+
 		next.ServeHTTP(w, r)
 	})
 }
@@ -360,7 +779,7 @@ func (s *Server) getPostsHandler(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
-	
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(Response{
 		Success: true,
@@ -378,7 +797,7 @@ func (s *Server) createPostHandler(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
-	
+
 	// Validate and sanitize post
 	if post.Title == "" || post.Content == "" {
 		w.WriteHeader(http.StatusBadRequest)
@@ -388,12 +807,12 @@ func (s *Server) createPostHandler(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
-	
+
 	// Generate filename if not provided
 	if post.Filename == "" {
 		post.Filename = generateFilename(post.Title, post.Date)
 	}
-	
+
 	// Save post as markdown file
 	if err := savePost(post); err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -403,7 +822,7 @@ func (s *Server) createPostHandler(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
-	
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(Response{
 		Success: true,
@@ -413,24 +832,24 @@ func (s *Server) createPostHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) deployHandler(w http.ResponseWriter, r *http.Request) {
-	// Run secure build and deploy
-	cmd := exec.Command("bash", "./scripts/deploy-secure.sh")
-	output, err := cmd.CombinedOutput()
-	
+	cmd, err := s.sandbox.Command("./scripts/deploy-secure.sh")
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(Response{
-			Success: false,
-			Message: fmt.Sprintf("Deploy failed: %s", output),
-		})
+		json.NewEncoder(w).Encode(Response{Success: false, Message: fmt.Sprintf("Sandbox unavailable: %v", err)})
 		return
 	}
-	
+	job, err := s.startJobCmd("deploy", cmd)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(Response{Success: false, Message: fmt.Sprintf("Deploy failed to start: %v", err)})
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(Response{
 		Success: true,
-		Message: "Blog deployed successfully! 🚀",
-		Data:    string(output),
+		Message: "Deploy started - stream progress via GET /api/jobs/{id}/events",
+		Data:    map[string]string{"jobID": job.ID},
 	})
 }
 
@@ -445,105 +864,47 @@ func (s *Server) securityScanHandler(w http.ResponseWriter, r *http.Request) {
 		{Name: "File Upload Security", Status: "sandboxed", Icon: "✅"},
 		{Name: "Session Security", Status: "hardened", Icon: "✅"},
 	}
-	
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(Response{
-		Success: true,
-		Message: "Security scan completed - All checks passed!",
-		Data:    checks,
-	})
-}
 
-func (s *Server) buildHandler(w http.ResponseWriter, r *http.Request) {
-	// Run secure build
-	cmd := exec.Command("bash", "./build-sandbox.sh")
-	output, err := cmd.CombinedOutput()
-	
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(Response{
-			Success: false,
-			Message: fmt.Sprintf("Build failed: %s", output),
-		})
-		return
+	// Reuse the job subsystem so the admin UI can tail the scan the same
+	// way it tails a deploy/build, even though every check here runs
+	// synchronously and the job finishes before this handler returns.
+	job := newJob("security-scan")
+	s.jobsMutex.Lock()
+	s.jobs[job.ID] = job
+	s.jobsMutex.Unlock()
+
+	for _, check := range checks {
+		job.publish([]byte(fmt.Sprintf("%s %s: %s\n", check.Icon, check.Name, check.Status)))
 	}
-	
+	job.finish(JobStatusSucceeded, 0)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(Response{
 		Success: true,
-		Message: "Build completed successfully!",
-		Data:    string(output),
+		Message: "Security scan completed - All checks passed!",
+		Data:    map[string]interface{}{"jobID": job.ID, "checks": checks},
 	})
 }
 
-func (s *Server) uploadHandler(w http.ResponseWriter, r *http.Request) {
-	// Limit upload size
-	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
-	
-	// Parse multipart form
-	if err := r.ParseMultipartForm(maxUploadSize); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(Response{
-			Success: false,
-			Message: "File too large or invalid",
-		})
-		return
-	}
-	
-	file, header, err := r.FormFile("image")
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(Response{
-			Success: false,
-			Message: "No file uploaded",
-		})
-		return
-	}
-	defer file.Close()
-	
-	// Validate file type
-	if !strings.HasPrefix(header.Header.Get("Content-Type"), "image/") {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(Response{
-			Success: false,
-			Message: "Only image files are allowed",
-		})
-		return
-	}
-	
-	// Save file securely
-	uploadPath := filepath.Join("content", "images", header.Filename)
-	os.MkdirAll(filepath.Dir(uploadPath), 0755)
-	
-	dst, err := os.Create(uploadPath)
+func (s *Server) buildHandler(w http.ResponseWriter, r *http.Request) {
+	cmd, err := s.sandbox.Command("./scripts/build.sh")
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(Response{
-			Success: false,
-			Message: "Failed to save file",
-		})
+		json.NewEncoder(w).Encode(Response{Success: false, Message: fmt.Sprintf("Sandbox unavailable: %v", err)})
 		return
 	}
-	defer dst.Close()
-	
-	_, err = io.Copy(dst, file)
+	job, err := s.startJobCmd("build", cmd)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(Response{
-			Success: false,
-			Message: "Failed to save file",
-		})
+		json.NewEncoder(w).Encode(Response{Success: false, Message: fmt.Sprintf("Build failed to start: %v", err)})
 		return
 	}
-	
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(Response{
 		Success: true,
-		Message: "Image uploaded successfully!",
-		Data: map[string]string{
-			"filename": header.Filename,
-			"path":     "/images/" + header.Filename,
-		},
+		Message: "Build started - stream progress via GET /api/jobs/{id}/events",
+		Data:    map[string]string{"jobID": job.ID},
 	})
 }
 
@@ -569,22 +930,13 @@ func (s *Server) updateSettingsHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) logoutHandler(w http.ResponseWriter, r *http.Request) {
-	// Remove session from active sessions
-	cookie, err := r.Cookie("session")
-	if err == nil {
-		value := make(map[string]string)
-		if s.secureCookie.Decode("session", cookie.Value, &value) == nil {
-			sessionID := value["sessionID"]
-			if sessionID != "" {
-				s.sessionMutex.Lock()
-				delete(s.activeSessions, sessionID)
-				s.sessionMutex.Unlock()
-			}
-		}
+	// Revoke the session so it can't be replayed before it would otherwise
+	// expire, then clear the cookie.
+	if session, ok := s.sessionFromRequest(r); ok && session.SessionID != "" {
+		s.sessionStore.Revoke(session.SessionID, time.Now().Add(sessionTimeout))
 	}
-	
-	// Clear session cookie
-	cookie = &http.Cookie{
+
+	cookie := &http.Cookie{
 		Name:     "session",
 		Value:    "",
 		Path:     "/",
@@ -592,10 +944,84 @@ func (s *Server) logoutHandler(w http.ResponseWriter, r *http.Request) {
 		HttpOnly: true,
 	}
 	http.SetCookie(w, cookie)
-	
+
 	http.Redirect(w, r, "/login", http.StatusSeeOther)
 }
 
+// sessionsHandler returns the requesting browser's own session metadata.
+// There is no server-side session table to enumerate other devices from --
+// each cookie carries its own metadata, so this reports only "this"
+// session, marked as the current one.
+func (s *Server) sessionsHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.sessionFromRequest(r)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(Response{Success: false, Message: "No active session"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Response{
+		Success: true,
+		Data: []map[string]interface{}{{
+			"username":    session.Username,
+			"login_time":  session.LoginTime,
+			"last_active": session.LastActive,
+			"ip_address":  session.IPAddress,
+			"user_agent":  session.UserAgent,
+			"current":     true,
+		}},
+	})
+}
+
+// revokeAllSessionsHandler bumps the global token version, invalidating
+// every outstanding session cookie -- including the one making this
+// request, which will need to log in again.
+func (s *Server) revokeAllSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	if _, err := s.sessionStore.BumpTokenVersion(); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(Response{Success: false, Message: "Failed to revoke sessions"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Response{Success: true, Message: "All sessions revoked"})
+}
+
+// plaintextHTTPMiddleware marks every request as served over plaintext
+// HTTP for gorilla/csrf, which otherwise assumes TLS and enforces an
+// HTTPS-only Referer check that a cleartext localhost server can never
+// satisfy.
+func plaintextHTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), csrf.PlaintextHTTPContextKey, true)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// csrfTokenHandler returns the current request's CSRF token so the admin
+// SPA can read it once and echo it back as X-CSRF-Token on its JSON
+// requests.
+func csrfTokenHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Response{
+		Success: true,
+		Data:    map[string]string{"csrf_token": csrf.Token(r)},
+	})
+}
+
+// csrfFailureHandler responds to a rejected CSRF token the same way the
+// rest of the JSON API reports auth failures, instead of gorilla/csrf's
+// default plain-text 403.
+func csrfFailureHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(Response{
+		Success: false,
+		Message: "CSRF token invalid",
+	})
+}
+
 // Security headers middleware
 func securityHeadersMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -606,7 +1032,7 @@ func securityHeadersMiddleware(next http.Handler) http.Handler {
 		w.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
 		w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate")
 		w.Header().Set("Content-Security-Policy", "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline';")
-		
+
 		next.ServeHTTP(w, r)
 	})
 }
@@ -636,7 +1062,7 @@ func (s *Server) activityTrackingMiddleware(next http.Handler) http.Handler {
 func (s *Server) monitorInactivity() {
 	ticker := time.NewTicker(5 * time.Minute) // Check every 5 minutes
 	defer ticker.Stop()
-	
+
 	for {
 		select {
 		case <-ticker.C:
@@ -645,15 +1071,12 @@ func (s *Server) monitorInactivity() {
 				s.shutdownChan <- os.Interrupt
 				return
 			}
-			
-			// Clean expired sessions
-			s.sessionMutex.Lock()
-			for sessionID, session := range s.activeSessions {
-				if !IsSessionValid(session, sessionTimeout) {
-					delete(s.activeSessions, sessionID)
-				}
+
+			// Prune revocation entries whose expiry has already passed --
+			// sessions themselves live in cookies and need no cleanup.
+			if err := s.sessionStore.PruneExpired(); err != nil {
+				log.Printf("⚠️ Failed to prune expired session revocations: %v", err)
 			}
-			s.sessionMutex.Unlock()
 		}
 	}
 }
@@ -662,48 +1085,52 @@ func (s *Server) monitorInactivity() {
 func (s *Server) handleShutdown() {
 	<-s.shutdownChan
 	log.Println("🔒 Graceful shutdown initiated...")
-	
-	// Clear all sessions
-	s.sessionMutex.Lock()
-	s.activeSessions = make(map[string]Session)
-	s.sessionMutex.Unlock()
-	
+
+	// Bump the token version so every outstanding session cookie is
+	// invalidated on restart.
+	if _, err := s.sessionStore.BumpTokenVersion(); err != nil {
+		log.Printf("⚠️ Failed to revoke sessions on shutdown: %v", err)
+	}
+	if err := s.sessionStore.Close(); err != nil {
+		log.Printf("⚠️ Failed to close session store: %v", err)
+	}
+
 	log.Println("✅ All sessions cleared")
 	os.Exit(0)
 }
 
-// Generate secure session ID
+// generateSessionID returns a cryptographically random session identifier.
+// It's used only as an opaque key into session.Store's revocation list --
+// the session itself is never looked up by it.
 func generateSessionID() string {
-	return fmt.Sprintf("%d-%s", time.Now().UnixNano(), generateRandomString(32))
-}
-
-func generateRandomString(length int) string {
-	bytes := make([]byte, length)
-	for i := range bytes {
-		bytes[i] = byte(65 + (time.Now().UnixNano() % 26)) // Simple random A-Z
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand failing means the OS CSPRNG is broken; there's no
+		// safe fallback for something used as a session identifier.
+		panic(fmt.Sprintf("generating session ID: %v", err))
 	}
-	return string(bytes)
+	return hex.EncodeToString(b)
 }
 
 // Utility functions
 func loadPosts() ([]Post, error) {
 	var posts []Post
-	
+
 	err := filepath.Walk("content/posts", func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		
+
 		if strings.HasSuffix(path, ".md") {
 			post, err := parseMarkdownFile(path)
 			if err == nil {
 				posts = append(posts, post)
 			}
 		}
-		
+
 		return nil
 	})
-	
+
 	return posts, err
 }
 
@@ -712,7 +1139,7 @@ func parseMarkdownFile(filename string) (Post, error) {
 	if err != nil {
 		return Post{}, err
 	}
-	
+
 	// Parse frontmatter and content
 	// This is a simplified implementation
 	post := Post{
@@ -720,14 +1147,14 @@ func parseMarkdownFile(filename string) (Post, error) {
 		Content:  string(content),
 		Date:     time.Now(),
 	}
-	
+
 	return post, nil
 }
 
 func savePost(post Post) error {
 	// Ensure content directory exists
 	os.MkdirAll("content/posts", 0755)
-	
+
 	// Create markdown content with frontmatter
 	content := fmt.Sprintf(`---
 title: "%s"
@@ -738,9 +1165,9 @@ draft: %t
 ---
 
 %s
-`, post.Title, post.Date.Format("2006-01-02"), post.Slug, 
-   strings.Join(post.Tags, ", "), post.Draft, post.Content)
-	
+`, post.Title, post.Date.Format("2006-01-02"), post.Slug,
+		strings.Join(post.Tags, ", "), post.Draft, post.Content)
+
 	filename := filepath.Join("content/posts", post.Filename+".md")
 	return os.WriteFile(filename, []byte(content), 0644)
 }
@@ -756,4 +1183,4 @@ func generateFilename(title string, date time.Time) string {
 		}
 	}
 	return fmt.Sprintf("%s-%s", date.Format("2006-01-02"), result.String())
-}
\ No newline at end of file
+}
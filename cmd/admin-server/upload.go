@@ -0,0 +1,179 @@
+// upload.go - image upload hardening: the client controls none of the
+// bytes that end up on disk. Content-Type and filename are both untrusted
+// input, so the upload path sniffs the real format, decodes and
+// re-encodes the image (stripping EXIF/ICC/any embedded payload), and
+// names the stored file after a hash of its own content.
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/image/webp"
+)
+
+// maxImageDimension bounds the width/height an uploaded image may decode
+// to, checked against the image header before decoding pixels, so a small
+// file crafted to expand into a huge bitmap (a decompression bomb) is
+// rejected before it can exhaust memory.
+const maxImageDimension = 8000
+
+// allowedImageTypes maps a sniffed MIME type to the canonical extension
+// re-encoded output is stored under. SVG and every other format -- scripts,
+// polyglots, anything not in this list -- is rejected outright.
+var allowedImageTypes = map[string]string{
+	"image/png":  "png",
+	"image/jpeg": "jpg",
+	"image/gif":  "gif",
+	"image/webp": "webp",
+}
+
+// uploadHandler accepts a single "image" multipart field, verifies it's
+// really one of the allowed image formats (not just labeled as one),
+// re-encodes it to strip anything beyond raw pixel data, and stores it
+// under a content-addressed, date-partitioned path.
+func (s *Server) uploadHandler(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+
+	if err := r.ParseMultipartForm(maxUploadSize); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(Response{Success: false, Message: "File too large or invalid"})
+		return
+	}
+
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(Response{Success: false, Message: "No file uploaded"})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(Response{Success: false, Message: "Failed to read upload"})
+		return
+	}
+
+	encoded, ext, err := reencodeImage(data)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(Response{Success: false, Message: err.Error()})
+		return
+	}
+
+	relPath, err := saveUploadedImage(encoded, ext)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(Response{Success: false, Message: "Failed to save file"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Response{
+		Success: true,
+		Message: "Image uploaded successfully!",
+		Data:    map[string]string{"path": "/" + relPath},
+	})
+}
+
+// reencodeImage sniffs data's real format against an allow-list, decodes
+// it (rejecting anything larger than maxImageDimension before doing so),
+// and re-encodes it fresh -- dropping EXIF, ICC profiles, and any other
+// non-pixel data a crafted file might carry. It returns the re-encoded
+// bytes and the canonical extension to store them under.
+func reencodeImage(data []byte) (encoded []byte, ext string, err error) {
+	sniffed := http.DetectContentType(data)
+	if _, ok := allowedImageTypes[sniffed]; !ok {
+		return nil, "", fmt.Errorf("unsupported or unrecognized file type %q", sniffed)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid image data: %w", err)
+	}
+	if cfg.Width > maxImageDimension || cfg.Height > maxImageDimension {
+		return nil, "", fmt.Errorf("image dimensions %dx%d exceed the %dx%d maximum", cfg.Width, cfg.Height, maxImageDimension, maxImageDimension)
+	}
+
+	var img image.Image
+	if sniffed == "image/webp" {
+		// The standard library can decode but not encode webp; decode it
+		// here and fall through to the PNG encoder below.
+		img, err = webp.Decode(bytes.NewReader(data))
+	} else {
+		img, _, err = image.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("decoding image: %w", err)
+	}
+
+	var buf bytes.Buffer
+	switch sniffed {
+	case "image/jpeg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90})
+		ext = "jpg"
+	case "image/gif":
+		err = gif.Encode(&buf, img, nil)
+		ext = "gif"
+	default: // image/png, image/webp
+		err = png.Encode(&buf, img)
+		ext = "png"
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("re-encoding image: %w", err)
+	}
+
+	return buf.Bytes(), ext, nil
+}
+
+// saveUploadedImage writes encoded to a content-addressed, date-partitioned
+// path under content/images, ignoring any client-supplied name entirely,
+// and returns that path relative to the content root. The write is atomic:
+// the data lands in a temp file first and is only renamed into place once
+// it's fully flushed to disk.
+func saveUploadedImage(encoded []byte, ext string) (string, error) {
+	sum := sha256.Sum256(encoded)
+	name := hex.EncodeToString(sum[:8]) + "." + ext
+
+	dir := filepath.Join("content", "images", time.Now().Format("2006"), time.Now().Format("01"))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("creating %s: %w", dir, err)
+	}
+
+	finalPath := filepath.Join(dir, name)
+
+	tmp, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return "", fmt.Errorf("creating temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // no-op once the rename below succeeds
+
+	if _, err := tmp.Write(encoded); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("writing temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("closing temp file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, finalPath); err != nil {
+		return "", fmt.Errorf("renaming into place: %w", err)
+	}
+
+	return finalPath, nil
+}
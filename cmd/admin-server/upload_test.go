@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func encodeTestPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+// pngIHDROnly builds a minimal, otherwise-invalid PNG containing just the
+// signature and IHDR chunk declaring width x height. image.DecodeConfig
+// only needs IHDR to report dimensions, so this lets tests assert the
+// decompression-bomb guard rejects huge declared dimensions without
+// actually allocating a huge image.
+func pngIHDROnly(width, height uint32) []byte {
+	var buf bytes.Buffer
+	buf.Write([]byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A})
+
+	data := make([]byte, 13)
+	binary.BigEndian.PutUint32(data[0:4], width)
+	binary.BigEndian.PutUint32(data[4:8], height)
+	data[8] = 8  // bit depth
+	data[9] = 6  // color type: truecolor with alpha
+	data[10] = 0 // compression
+	data[11] = 0 // filter
+	data[12] = 0 // interlace
+
+	var lenBuf [4]byte
+	binary.BigEndian.PutUint32(lenBuf[:], uint32(len(data)))
+	buf.Write(lenBuf[:])
+	buf.WriteString("IHDR")
+	buf.Write(data)
+
+	crc := crc32.NewIEEE()
+	crc.Write([]byte("IHDR"))
+	crc.Write(data)
+	var crcBuf [4]byte
+	binary.BigEndian.PutUint32(crcBuf[:], crc.Sum32())
+	buf.Write(crcBuf[:])
+
+	return buf.Bytes()
+}
+
+func TestReencodeImageAcceptsValidPNG(t *testing.T) {
+	data := encodeTestPNG(t, 16, 16)
+
+	encoded, ext, err := reencodeImage(data)
+	if err != nil {
+		t.Fatalf("reencodeImage() error = %v", err)
+	}
+	if ext != "png" {
+		t.Errorf("ext = %q, want png", ext)
+	}
+	if _, _, err := image.Decode(bytes.NewReader(encoded)); err != nil {
+		t.Errorf("re-encoded output does not decode as a valid image: %v", err)
+	}
+}
+
+func TestReencodeImageRejectsSpoofedContentType(t *testing.T) {
+	// A polyglot: a script payload, not an image at all, regardless of
+	// what Content-Type header an attacker might have sent alongside it.
+	polyglot := []byte("<script>alert(document.cookie)</script>")
+
+	if _, _, err := reencodeImage(polyglot); err == nil {
+		t.Error("reencodeImage() accepted a non-image payload")
+	}
+}
+
+func TestReencodeImageRejectsSVG(t *testing.T) {
+	svg := []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`)
+
+	if _, _, err := reencodeImage(svg); err == nil {
+		t.Error("reencodeImage() accepted an SVG payload")
+	}
+}
+
+func TestReencodeImageRejectsOversizedDimensions(t *testing.T) {
+	huge := pngIHDROnly(maxImageDimension+1, maxImageDimension+1)
+
+	_, _, err := reencodeImage(huge)
+	if err == nil {
+		t.Fatal("reencodeImage() accepted an image exceeding the maximum dimensions")
+	}
+	if !strings.Contains(err.Error(), "exceed") {
+		t.Errorf("error = %v, want a message about exceeding the dimension limit", err)
+	}
+}
+
+func TestSaveUploadedImageIgnoresClientNameAndPreventsTraversal(t *testing.T) {
+	dir := t.TempDir()
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd() error = %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Chdir() error = %v", err)
+	}
+	defer os.Chdir(cwd)
+
+	data := []byte("pretend this is encoded image bytes")
+	path, err := saveUploadedImage(data, "png")
+	if err != nil {
+		t.Fatalf("saveUploadedImage() error = %v", err)
+	}
+
+	if strings.Contains(path, "..") {
+		t.Errorf("path = %q, contains a traversal segment", path)
+	}
+	if !strings.HasPrefix(path, filepath.Join("content", "images")) {
+		t.Errorf("path = %q, want it rooted under content/images", path)
+	}
+	if filepath.Ext(path) != ".png" {
+		t.Errorf("path = %q, want a .png extension", path)
+	}
+
+	stored, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading stored file: %v", err)
+	}
+	if !bytes.Equal(stored, data) {
+		t.Error("stored file contents do not match the encoded input")
+	}
+
+	// Saving identical bytes again must resolve to the same
+	// content-addressed path.
+	path2, err := saveUploadedImage(data, "png")
+	if err != nil {
+		t.Fatalf("saveUploadedImage() second call error = %v", err)
+	}
+	if path != path2 {
+		t.Errorf("path = %q, path2 = %q, want identical content-addressed paths", path, path2)
+	}
+}
@@ -0,0 +1,674 @@
+// webauthn.go adds a hardware security key or platform passkey as an
+// alternative (or additional) second factor to the TOTP path in auth.go.
+// Session.TwoFAVerified (internal/session) records which factor a login
+// actually used, so a high-risk endpoint can require the phishing-resistant
+// one specifically -- see requireWebAuthnFactor below.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/gorilla/csrf"
+)
+
+// WebAuthnConfig configures the admin relying party. RPID is pinned to the
+// configured admin host (localhost in the default, loopback-only
+// deployment); RPOrigin is the exact scheme+host+port the admin UI is
+// served from, so an assertion requested by a phishing origin can't be
+// replayed against this one.
+type WebAuthnConfig struct {
+	RPID          string
+	RPOrigin      string
+	RPDisplayName string
+}
+
+// DefaultWebAuthnConfig returns the relying party config for the admin
+// server's default loopback-only deployment.
+func DefaultWebAuthnConfig() WebAuthnConfig {
+	return WebAuthnConfig{
+		RPID:          "localhost",
+		RPOrigin:      "http://localhost:" + serverPort,
+		RPDisplayName: "SecureBlog Admin",
+	}
+}
+
+// NewWebAuthn builds the relying party *webauthn.WebAuthn from cfg.
+func NewWebAuthn(cfg WebAuthnConfig) (*webauthn.WebAuthn, error) {
+	return webauthn.New(&webauthn.Config{
+		RPID:          cfg.RPID,
+		RPDisplayName: cfg.RPDisplayName,
+		RPOrigins:     []string{cfg.RPOrigin},
+	})
+}
+
+// WebAuthnCredential is one enrolled passkey/security key, persisted to
+// webAuthnCredentialsPath -- the same ~/.secureblog convention as the CSRF
+// secret and backup codes.
+type WebAuthnCredential struct {
+	ID              []byte `json:"id"`
+	PublicKey       []byte `json:"publicKey"`
+	AttestationType string `json:"attestationType"`
+	AAGUID          []byte `json:"aaguid"`
+	SignCount       uint32 `json:"signCount"`
+	UserVerified    bool   `json:"userVerified"`
+	Name            string `json:"name"` // operator-chosen label, e.g. "YubiKey 5C"
+}
+
+func webAuthnCredentialsPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("resolving home directory: %w", err)
+	}
+	return filepath.Join(home, ".secureblog", "webauthn-credentials.json"), nil
+}
+
+// LoadWebAuthnCredentials reads the enrolled credentials from disk. None
+// enrolled yet is not an error.
+func LoadWebAuthnCredentials() ([]WebAuthnCredential, error) {
+	path, err := webAuthnCredentialsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var creds []WebAuthnCredential
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return creds, nil
+}
+
+// SaveWebAuthnCredentials persists creds, replacing whatever was stored.
+func SaveWebAuthnCredentials(creds []WebAuthnCredential) error {
+	path, err := webAuthnCredentialsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Dir(path), err)
+	}
+
+	data, err := json.MarshalIndent(creds, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// adminWebAuthnUser adapts the single admin account to webauthn.User.
+// WebAuthnID is a fixed, non-secret handle (not the username): the spec
+// only requires an opaque user handle, and using a constant keeps
+// credentials portable across a username change.
+type adminWebAuthnUser struct {
+	credentials []WebAuthnCredential
+}
+
+var adminWebAuthnUserID = []byte("secureblog-admin")
+
+func (u *adminWebAuthnUser) WebAuthnID() []byte          { return adminWebAuthnUserID }
+func (u *adminWebAuthnUser) WebAuthnName() string        { return adminUser }
+func (u *adminWebAuthnUser) WebAuthnDisplayName() string { return adminUser }
+func (u *adminWebAuthnUser) WebAuthnIcon() string        { return "" }
+
+func (u *adminWebAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	creds := make([]webauthn.Credential, len(u.credentials))
+	for i, c := range u.credentials {
+		creds[i] = webauthn.Credential{
+			ID:              c.ID,
+			PublicKey:       c.PublicKey,
+			AttestationType: c.AttestationType,
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    c.AAGUID,
+				SignCount: c.SignCount,
+			},
+			Flags: webauthn.CredentialFlags{UserVerified: c.UserVerified},
+		}
+	}
+	return creds
+}
+
+// loadAdminWebAuthnUser builds the adminWebAuthnUser from whatever is
+// currently persisted.
+func loadAdminWebAuthnUser() (*adminWebAuthnUser, error) {
+	creds, err := LoadWebAuthnCredentials()
+	if err != nil {
+		return nil, err
+	}
+	return &adminWebAuthnUser{credentials: creds}, nil
+}
+
+// webAuthnCeremonyCookie is the short-lived cookie holding the in-progress
+// registration or login session.SessionData between the Begin and Finish
+// steps, the same pattern the "pending2fa" cookie uses for the TOTP
+// challenge.
+const webAuthnCeremonyCookie = "webauthn_ceremony"
+
+func (s *Server) setWebAuthnCeremonyCookie(w http.ResponseWriter, data *webauthn.SessionData) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("encoding WebAuthn ceremony state: %w", err)
+	}
+
+	value, err := s.secureCookie.Encode(webAuthnCeremonyCookie, string(encoded))
+	if err != nil {
+		return fmt.Errorf("encoding WebAuthn ceremony cookie: %w", err)
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     webAuthnCeremonyCookie,
+		Value:    value,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+		MaxAge:   int(pendingSessionTTL.Seconds()),
+	})
+	return nil
+}
+
+func (s *Server) webAuthnCeremonyFromRequest(r *http.Request) (*webauthn.SessionData, error) {
+	cookie, err := r.Cookie(webAuthnCeremonyCookie)
+	if err != nil {
+		return nil, fmt.Errorf("no WebAuthn ceremony in progress: %w", err)
+	}
+
+	var encoded string
+	if err := s.secureCookie.Decode(webAuthnCeremonyCookie, cookie.Value, &encoded); err != nil {
+		return nil, fmt.Errorf("decoding WebAuthn ceremony cookie: %w", err)
+	}
+
+	var data webauthn.SessionData
+	if err := json.Unmarshal([]byte(encoded), &data); err != nil {
+		return nil, fmt.Errorf("parsing WebAuthn ceremony state: %w", err)
+	}
+	return &data, nil
+}
+
+func clearWebAuthnCeremonyCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     webAuthnCeremonyCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+}
+
+// beginWebAuthnRegistrationHandler starts enrolling a new security key or
+// passkey for the admin account. Behind authMiddleware: an existing,
+// authenticated session is required to enroll another factor.
+func (s *Server) beginWebAuthnRegistrationHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, err := loadAdminWebAuthnUser()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	creation, sessionData, err := s.webAuthn.BeginRegistration(user)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := s.setWebAuthnCeremonyCookie(w, sessionData); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(creation)
+}
+
+// finishWebAuthnRegistrationHandler validates the authenticator's response
+// and, on success, persists the new credential.
+func (s *Server) finishWebAuthnRegistrationHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	sessionData, err := s.webAuthnCeremonyFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer clearWebAuthnCeremonyCookie(w)
+
+	user, err := loadAdminWebAuthnUser()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	credential, err := s.webAuthn.FinishRegistration(user, *sessionData, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stored := WebAuthnCredential{
+		ID:              credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		UserVerified:    credential.Flags.UserVerified,
+		Name:            r.URL.Query().Get("name"),
+	}
+
+	creds, err := LoadWebAuthnCredentials()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	creds = append(creds, stored)
+	if err := SaveWebAuthnCredentials(creds); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(Response{Success: true, Message: "Security key enrolled"})
+}
+
+// beginWebAuthnLoginHandler starts the assertion ceremony for a pending
+// login (after a correct password, before the 2FA challenge is satisfied).
+func (s *Server) beginWebAuthnLoginHandler(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.pendingSessionFromRequest(r); !ok {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	user, err := loadAdminWebAuthnUser()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(user.credentials) == 0 {
+		http.Error(w, "no security keys enrolled", http.StatusBadRequest)
+		return
+	}
+
+	assertion, sessionData, err := s.webAuthn.BeginLogin(user)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := s.setWebAuthnCeremonyCookie(w, sessionData); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(assertion)
+}
+
+// finishWebAuthnLoginHandler validates the assertion and, on success,
+// completes the pending login with TwoFactorWebAuthn recorded as the
+// factor used.
+func (s *Server) finishWebAuthnLoginHandler(w http.ResponseWriter, r *http.Request) {
+	pending, ok := s.pendingSessionFromRequest(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	clientIP := GetClientIP(r)
+	if !s.totpLimiter.Allowed(remoteAddrIP(r)) {
+		http.Error(w, "too many attempts", http.StatusTooManyRequests)
+		return
+	}
+
+	sessionData, err := s.webAuthnCeremonyFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer clearWebAuthnCeremonyCookie(w)
+
+	user, err := loadAdminWebAuthnUser()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	credential, err := s.webAuthn.FinishLogin(user, *sessionData, r)
+	if err != nil {
+		s.totpLimiter.RecordFailure(remoteAddrIP(r))
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if !credential.Flags.UserVerified {
+		http.Error(w, "authenticator did not verify the user", http.StatusUnauthorized)
+		return
+	}
+
+	if err := updateWebAuthnSignCount(credential.ID, credential.Authenticator.SignCount); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	clearPendingSessionCookie(w)
+	if err := s.issueSession(w, pending.Username, clientIP, r.UserAgent(), TwoFactorWebAuthn); err != nil {
+		http.Error(w, "Login failed", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(Response{Success: true, Message: "Signed in"})
+}
+
+// updateWebAuthnSignCount persists credentialID's new sign count after a
+// successful assertion, so a cloned authenticator replaying an old
+// assertion is caught on its next use (see webauthn.Authenticator.UpdateCounter).
+func updateWebAuthnSignCount(credentialID []byte, signCount uint32) error {
+	creds, err := LoadWebAuthnCredentials()
+	if err != nil {
+		return err
+	}
+	for i, c := range creds {
+		if string(c.ID) == string(credentialID) {
+			creds[i].SignCount = signCount
+		}
+	}
+	return SaveWebAuthnCredentials(creds)
+}
+
+// webAuthnLoginHTML drives the login-time assertion ceremony. TOTP's
+// equivalent (twoFAChallengeHTML) is a plain <form> because a 6-digit code
+// is something a human types; a WebAuthn assertion can only be produced by
+// calling navigator.credentials.get() from the browser, so this page needs
+// an inline script instead.
+const webAuthnLoginHTML = `<!DOCTYPE html>
+<html>
+<head>
+    <title>SecureBlog Admin Login - Security Key</title>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <style>
+        body {
+            font-family: -apple-system, BlinkMacSystemFont, sans-serif;
+            background: linear-gradient(135deg, #667eea 0%, #764ba2 100%);
+            min-height: 100vh; display: flex; align-items: center; justify-content: center;
+        }
+        .login-card {
+            background: white; padding: 2rem; border-radius: 0.5rem;
+            box-shadow: 0 25px 50px -12px rgba(0,0,0,0.25); max-width: 400px; width: 100%; text-align: center;
+        }
+        .logo { margin-bottom: 2rem; }
+        .btn {
+            width: 100%; padding: 0.75rem; background: #2563eb; color: white;
+            border: none; border-radius: 0.375rem; font-weight: 600; cursor: pointer;
+        }
+        .status { margin-top: 1rem; color: #6b7280; font-size: 0.875rem; min-height: 1.25rem; }
+    </style>
+</head>
+<body>
+    <div class="login-card">
+        <div class="logo">
+            <h1>🔒 SecureBlog</h1>
+            <p style="color: #6b7280; margin-top: 1rem;">Sign in with your security key or passkey</p>
+        </div>
+        <button id="webauthn-btn" class="btn">🔑 Use security key</button>
+        <p class="status" id="webauthn-status"></p>
+    </div>
+    <script>
+    (function() {
+        var csrfToken = {{csrfToken}};
+
+        function b64uToBuf(b64u) {
+            var b64 = b64u.replace(/-/g, "+").replace(/_/g, "/");
+            while (b64.length % 4 !== 0) { b64 += "="; }
+            var bin = atob(b64);
+            var buf = new Uint8Array(bin.length);
+            for (var i = 0; i < bin.length; i++) { buf[i] = bin.charCodeAt(i); }
+            return buf.buffer;
+        }
+
+        function bufToB64u(buf) {
+            var bytes = new Uint8Array(buf);
+            var bin = "";
+            for (var i = 0; i < bytes.length; i++) { bin += String.fromCharCode(bytes[i]); }
+            return btoa(bin).replace(/\+/g, "-").replace(/\//g, "_").replace(/=+$/, "");
+        }
+
+        function decodeRequestOptions(options) {
+            options.challenge = b64uToBuf(options.challenge);
+            if (options.allowCredentials) {
+                options.allowCredentials = options.allowCredentials.map(function(c) {
+                    return { type: c.type, id: b64uToBuf(c.id), transports: c.transports };
+                });
+            }
+            return options;
+        }
+
+        function encodeAssertion(cred) {
+            return {
+                id: cred.id,
+                rawId: bufToB64u(cred.rawId),
+                type: cred.type,
+                response: {
+                    authenticatorData: bufToB64u(cred.response.authenticatorData),
+                    clientDataJSON: bufToB64u(cred.response.clientDataJSON),
+                    signature: bufToB64u(cred.response.signature),
+                    userHandle: cred.response.userHandle ? bufToB64u(cred.response.userHandle) : undefined
+                }
+            };
+        }
+
+        document.getElementById("webauthn-btn").addEventListener("click", function() {
+            var status = document.getElementById("webauthn-status");
+            status.textContent = "Waiting for your security key...";
+
+            fetch("/login/2fa/webauthn/begin", { method: "POST", headers: { "X-CSRF-Token": csrfToken } })
+                .then(function(res) {
+                    if (!res.ok) { throw new Error("Could not start security key sign-in"); }
+                    return res.json();
+                })
+                .then(function(options) {
+                    return navigator.credentials.get({ publicKey: decodeRequestOptions(options.publicKey) });
+                })
+                .then(function(cred) {
+                    return fetch("/login/2fa/webauthn/finish", {
+                        method: "POST",
+                        headers: { "Content-Type": "application/json", "X-CSRF-Token": csrfToken },
+                        body: JSON.stringify(encodeAssertion(cred))
+                    });
+                })
+                .then(function(res) {
+                    if (!res.ok) { throw new Error("Security key verification failed"); }
+                    window.location.href = "/admin";
+                })
+                .catch(function(err) {
+                    status.textContent = err.message;
+                });
+        });
+    })();
+    </script>
+</body>
+</html>`
+
+// loginWebAuthnPageHandler serves the page that drives login-time
+// navigator.credentials.get() against beginWebAuthnLoginHandler and
+// finishWebAuthnLoginHandler. Gated the same way twoFAChallengeHandler is:
+// a password has already been verified, but the 2FA challenge hasn't.
+func (s *Server) loginWebAuthnPageHandler(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.pendingSessionFromRequest(r); !ok {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	page := strings.Replace(webAuthnLoginHTML, "{{csrfToken}}", fmt.Sprintf("%q", csrf.Token(r)), 1)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(page))
+}
+
+// webAuthnEnrollHTML drives the registration-time attestation ceremony
+// (navigator.credentials.create()), the enrollment counterpart to
+// webAuthnLoginHTML above.
+const webAuthnEnrollHTML = `<!DOCTYPE html>
+<html>
+<head>
+    <title>SecureBlog Admin - Register Security Key</title>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <style>
+        body {
+            font-family: -apple-system, BlinkMacSystemFont, sans-serif;
+            background: linear-gradient(135deg, #667eea 0%, #764ba2 100%);
+            min-height: 100vh; display: flex; align-items: center; justify-content: center;
+        }
+        .login-card {
+            background: white; padding: 2rem; border-radius: 0.5rem;
+            box-shadow: 0 25px 50px -12px rgba(0,0,0,0.25); max-width: 400px; width: 100%; text-align: center;
+        }
+        .logo { margin-bottom: 2rem; }
+        .form-input {
+            width: 100%; padding: 0.75rem; border: 1px solid #d1d5db;
+            border-radius: 0.375rem; font-size: 0.875rem; margin-bottom: 1rem;
+        }
+        .btn {
+            width: 100%; padding: 0.75rem; background: #2563eb; color: white;
+            border: none; border-radius: 0.375rem; font-weight: 600; cursor: pointer;
+        }
+        .status { margin-top: 1rem; color: #6b7280; font-size: 0.875rem; min-height: 1.25rem; }
+    </style>
+</head>
+<body>
+    <div class="login-card">
+        <div class="logo">
+            <h1>🔒 SecureBlog</h1>
+            <p style="color: #6b7280; margin-top: 1rem;">Register a security key or passkey</p>
+        </div>
+        <input type="text" id="cred-name" class="form-input" placeholder="Key name (e.g. YubiKey 5C)">
+        <button id="register-btn" class="btn">🔑 Register security key</button>
+        <p class="status" id="register-status"></p>
+    </div>
+    <script>
+    (function() {
+        var csrfToken = {{csrfToken}};
+
+        function b64uToBuf(b64u) {
+            var b64 = b64u.replace(/-/g, "+").replace(/_/g, "/");
+            while (b64.length % 4 !== 0) { b64 += "="; }
+            var bin = atob(b64);
+            var buf = new Uint8Array(bin.length);
+            for (var i = 0; i < bin.length; i++) { buf[i] = bin.charCodeAt(i); }
+            return buf.buffer;
+        }
+
+        function bufToB64u(buf) {
+            var bytes = new Uint8Array(buf);
+            var bin = "";
+            for (var i = 0; i < bytes.length; i++) { bin += String.fromCharCode(bytes[i]); }
+            return btoa(bin).replace(/\+/g, "-").replace(/\//g, "_").replace(/=+$/, "");
+        }
+
+        function decodeCreationOptions(options) {
+            options.challenge = b64uToBuf(options.challenge);
+            options.user.id = b64uToBuf(options.user.id);
+            if (options.excludeCredentials) {
+                options.excludeCredentials = options.excludeCredentials.map(function(c) {
+                    return { type: c.type, id: b64uToBuf(c.id), transports: c.transports };
+                });
+            }
+            return options;
+        }
+
+        function encodeAttestation(cred) {
+            return {
+                id: cred.id,
+                rawId: bufToB64u(cred.rawId),
+                type: cred.type,
+                response: {
+                    attestationObject: bufToB64u(cred.response.attestationObject),
+                    clientDataJSON: bufToB64u(cred.response.clientDataJSON)
+                }
+            };
+        }
+
+        document.getElementById("register-btn").addEventListener("click", function() {
+            var status = document.getElementById("register-status");
+            var name = document.getElementById("cred-name").value || "Security key";
+            status.textContent = "Waiting for your security key...";
+
+            fetch("/api/2fa/webauthn/register/begin", { method: "POST", headers: { "X-CSRF-Token": csrfToken } })
+                .then(function(res) {
+                    if (!res.ok) { throw new Error("Could not start registration"); }
+                    return res.json();
+                })
+                .then(function(options) {
+                    return navigator.credentials.create({ publicKey: decodeCreationOptions(options.publicKey) });
+                })
+                .then(function(cred) {
+                    return fetch("/api/2fa/webauthn/register/finish?name=" + encodeURIComponent(name), {
+                        method: "POST",
+                        headers: { "Content-Type": "application/json", "X-CSRF-Token": csrfToken },
+                        body: JSON.stringify(encodeAttestation(cred))
+                    });
+                })
+                .then(function(res) {
+                    if (!res.ok) { throw new Error("Registration failed"); }
+                    return res.json();
+                })
+                .then(function(body) {
+                    status.textContent = body.message || "Security key registered";
+                })
+                .catch(function(err) {
+                    status.textContent = err.message;
+                });
+        });
+    })();
+    </script>
+</body>
+</html>`
+
+// webAuthnEnrollPageHandler serves the page that drives registration-time
+// navigator.credentials.create() against beginWebAuthnRegistrationHandler
+// and finishWebAuthnRegistrationHandler. Gated like adminHandler: enrolling
+// a new factor requires an already-authenticated session.
+func (s *Server) webAuthnEnrollPageHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.isAuthenticated(r) {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	page := strings.Replace(webAuthnEnrollHTML, "{{csrfToken}}", fmt.Sprintf("%q", csrf.Token(r)), 1)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(page))
+}
+
+// requireWebAuthnFactor wraps a high-risk handler (actor key rotation,
+// ActivityPub actor changes) so it requires the session's 2FA challenge to
+// have been satisfied specifically by a WebAuthn credential -- TOTP and
+// backup codes are bearer secrets an attacker who phished the admin could
+// relay themselves, where a WebAuthn assertion is bound to this origin.
+func (s *Server) requireWebAuthnFactor(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess, ok := s.sessionFromRequest(r)
+		if !ok || !sess.TwoFAVerified.IsPhishingResistant() {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(Response{
+				Success: false,
+				Message: "This action requires verifying with a security key or passkey",
+			})
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import "testing"
+
+func TestWebAuthnCredentialsRoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("USERPROFILE", t.TempDir())
+
+	want := []WebAuthnCredential{{
+		ID:              []byte{1, 2, 3, 4},
+		PublicKey:       []byte{5, 6, 7, 8},
+		AttestationType: "none",
+		AAGUID:          []byte{9, 9, 9, 9},
+		SignCount:       1,
+		UserVerified:    true,
+		Name:            "YubiKey 5C",
+	}}
+
+	if err := SaveWebAuthnCredentials(want); err != nil {
+		t.Fatalf("SaveWebAuthnCredentials() error = %v", err)
+	}
+
+	got, err := LoadWebAuthnCredentials()
+	if err != nil {
+		t.Fatalf("LoadWebAuthnCredentials() error = %v", err)
+	}
+	if len(got) != 1 || string(got[0].ID) != string(want[0].ID) || got[0].Name != want[0].Name {
+		t.Errorf("LoadWebAuthnCredentials() = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadWebAuthnCredentialsEmptyWhenUnset(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("USERPROFILE", t.TempDir())
+
+	creds, err := LoadWebAuthnCredentials()
+	if err != nil {
+		t.Fatalf("LoadWebAuthnCredentials() error = %v", err)
+	}
+	if len(creds) != 0 {
+		t.Errorf("LoadWebAuthnCredentials() = %+v, want none enrolled yet", creds)
+	}
+}
+
+func TestAdminWebAuthnUserMapsCredentials(t *testing.T) {
+	user := &adminWebAuthnUser{credentials: []WebAuthnCredential{{
+		ID:           []byte{1, 2, 3},
+		PublicKey:    []byte{4, 5, 6},
+		SignCount:    7,
+		UserVerified: true,
+	}}}
+
+	creds := user.WebAuthnCredentials()
+	if len(creds) != 1 {
+		t.Fatalf("WebAuthnCredentials() returned %d credentials, want 1", len(creds))
+	}
+	if string(creds[0].ID) != "\x01\x02\x03" {
+		t.Errorf("WebAuthnCredentials()[0].ID = %v, want the stored ID", creds[0].ID)
+	}
+	if creds[0].Authenticator.SignCount != 7 {
+		t.Errorf("WebAuthnCredentials()[0].Authenticator.SignCount = %d, want 7", creds[0].Authenticator.SignCount)
+	}
+	if !creds[0].Flags.UserVerified {
+		t.Errorf("WebAuthnCredentials()[0].Flags.UserVerified = false, want true")
+	}
+}
+
+func TestUpdateWebAuthnSignCount(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("USERPROFILE", t.TempDir())
+
+	if err := SaveWebAuthnCredentials([]WebAuthnCredential{{ID: []byte{1, 2, 3}, SignCount: 1}}); err != nil {
+		t.Fatalf("SaveWebAuthnCredentials() error = %v", err)
+	}
+
+	if err := updateWebAuthnSignCount([]byte{1, 2, 3}, 42); err != nil {
+		t.Fatalf("updateWebAuthnSignCount() error = %v", err)
+	}
+
+	creds, err := LoadWebAuthnCredentials()
+	if err != nil {
+		t.Fatalf("LoadWebAuthnCredentials() error = %v", err)
+	}
+	if len(creds) != 1 || creds[0].SignCount != 42 {
+		t.Errorf("LoadWebAuthnCredentials() = %+v, want SignCount updated to 42", creds)
+	}
+}
@@ -1,28 +1,93 @@
 package main
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
+	"context"
 	"flag"
 	"fmt"
-	"html/template"
-	"io"
 	"log"
+	"net/http"
 	"os"
-	"path/filepath"
 	"secureblog/internal/builder"
+	"secureblog/internal/micropub"
 	"secureblog/internal/security"
+	"secureblog/internal/server"
+	"secureblog/internal/session"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "admin" {
+		runAdminCommand(os.Args[2:])
+		return
+	}
+
+	// build-v2 uses the plugin-based BuilderV2 pipeline instead of the
+	// default hardcoded one (see cmd/main_v2.go) -- kept as an opt-in
+	// subcommand rather than the default since its plugin registration
+	// order and flag surface differ from the build above.
+	if len(os.Args) > 1 && os.Args[1] == "build-v2" {
+		runV2Build(os.Args[2:])
+		return
+	}
+
 	var (
-		contentDir = flag.String("content", "content", "Content directory")
-		outputDir  = flag.String("output", "build", "Output directory")
-		signOutput = flag.Bool("sign", true, "Sign output files")
-		verify     = flag.Bool("verify", false, "Verify build integrity")
+		contentDir     = flag.String("content", "content", "Content directory")
+		templateDir    = flag.String("templates", "templates", "Template directory")
+		outputDir      = flag.String("output", "build", "Output directory")
+		signOutput     = flag.Bool("sign", true, "Sign output files")
+		verify         = flag.Bool("verify", false, "Verify build integrity")
+		reproduce      = flag.String("reproduce", "", "Rebuild and diff against a known-good manifest.json")
+		incremental    = flag.Bool("incremental", false, "Only rebuild posts/templates/static files that changed")
+		force          = flag.Bool("force", false, "With -incremental, ignore the dependency cache and rebuild everything")
+		serve          = flag.Bool("serve", false, "Run the local dev server instead of building once")
+		serveAddr      = flag.String("serve-addr", ":8000", "Dev server listen address")
+		serveTLS       = flag.Bool("serve-tls", false, "Serve the dev server over HTTPS with a locally generated cert")
+		noInject       = flag.Bool("no-inject", false, "Dev server: never inject the live-reload script (byte-identical to prod, manual refresh)")
+		micropubListen = flag.String("micropub-listen", "", "Address to serve the Micropub endpoint on (e.g. :8001); empty disables it")
+		micropubToken  = flag.String("micropub-token-endpoint", "", "IndieAuth token endpoint used to verify Micropub Bearer tokens")
+		micropubMe     = flag.String("micropub-me", "", "This site's IndieAuth identity URL; tokens issued to any other \"me\" are rejected")
 	)
 	flag.Parse()
 
+	if *micropubListen != "" {
+		b := builder.New(builder.Config{
+			ContentDir:  *contentDir,
+			OutputDir:   *outputDir,
+			TemplateDir: *templateDir,
+			Secure:      true,
+		})
+		h := micropub.Handler(micropub.Config{
+			ContentDir:    *contentDir,
+			OutputDir:     *outputDir,
+			Builder:       b,
+			TokenEndpoint: *micropubToken,
+			Me:            *micropubMe,
+		})
+		log.Printf("Micropub endpoint listening on %s", *micropubListen)
+		log.Fatal(http.ListenAndServe(*micropubListen, h))
+	}
+
+	if *serve {
+		b := builder.New(builder.Config{
+			ContentDir:  *contentDir,
+			OutputDir:   *outputDir,
+			TemplateDir: *templateDir,
+			Secure:      true,
+		})
+		if err := b.BuildIncremental(context.Background(), false); err != nil {
+			log.Fatalf("Initial build failed: %v", err)
+		}
+		err := server.Dev(server.Config{
+			Builder:     b,
+			OutputDir:   *outputDir,
+			ContentDir:  *contentDir,
+			TemplateDir: *templateDir,
+			Addr:        *serveAddr,
+			TLS:         *serveTLS,
+			NoInject:    *noInject,
+		})
+		log.Fatalf("Dev server exited: %v", err)
+	}
+
 	if *verify {
 		if err := security.VerifyBuild(*outputDir); err != nil {
 			log.Fatalf("Build verification failed: %v", err)
@@ -31,8 +96,31 @@ func main() {
 		return
 	}
 
-	// Clean output directory
-	os.RemoveAll(*outputDir)
+	if *reproduce != "" {
+		b := builder.New(builder.Config{
+			ContentDir:  *contentDir,
+			OutputDir:   *outputDir,
+			TemplateDir: "templates",
+			Secure:      true,
+		})
+		if err := b.Build(); err != nil {
+			log.Fatalf("Build failed: %v", err)
+		}
+		diffs, err := security.ReproduceBuild(*outputDir, *reproduce)
+		if err != nil {
+			log.Fatalf("Reproduce failed: %v", err)
+		}
+		if len(diffs) == 0 {
+			fmt.Println("✓ Build is reproducible: output matches known-good manifest")
+			return
+		}
+		fmt.Println("✗ Build is NOT reproducible:")
+		for _, d := range diffs {
+			fmt.Printf("  - %s\n", d)
+		}
+		os.Exit(1)
+	}
+
 	os.MkdirAll(*outputDir, 0755)
 
 	// Initialize builder with security settings
@@ -43,9 +131,20 @@ func main() {
 		Secure:      true,
 	})
 
-	// Build the site
-	if err := b.Build(); err != nil {
-		log.Fatalf("Build failed: %v", err)
+	if *incremental {
+		if *force {
+			os.RemoveAll(builder.DepGraphPath)
+		}
+		if err := b.BuildIncremental(context.Background(), *force); err != nil {
+			log.Fatalf("Incremental build failed: %v", err)
+		}
+	} else {
+		// Full (non-incremental) build: start from a clean output directory.
+		os.RemoveAll(*outputDir)
+		os.MkdirAll(*outputDir, 0755)
+		if err := b.Build(); err != nil {
+			log.Fatalf("Build failed: %v", err)
+		}
 	}
 
 	// Sign output if requested
@@ -53,14 +152,57 @@ func main() {
 		if err := security.SignBuild(*outputDir); err != nil {
 			log.Fatalf("Failed to sign build: %v", err)
 		}
-		fmt.Println("✓ Build signed with SHA256")
+		fmt.Println("✓ Build manifest signed and recorded in transparency log")
 	}
 
 	// Generate security headers file
 	security.GenerateHeaders(*outputDir)
-	
+
 	fmt.Printf("✓ Secure blog built successfully in %s\n", *outputDir)
 	fmt.Println("✓ CSP headers generated")
 	fmt.Println("✓ No JavaScript included")
 	fmt.Println("✓ No external dependencies")
-}
\ No newline at end of file
+}
+
+// runAdminCommand handles the "secureblog admin ..." subcommand family,
+// kept separate from the flag.FlagSet the rest of main uses since it takes
+// its own nested subcommand (currently just "token issue").
+func runAdminCommand(args []string) {
+	if len(args) == 0 {
+		log.Fatal("usage: secureblog admin token issue --user <name> --feed <rss|atom>")
+	}
+
+	switch args[0] {
+	case "token":
+		runAdminTokenCommand(args[1:])
+	default:
+		log.Fatalf("unknown admin subcommand %q", args[0])
+	}
+}
+
+// runAdminTokenCommand handles "secureblog admin token issue --user X
+// --feed rss", minting an opaque, revocable token a feed reader can use to
+// poll a SECUREBLOG_PRIVATE-gated feed without a browser session (see
+// internal/session.IssueFeedToken and internal/server's private-mode
+// middleware).
+func runAdminTokenCommand(args []string) {
+	if len(args) == 0 || args[0] != "issue" {
+		log.Fatal("usage: secureblog admin token issue --user <name> --feed <rss|atom>")
+	}
+
+	fs := flag.NewFlagSet("admin token issue", flag.ExitOnError)
+	user := fs.String("user", "", "Username the token is issued to")
+	feed := fs.String("feed", "", "Feed the token grants access to (rss, atom)")
+	fs.Parse(args[1:])
+
+	if *user == "" || *feed == "" {
+		log.Fatal("usage: secureblog admin token issue --user <name> --feed <rss|atom>")
+	}
+
+	token, err := session.IssueFeedToken(*user, *feed)
+	if err != nil {
+		log.Fatalf("issuing feed token: %v", err)
+	}
+	fmt.Printf("✓ Issued %s feed token for %s: %s\n", *feed, *user, token)
+	fmt.Printf("  Set plugins.%s.feedToken to this value to publish a self-link that carries it.\n", *feed)
+}
@@ -6,22 +6,41 @@ import (
 	"log"
 	"os"
 	"secureblog/internal/builder"
+	"secureblog/internal/policy"
+	"secureblog/plugins/activitypub"
+	"secureblog/plugins/atom"
+	"secureblog/plugins/diagrams"
 	"secureblog/plugins/integrity"
 	"secureblog/plugins/markdown"
+	"secureblog/plugins/minify"
 	"secureblog/plugins/rss"
 	"secureblog/plugins/security"
 	"secureblog/plugins/sitemap"
 )
 
-func main() {
+// runV2Build is the entry point for the plugin-based builder (BuilderV2),
+// invoked via "secureblog build-v2 ..." (see main's dispatch in main.go). It
+// takes its own flag.FlagSet, separate from the one the default build uses,
+// since the two builders don't share a flag surface (-plugins, -config,
+// -markdown-engine, the per-format -minify-* flags, and -list-plugins exist
+// only here).
+func runV2Build(args []string) {
+	fs := flag.NewFlagSet("build-v2", flag.ExitOnError)
 	var (
-		contentDir  = flag.String("content", "content", "Content directory")
-		outputDir   = flag.String("output", "build", "Output directory")
-		pluginDir   = flag.String("plugins", "plugins", "Plugin directory")
-		configFile  = flag.String("config", "config.yaml", "Config file")
-		listPlugins = flag.Bool("list-plugins", false, "List available plugins")
+		contentDir  = fs.String("content", "content", "Content directory")
+		outputDir   = fs.String("output", "build", "Output directory")
+		pluginDir   = fs.String("plugins", "plugins", "Plugin directory")
+		configFile  = fs.String("config", "config.yaml", "Config file")
+		listPlugins = fs.Bool("list-plugins", false, "List available plugins")
+		doMinify    = fs.Bool("minify", true, "Minify HTML/CSS/XML output")
+		minifyHTML  = fs.Bool("minify-html", true, "Minify HTML output (requires -minify)")
+		minifyCSS   = fs.Bool("minify-css", true, "Minify CSS output (requires -minify)")
+		minifyXML   = fs.Bool("minify-xml", true, "Minify XML/RSS output (requires -minify)")
+		minifySVG   = fs.Bool("minify-svg", true, "Minify SVG output (requires -minify)")
+		mdEngine    = fs.String("markdown-engine", "blackfriday", "Markdown rendering engine (blackfriday, goldmark)")
+		siteURL     = fs.String("site-url", "", "Canonical site base URL, used by the absURL template func")
 	)
-	flag.Parse()
+	fs.Parse(args)
 
 	if *listPlugins {
 		listAvailablePlugins()
@@ -34,30 +53,75 @@ func main() {
 
 	// Initialize builder with plugin system
 	b := builder.NewV2(builder.Config{
-		ContentDir:  *contentDir,
-		OutputDir:   *outputDir,
-		TemplateDir: "templates",
-		Secure:      true,
+		ContentDir:     *contentDir,
+		OutputDir:      *outputDir,
+		TemplateDir:    "templates",
+		Secure:         true,
+		MarkdownEngine: *mdEngine,
+		SiteURL:        *siteURL,
 	})
 
+	// The security policy gates which binaries the diagrams plugin (and
+	// any out-of-process plugin loaded later) may spawn; a missing
+	// *configFile just means everything stays denied until the site opts
+	// a renderer in under exec.allow.
+	pol, err := policy.Load(*configFile)
+	if err != nil {
+		log.Fatalf("loading security policy: %v", err)
+	}
+
 	// Register core plugins
 	fmt.Println("🔌 Loading plugins...")
-	
+
+	// Diagrams must run before markdown processing (lower Priority), so
+	// fenced ```mermaid```/```kroki-*``` blocks become inline SVG before
+	// the CommonMark engine ever sees them.
+	dg := diagrams.New()
+	if err := dg.Init(map[string]interface{}{"policy": pol}); err != nil {
+		log.Fatalf("configuring diagrams plugin: %v", err)
+	}
+	b.RegisterPlugin(dg)
+	fmt.Println("  ✓ Diagram rendering (Mermaid, Kroki)")
+
 	// Content processing
-	b.RegisterPlugin(markdown.New())
-	fmt.Println("  ✓ Markdown processor")
-	
+	md := markdown.New()
+	if err := md.Init(map[string]interface{}{"engine": *mdEngine}); err != nil {
+		log.Fatalf("configuring markdown engine: %v", err)
+	}
+	b.RegisterPlugin(md)
+	fmt.Printf("  ✓ Markdown processor (%s)\n", *mdEngine)
+
 	// Security
 	b.RegisterPlugin(security.NewCSP())
 	fmt.Println("  ✓ CSP security")
-	
+
 	// Output formats
 	b.RegisterPlugin(rss.New())
 	fmt.Println("  ✓ RSS generator")
-	
+
+	b.RegisterPlugin(atom.New())
+	fmt.Println("  ✓ Atom generator")
+
 	b.RegisterPlugin(sitemap.New())
 	fmt.Println("  ✓ Sitemap generator")
-	
+
+	b.RegisterPlugin(activitypub.New())
+	fmt.Println("  ✓ ActivityPub federation (actor, outbox, WebFinger)")
+
+	// Minification (must run before integrity hashing, so the signed
+	// manifest matches the bytes actually served)
+	if *doMinify {
+		m := minify.New()
+		m.Init(map[string]interface{}{
+			"html": *minifyHTML,
+			"css":  *minifyCSS,
+			"xml":  *minifyXML,
+			"svg":  *minifySVG,
+		})
+		b.RegisterPlugin(m)
+		fmt.Println("  ✓ Output minification")
+	}
+
 	// Build integrity
 	b.RegisterPlugin(integrity.New())
 	fmt.Println("  ✓ Integrity hashing")
@@ -84,11 +148,14 @@ func main() {
 func listAvailablePlugins() {
 	fmt.Println("📦 Available Plugins:")
 	fmt.Println("\nCore Plugins:")
-	fmt.Println("  • markdown     - Markdown to HTML conversion")
+	fmt.Println("  • diagrams     - Mermaid/Kroki fenced code blocks rendered to inline SVG")
+	fmt.Println("  • markdown     - Markdown to HTML conversion (blackfriday or goldmark, -markdown-engine)")
 	fmt.Println("  • csp-security - Content Security Policy")
 	fmt.Println("  • integrity    - SHA256 content hashing")
 	fmt.Println("  • rss          - RSS feed generation")
+	fmt.Println("  • atom         - Atom 1.0 feed generation")
 	fmt.Println("  • sitemap      - XML sitemap generation")
+	fmt.Println("  • activitypub  - ActivityPub actor/outbox/WebFinger generation")
 	fmt.Println("\nCustom Plugins:")
 	fmt.Println("  Place .go files in plugins/ directory")
 }
@@ -97,4 +164,4 @@ func loadCustomPlugins(b *builder.BuilderV2, pluginDir string) error {
 	// In production, this would load compiled plugins
 	// For security, we only load pre-compiled plugins
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,65 @@
+// assets.go - the dashboard's HTML/CSS/JS are embedded at build time
+// instead of living in a Go string, then minified and hashed at startup so
+// the CSP header can allow-list them by hash and drop 'unsafe-inline'.
+package main
+
+import (
+	_ "embed"
+	"regexp"
+	"strings"
+
+	"secureblog/plugins/security"
+)
+
+//go:embed assets/dashboard.html
+var dashboardTemplate string
+
+//go:embed assets/dashboard.css
+var dashboardCSSSource string
+
+//go:embed assets/dashboard.js
+var dashboardJSSource string
+
+var (
+	dashboardCSS  []byte
+	dashboardJS   []byte
+	dashboardPage []byte
+	dashboardCSP  string
+)
+
+func init() {
+	dashboardCSS = minifyCSS([]byte(dashboardCSSSource))
+	dashboardJS = minifyJS([]byte(dashboardJSSource))
+
+	page := dashboardTemplate
+	page = strings.Replace(page, "__CSS__", string(dashboardCSS), 1)
+	page = strings.Replace(page, "__JS__", string(dashboardJS), 1)
+	dashboardPage = []byte(page)
+
+	dashboardCSP = security.CSPForAssets([][]byte{dashboardJS}, [][]byte{dashboardCSS})
+}
+
+var (
+	cssCommentRe = regexp.MustCompile(`/\*[\s\S]*?\*/`)
+	jsCommentRe  = regexp.MustCompile(`(?m)//[^\n]*$`)
+	runsOfSpace  = regexp.MustCompile(`[ \t]+`)
+	blankLines   = regexp.MustCompile(`\n{2,}`)
+)
+
+// minifyCSS strips comments and collapses redundant whitespace. It's a
+// light touch, not a full minifier -- good enough to keep the inlined
+// asset small and its hash stable.
+func minifyCSS(b []byte) []byte {
+	return squeeze(cssCommentRe.ReplaceAll(b, nil))
+}
+
+// minifyJS strips line comments and collapses redundant whitespace.
+func minifyJS(b []byte) []byte {
+	return squeeze(jsCommentRe.ReplaceAll(b, nil))
+}
+
+func squeeze(b []byte) []byte {
+	s := runsOfSpace.ReplaceAllString(string(b), " ")
+	s = blankLines.ReplaceAllString(s, "\n")
+	return []byte(strings.TrimSpace(s))
+}
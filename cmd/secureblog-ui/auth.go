@@ -0,0 +1,387 @@
+// auth.go - Optional passphrase gate for the local UI.
+//
+// The server only binds 127.0.0.1, but that's not the same as "only the
+// operator can reach it": any local process -- a malicious browser
+// extension doing DNS rebinding, a compromised dev tool, another user on a
+// shared machine -- can still POST to /api/publish. Passing -auth requires
+// a passphrase (Argon2id-hashed, stored at ~/.config/secureblog/ui.hash)
+// before any /api/* route will respond, backed by a short-lived HMAC
+// session cookie and a Host-header allowlist that defeats DNS rebinding.
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// uiArgonParams are the Argon2id parameters the UI's passphrase is hashed
+// with (OWASP-recommended: 128MB, 4 iterations, 4 threads).
+var uiArgonParams = struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}{
+	Memory:      128 * 1024,
+	Iterations:  4,
+	Parallelism: 4,
+	SaltLength:  32,
+	KeyLength:   32,
+}
+
+// uiPassphraseHash is the Argon2id hash persisted to ui.hash.
+type uiPassphraseHash struct {
+	Hash string `json:"hash"`
+	Salt string `json:"salt"`
+}
+
+const (
+	uiSessionCookie    = "secureblog_ui_session"
+	uiSessionTTL       = 12 * time.Hour
+	uiLoginMaxAttempts = 5
+	uiLoginWindow      = time.Minute
+)
+
+// uiConfigDir returns ~/.config/secureblog, where the UI's passphrase hash
+// and session signing key are persisted -- outside the repo/build tree, so
+// neither ends up in a commit or a published build.
+func uiConfigDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("resolving home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "secureblog"), nil
+}
+
+func uiHashPath() (string, error) {
+	dir, err := uiConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ui.hash"), nil
+}
+
+func uiSessionKeyPath() (string, error) {
+	dir, err := uiConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ui-session-key"), nil
+}
+
+func hashUIPassphrase(passphrase string) (uiPassphraseHash, error) {
+	salt := make([]byte, uiArgonParams.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return uiPassphraseHash{}, fmt.Errorf("generating salt: %w", err)
+	}
+	hash := argon2.IDKey([]byte(passphrase), salt, uiArgonParams.Iterations, uiArgonParams.Memory, uiArgonParams.Parallelism, uiArgonParams.KeyLength)
+	return uiPassphraseHash{
+		Hash: base64.StdEncoding.EncodeToString(hash),
+		Salt: base64.StdEncoding.EncodeToString(salt),
+	}, nil
+}
+
+// verifyUIPassphrase reports whether passphrase matches stored, comparing
+// in constant time so a timing side-channel can't narrow down the hash.
+func verifyUIPassphrase(passphrase string, stored uiPassphraseHash) bool {
+	salt, err := base64.StdEncoding.DecodeString(stored.Salt)
+	if err != nil {
+		return false
+	}
+	want, err := base64.StdEncoding.DecodeString(stored.Hash)
+	if err != nil {
+		return false
+	}
+	got := argon2.IDKey([]byte(passphrase), salt, uiArgonParams.Iterations, uiArgonParams.Memory, uiArgonParams.Parallelism, uiArgonParams.KeyLength)
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
+
+// loadOrCreateUIPassphrase loads the persisted passphrase hash from
+// uiHashPath, or -- on first run -- generates a random passphrase, hashes
+// and persists it, and prints the plaintext once so the operator can save
+// it. The plaintext is never written to disk.
+func loadOrCreateUIPassphrase() (uiPassphraseHash, error) {
+	path, err := uiHashPath()
+	if err != nil {
+		return uiPassphraseHash{}, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		var stored uiPassphraseHash
+		if err := json.Unmarshal(data, &stored); err != nil {
+			return uiPassphraseHash{}, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		return stored, nil
+	}
+	if !os.IsNotExist(err) {
+		return uiPassphraseHash{}, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	raw := make([]byte, 18)
+	if _, err := rand.Read(raw); err != nil {
+		return uiPassphraseHash{}, fmt.Errorf("generating passphrase: %w", err)
+	}
+	passphrase := base64.RawURLEncoding.EncodeToString(raw)
+
+	stored, err := hashUIPassphrase(passphrase)
+	if err != nil {
+		return uiPassphraseHash{}, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return uiPassphraseHash{}, fmt.Errorf("creating %s: %w", filepath.Dir(path), err)
+	}
+	data, err = json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return uiPassphraseHash{}, err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return uiPassphraseHash{}, fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	fmt.Printf("\n🔑 First run: generated a UI passphrase (this is the only time it's shown):\n\n    %s\n\n", passphrase)
+	fmt.Printf("   Hashed copy stored at %s. Delete that file to reset it.\n\n", path)
+	return stored, nil
+}
+
+// loadOrCreateUISessionKey returns the persisted HMAC key session cookies
+// are signed with, generating and saving one on first run so sessions
+// survive a server restart.
+func loadOrCreateUISessionKey() ([]byte, error) {
+	path, err := uiSessionKeyPath()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := os.ReadFile(path)
+	if err == nil && len(key) == sha256.Size {
+		return key, nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	key = make([]byte, sha256.Size)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("generating session key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("creating %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, key, 0600); err != nil {
+		return nil, fmt.Errorf("writing %s: %w", path, err)
+	}
+	return key, nil
+}
+
+// signUISession produces a session cookie value good until expiry: the
+// expiry timestamp plus an HMAC-SHA256 over it, so a tampered or forged
+// expiry is rejected by verifyUISession.
+func signUISession(key []byte, expiry time.Time) string {
+	payload := strconv.FormatInt(expiry.Unix(), 10)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verifyUISession reports whether value is a session cookie signed with
+// key and not yet expired.
+func verifyUISession(key []byte, value string) bool {
+	payload, sig, ok := strings.Cut(value, ".")
+	if !ok {
+		return false
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	want := mac.Sum(nil)
+
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(want, got) {
+		return false
+	}
+
+	expiryUnix, err := strconv.ParseInt(payload, 10, 64)
+	if err != nil {
+		return false
+	}
+	return time.Now().Before(time.Unix(expiryUnix, 0))
+}
+
+// uiLoginLimiter caps login attempts per client IP, so a stolen/guessed
+// session can't be paired with an online brute force of the passphrase.
+type uiLoginLimiter struct {
+	mu       sync.Mutex
+	attempts map[string][]time.Time
+}
+
+func newUILoginLimiter() *uiLoginLimiter {
+	return &uiLoginLimiter{attempts: make(map[string][]time.Time)}
+}
+
+// Allowed reports whether ip may attempt a login right now, pruning
+// attempts outside uiLoginWindow first.
+func (l *uiLoginLimiter) Allowed(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cutoff := time.Now().Add(-uiLoginWindow)
+	recent := l.attempts[ip][:0]
+	for _, t := range l.attempts[ip] {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	l.attempts[ip] = recent
+
+	return len(recent) < uiLoginMaxAttempts
+}
+
+// RecordAttempt records a login attempt (successful or not) from ip.
+func (l *uiLoginLimiter) RecordAttempt(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.attempts[ip] = append(l.attempts[ip], time.Now())
+}
+
+// allowedUIHosts are the only Host header values a request may carry to
+// reach an authenticated route. Literal loopback names/addresses can't be
+// spoofed by a remote DNS response, which is what makes this a defense
+// against DNS rebinding -- a page on attacker.example can resolve to
+// 127.0.0.1, but it still sends "Host: attacker.example".
+var allowedUIHosts = map[string]bool{
+	"localhost": true,
+	"127.0.0.1": true,
+	"::1":       true,
+}
+
+// allowedUIHost reports whether r's Host header, port stripped, is one of
+// allowedUIHosts.
+func allowedUIHost(r *http.Request) bool {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return allowedUIHosts[host]
+}
+
+// uiClientIP extracts the IP part of r.RemoteAddr for rate-limiting; on
+// this loopback-only server it's always 127.0.0.1 or ::1.
+func uiClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// uiAuth gates the UI's /api/* routes when enabled: requests must carry a
+// literal loopback Host header and a valid, unexpired session cookie
+// obtained from /api/login. When disabled it's a no-op, matching the UI's
+// original unauthenticated behavior.
+type uiAuth struct {
+	enabled    bool
+	stored     uiPassphraseHash
+	sessionKey []byte
+	limiter    *uiLoginLimiter
+}
+
+// newUIAuth prepares the passphrase gate. When enabled, it loads (or, on
+// first run, generates and prints) the passphrase hash and session signing
+// key so the server is ready to authenticate before it starts listening.
+func newUIAuth(enabled bool) (*uiAuth, error) {
+	a := &uiAuth{enabled: enabled, limiter: newUILoginLimiter()}
+	if !enabled {
+		return a, nil
+	}
+
+	stored, err := loadOrCreateUIPassphrase()
+	if err != nil {
+		return nil, fmt.Errorf("loading UI passphrase: %w", err)
+	}
+	key, err := loadOrCreateUISessionKey()
+	if err != nil {
+		return nil, fmt.Errorf("loading UI session key: %w", err)
+	}
+	a.stored = stored
+	a.sessionKey = key
+	return a, nil
+}
+
+// requireAuth wraps next so it only runs once the Host header and session
+// cookie both check out. A no-op when auth is disabled.
+func (a *uiAuth) requireAuth(next http.HandlerFunc) http.HandlerFunc {
+	if !a.enabled {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !allowedUIHost(r) {
+			http.Error(w, "Access denied: untrusted Host header", http.StatusForbidden)
+			return
+		}
+		cookie, err := r.Cookie(uiSessionCookie)
+		if err != nil || !verifyUISession(a.sessionKey, cookie.Value) {
+			http.Error(w, "Authentication required", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleLogin verifies a passphrase against the stored hash and, on
+// success, issues a fresh session cookie. Rate-limited to
+// uiLoginMaxAttempts per uiLoginWindow per client IP.
+func (a *uiAuth) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if !allowedUIHost(r) {
+		http.Error(w, "Access denied: untrusted Host header", http.StatusForbidden)
+		return
+	}
+	if r.Method != http.MethodPost {
+		jsonResponse(w, Response{Success: false, Message: "Method not allowed"})
+		return
+	}
+
+	ip := uiClientIP(r)
+	if !a.limiter.Allowed(ip) {
+		jsonResponse(w, Response{Success: false, Message: "Too many attempts, try again in a minute"})
+		return
+	}
+	a.limiter.RecordAttempt(ip)
+
+	var req struct {
+		Passphrase string `json:"passphrase"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	if !verifyUIPassphrase(req.Passphrase, a.stored) {
+		jsonResponse(w, Response{Success: false, Message: "Invalid passphrase"})
+		return
+	}
+
+	expiry := time.Now().Add(uiSessionTTL)
+	http.SetCookie(w, &http.Cookie{
+		Name:     uiSessionCookie,
+		Value:    signUISession(a.sessionKey, expiry),
+		Path:     "/",
+		Expires:  expiry,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	jsonResponse(w, Response{Success: true, Message: "Authenticated"})
+}
@@ -0,0 +1,215 @@
+// jobs.go - runs pipeline steps in the background and streams their log
+// output to the browser over server-sent events, so a multi-minute build
+// or publish doesn't block the HTTP request that started it.
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ringBufferLimit bounds how much log output a Job keeps in memory; older
+// bytes are dropped once a job's output exceeds this, so a runaway step
+// can't exhaust memory.
+const ringBufferLimit = 1 << 20 // 1MB
+
+// ringBuffer is an append-only byte buffer that discards its oldest content
+// once it grows past ringBufferLimit.
+type ringBuffer struct {
+	mu   sync.Mutex
+	data []byte
+}
+
+func (b *ringBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = append(b.data, p...)
+	if len(b.data) > ringBufferLimit {
+		b.data = b.data[len(b.data)-ringBufferLimit:]
+	}
+	return len(p), nil
+}
+
+// Bytes returns a copy of the buffer's current contents.
+func (b *ringBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]byte, len(b.data))
+	copy(out, b.data)
+	return out
+}
+
+// Job tracks one background pipeline run: its live log and status, plus any
+// subscribers currently streaming that log over SSE.
+type Job struct {
+	ID       string    `json:"id"`
+	Kind     string    `json:"kind"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished,omitempty"`
+	Status   string    `json:"status"` // "running", "succeeded", "failed"
+
+	mu          sync.Mutex
+	logBuf      *ringBuffer
+	subscribers map[chan []byte]struct{}
+}
+
+// Job status values.
+const (
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+)
+
+var (
+	jobsMutex sync.RWMutex
+	jobs      = make(map[string]*Job)
+)
+
+func newJob(kind string) *Job {
+	return &Job{
+		ID:          generateJobID(),
+		Kind:        kind,
+		Started:     time.Now(),
+		Status:      JobStatusRunning,
+		logBuf:      &ringBuffer{},
+		subscribers: make(map[chan []byte]struct{}),
+	}
+}
+
+// generateJobID returns a random identifier used only to look a job up by
+// its SSE endpoint; it carries no authority of its own.
+func generateJobID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("generating job ID: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
+
+// subscribe registers a channel that receives every write published after
+// this call. The caller must call the returned unsubscribe func when done.
+func (j *Job) subscribe() (ch chan []byte, unsubscribe func()) {
+	ch = make(chan []byte, 64)
+	j.mu.Lock()
+	j.subscribers[ch] = struct{}{}
+	j.mu.Unlock()
+
+	return ch, func() {
+		j.mu.Lock()
+		if _, ok := j.subscribers[ch]; ok {
+			delete(j.subscribers, ch)
+			close(ch)
+		}
+		j.mu.Unlock()
+	}
+}
+
+// Write implements io.Writer so a Job can be passed directly to a pipeline
+// step as its progress writer. It appends to the log buffer and fans the
+// bytes out to every current subscriber; subscribers that aren't keeping up
+// are dropped rather than blocking the pipeline step.
+func (j *Job) Write(p []byte) (int, error) {
+	j.logBuf.Write(p)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for ch := range j.subscribers {
+		select {
+		case ch <- p:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+// finish marks the job complete and closes every subscriber channel so
+// their SSE handlers can send a terminal event and return.
+func (j *Job) finish(status string) {
+	j.mu.Lock()
+	j.Status = status
+	j.Finished = time.Now()
+	for ch := range j.subscribers {
+		delete(j.subscribers, ch)
+		close(ch)
+	}
+	j.mu.Unlock()
+}
+
+// runJob starts step in the background as a tracked, streamable job and
+// returns immediately with the Job so its ID can be handed to the caller.
+func runJob(kind string, step func(ctx context.Context, w *Job) error) *Job {
+	job := newJob(kind)
+
+	jobsMutex.Lock()
+	jobs[job.ID] = job
+	jobsMutex.Unlock()
+
+	go func() {
+		err := step(context.Background(), job)
+		if err != nil {
+			fmt.Fprintf(job, "error: %v\n", err)
+			job.finish(JobStatusFailed)
+			return
+		}
+		job.finish(JobStatusSucceeded)
+	}()
+
+	return job
+}
+
+// jobByID looks up a tracked job, reporting whether it exists.
+func jobByID(id string) (*Job, bool) {
+	jobsMutex.RLock()
+	defer jobsMutex.RUnlock()
+	job, ok := jobs[id]
+	return job, ok
+}
+
+// jobEventsHandler streams a job's log as Server-Sent Events: the buffered
+// output seen so far, then each new write as it's published, followed by a
+// terminal "done" event once the job finishes.
+func jobEventsHandler(w http.ResponseWriter, r *http.Request) {
+	job, ok := jobByID(r.URL.Query().Get("job"))
+	if !ok {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	fmt.Fprintf(w, "data: %s\n\n", job.logBuf.Bytes())
+	flusher.Flush()
+
+	ch, unsubscribe := job.subscribe()
+	defer unsubscribe()
+
+	for {
+		select {
+		case line, ok := <-ch:
+			if !ok {
+				data, _ := json.Marshal(map[string]string{"status": job.Status})
+				fmt.Fprintf(w, "event: done\ndata: %s\n\n", data)
+				flusher.Flush()
+				return
+			}
+			fmt.Fprintf(w, "data: %s\n\n", line)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
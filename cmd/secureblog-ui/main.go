@@ -2,24 +2,25 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
-	"html/template"
-	"io"
 	"log"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
-)
 
-const (
-	maxUploadSize = 10 << 20 // 10MB
-	serverPort    = "8080"
+	"secureblog/internal/pipeline"
+	"secureblog/internal/security"
+	"secureblog/plugins/publish"
 )
 
+const serverPort = "8080"
+
 type Post struct {
 	Title    string    `json:"title"`
 	Date     time.Time `json:"date"`
@@ -33,289 +34,45 @@ type Response struct {
 	Data    interface{} `json:"data,omitempty"`
 }
 
-var dashboardHTML = `<!DOCTYPE html>
-<html>
-<head>
-    <title>SecureBlog UI - Maximum Security Interface</title>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <style>
-        * { margin: 0; padding: 0; box-sizing: border-box; }
-        body { 
-            font-family: -apple-system, system-ui, sans-serif;
-            background: linear-gradient(135deg, #667eea 0%, #764ba2 100%);
-            min-height: 100vh;
-            padding: 20px;
-        }
-        .container { max-width: 1200px; margin: 0 auto; }
-        .header {
-            background: white;
-            border-radius: 12px;
-            padding: 24px;
-            margin-bottom: 24px;
-            box-shadow: 0 10px 25px rgba(0,0,0,0.1);
-        }
-        .header h1 { color: #1a202c; }
-        .security-badge {
-            background: #48bb78;
-            color: white;
-            padding: 4px 12px;
-            border-radius: 20px;
-            font-size: 12px;
-            display: inline-block;
-            margin-left: 12px;
-        }
-        .content {
-            background: white;
-            border-radius: 12px;
-            padding: 24px;
-            box-shadow: 0 10px 25px rgba(0,0,0,0.1);
-        }
-        .tabs {
-            display: flex;
-            gap: 12px;
-            margin-bottom: 24px;
-            border-bottom: 2px solid #e2e8f0;
-        }
-        .tab {
-            padding: 12px 24px;
-            background: none;
-            border: none;
-            cursor: pointer;
-            font-size: 16px;
-            color: #718096;
-            border-bottom: 2px solid transparent;
-            margin-bottom: -2px;
-        }
-        .tab.active {
-            color: #667eea;
-            border-bottom-color: #667eea;
-        }
-        .section { display: none; }
-        .section.active { display: block; }
-        .form-group { margin-bottom: 20px; }
-        .form-label {
-            display: block;
-            margin-bottom: 8px;
-            font-weight: 600;
-            color: #2d3748;
-        }
-        .form-input {
-            width: 100%;
-            padding: 12px;
-            border: 2px solid #e2e8f0;
-            border-radius: 8px;
-            font-size: 16px;
-        }
-        .form-input:focus {
-            outline: none;
-            border-color: #667eea;
-        }
-        textarea.form-input { min-height: 300px; font-family: monospace; }
-        .btn {
-            padding: 12px 24px;
-            border: none;
-            border-radius: 8px;
-            font-weight: 600;
-            cursor: pointer;
-            margin-right: 12px;
-        }
-        .btn-primary {
-            background: #667eea;
-            color: white;
-        }
-        .btn-success {
-            background: #48bb78;
-            color: white;
-        }
-        .btn:hover { opacity: 0.9; }
-        .log-output {
-            background: #1a202c;
-            color: #68d391;
-            padding: 20px;
-            border-radius: 8px;
-            font-family: monospace;
-            font-size: 14px;
-            white-space: pre-wrap;
-            max-height: 400px;
-            overflow-y: auto;
-            margin-top: 16px;
-        }
-        .security-notice {
-            background: #f0fff4;
-            border: 1px solid #9ae6b4;
-            border-radius: 8px;
-            padding: 16px;
-            margin: 16px 0;
-        }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>🔒 SecureBlog UI <span class="security-badge">LOCALHOST ONLY</span></h1>
-            <p style="color: #718096; margin-top: 8px;">
-                WordPress-level ease with maximum security • Zero JavaScript in output • Cryptographic signing
-            </p>
-        </div>
-        
-        <div class="content">
-            <div class="tabs">
-                <button class="tab active" onclick="showTab('write')">✍️ Write</button>
-                <button class="tab" onclick="showTab('images')">🖼️ Images</button>
-                <button class="tab" onclick="showTab('publish')">🚀 Publish</button>
-                <button class="tab" onclick="showTab('security')">🔒 Security</button>
-            </div>
-            
-            <!-- Write Tab -->
-            <div class="section active" id="write">
-                <h2>Create New Post</h2>
-                <div class="security-notice">
-                    🔒 All content is automatically scanned for JavaScript and cryptographically signed
-                </div>
-                
-                <form id="post-form">
-                    <div class="form-group">
-                        <label class="form-label">Post Title</label>
-                        <input type="text" class="form-input" id="post-title" placeholder="My Secure Blog Post">
-                    </div>
-                    
-                    <div class="form-group">
-                        <label class="form-label">Content (Markdown)</label>
-                        <textarea class="form-input" id="post-content" placeholder="Write your content in Markdown..."></textarea>
-                    </div>
-                    
-                    <button type="button" class="btn btn-primary" onclick="savePost()">💾 Save Post</button>
-                    <button type="button" class="btn btn-success" onclick="saveAndBuild()">✅ Save & Build</button>
-                </form>
-                <div id="write-log" class="log-output" style="display:none;"></div>
-            </div>
-            
-            <!-- Images Tab -->
-            <div class="section" id="images">
-                <h2>Upload Images</h2>
-                <p style="color: #718096; margin-bottom: 20px;">
-                    Images are validated for security and integrity-hashed
-                </p>
-                <input type="file" id="image-upload" accept="image/*" multiple onchange="uploadImages()">
-                <div id="image-log" class="log-output" style="display:none;"></div>
-            </div>
-            
-            <!-- Publish Tab -->
-            <div class="section" id="publish">
-                <h2>Secure Publishing</h2>
-                <div class="security-notice">
-                    🚀 Publishing runs all security checks: No-JS verification, integrity hashing, and cryptographic signing
-                </div>
-                <button class="btn btn-success" onclick="publishSite()">🚀 Publish to Production</button>
-                <button class="btn btn-primary" onclick="buildOnly()">🔨 Build Only</button>
-                <div id="publish-log" class="log-output" style="display:none;"></div>
-            </div>
-            
-            <!-- Security Tab -->
-            <div class="section" id="security">
-                <h2>Security Status</h2>
-                <button class="btn btn-primary" onclick="runAudit()">🔍 Run Security Audit</button>
-                <button class="btn btn-primary" onclick="checkIntegrity()">✅ Check Integrity</button>
-                <div id="security-log" class="log-output" style="display:none;"></div>
-            </div>
-        </div>
-    </div>
-
-    <script>
-        function showTab(tabName) {
-            document.querySelectorAll('.tab').forEach(t => t.classList.remove('active'));
-            document.querySelectorAll('.section').forEach(s => s.classList.remove('active'));
-            event.target.classList.add('active');
-            document.getElementById(tabName).classList.add('active');
-        }
-
-        async function apiCall(endpoint, options = {}) {
-            try {
-                const response = await fetch('/api' + endpoint, {
-                    headers: { 'Content-Type': 'application/json' },
-                    ...options
-                });
-                return await response.json();
-            } catch (error) {
-                return { success: false, message: error.message };
-            }
-        }
-
-        async function savePost() {
-            const title = document.getElementById('post-title').value;
-            const content = document.getElementById('post-content').value;
-            
-            const result = await apiCall('/post', {
-                method: 'POST',
-                body: JSON.stringify({ title, content })
-            });
-            
-            document.getElementById('write-log').style.display = 'block';
-            document.getElementById('write-log').textContent = result.message || 'Post saved!';
-        }
-
-        async function saveAndBuild() {
-            await savePost();
-            const result = await apiCall('/build', { method: 'POST' });
-            document.getElementById('write-log').textContent += '\n' + (result.data || result.message);
-        }
-
-        async function uploadImages() {
-            const input = document.getElementById('image-upload');
-            const logDiv = document.getElementById('image-log');
-            logDiv.style.display = 'block';
-            
-            for (let file of input.files) {
-                const formData = new FormData();
-                formData.append('image', file);
-                
-                const response = await fetch('/api/upload', {
-                    method: 'POST',
-                    body: formData
-                });
-                const result = await response.json();
-                logDiv.textContent += file.name + ': ' + result.message + '\n';
-            }
-        }
-
-        async function publishSite() {
-            const logDiv = document.getElementById('publish-log');
-            logDiv.style.display = 'block';
-            logDiv.textContent = 'Publishing with security verification...\n';
-            
-            const result = await apiCall('/publish', { method: 'POST' });
-            logDiv.textContent += result.data || result.message;
-        }
-
-        async function buildOnly() {
-            const logDiv = document.getElementById('publish-log');
-            logDiv.style.display = 'block';
-            const result = await apiCall('/build', { method: 'POST' });
-            logDiv.textContent = result.data || result.message;
-        }
-
-        async function runAudit() {
-            const logDiv = document.getElementById('security-log');
-            logDiv.style.display = 'block';
-            const result = await apiCall('/audit', { method: 'POST' });
-            logDiv.textContent = result.data || result.message;
-        }
-
-        async function checkIntegrity() {
-            const logDiv = document.getElementById('security-log');
-            logDiv.style.display = 'block';
-            const result = await apiCall('/integrity', { method: 'POST' });
-            logDiv.textContent = result.data || result.message;
-        }
-    </script>
-</body>
-</html>`
+// pipelineOpts points the build/audit/verify/publish steps at blogDir; it's
+// set once in main() before any handler can run.
+var pipelineOpts pipeline.Options
+
+// publishBackends holds every named backend configured in config.yaml's
+// publish.backends section, and defaultPublishBackend is the name a
+// request uses when it doesn't pick one explicitly. Both are set once in
+// main(); neither is touched if config.yaml has no publish section, which
+// leaves the UI publishing over git the same as it always did.
+var (
+	publishBackends       map[string]publish.Backend
+	defaultPublishBackend string
+)
 
 func main() {
+	authEnabled := flag.Bool("auth", false, "Require a passphrase (hashed, stored in ~/.config/secureblog/ui.hash) before any /api/* request")
+	flag.Parse()
+
 	blogDir := "."
-	if len(os.Args) > 1 {
-		blogDir = os.Args[1]
+	if flag.NArg() > 0 {
+		blogDir = flag.Arg(0)
+	}
+
+	pipelineOpts = pipeline.Options{
+		ContentDir:  filepath.Join(blogDir, "content"),
+		OutputDir:   filepath.Join(blogDir, "dist"),
+		TemplateDir: filepath.Join(blogDir, "templates"),
+	}
+
+	backends, defaultBackend, err := loadPublishConfig(blogDir)
+	if err != nil {
+		log.Fatalf("loading publish config: %v", err)
+	}
+	publishBackends = backends
+	defaultPublishBackend = defaultBackend
+
+	auth, err := newUIAuth(*authEnabled)
+	if err != nil {
+		log.Fatalf("initializing auth: %v", err)
 	}
 
 	// Ensure localhost-only access
@@ -327,26 +84,34 @@ func main() {
 		}
 
 		// Security headers
-		w.Header().Set("Content-Security-Policy", "default-src 'self' 'unsafe-inline'")
+		w.Header().Set("Content-Security-Policy", dashboardCSP)
 		w.Header().Set("X-Frame-Options", "DENY")
 		w.Header().Set("X-Content-Type-Options", "nosniff")
 
 		w.Header().Set("Content-Type", "text/html")
-		fmt.Fprint(w, dashboardHTML)
+		w.Write(dashboardPage)
 	})
 
 	// API endpoints
-	http.HandleFunc("/api/post", handlePost)
-	http.HandleFunc("/api/upload", handleUpload)
-	http.HandleFunc("/api/build", handleBuild)
-	http.HandleFunc("/api/publish", handlePublish)
-	http.HandleFunc("/api/audit", handleAudit)
-	http.HandleFunc("/api/integrity", handleIntegrity)
+	http.HandleFunc("/api/login", auth.handleLogin)
+	http.HandleFunc("/api/post", auth.requireAuth(handlePost))
+	http.HandleFunc("/api/upload", auth.requireAuth(handleUploadCreate))
+	http.HandleFunc("/api/upload/", auth.requireAuth(handleUploadByID))
+	http.HandleFunc("/api/build", auth.requireAuth(handleBuild))
+	http.HandleFunc("/api/publish", auth.requireAuth(handlePublish))
+	http.HandleFunc("/api/publish/backends", auth.requireAuth(handlePublishBackends))
+	http.HandleFunc("/api/publish/diff", auth.requireAuth(handlePublishDiff))
+	http.HandleFunc("/api/audit", auth.requireAuth(handleAudit))
+	http.HandleFunc("/api/integrity", auth.requireAuth(handleIntegrity))
+	http.HandleFunc("/api/events", auth.requireAuth(jobEventsHandler))
 
 	fmt.Printf("🔒 SecureBlog UI starting on http://localhost:%s\n", serverPort)
 	fmt.Println("🛡️  Security: Localhost-only access")
 	fmt.Println("🚫 Zero JavaScript in blog output")
 	fmt.Println("✅ All content cryptographically signed")
+	if *authEnabled {
+		fmt.Println("🔐 Passphrase auth: enabled (Host-header allowlist + rate-limited /api/login)")
+	}
 
 	log.Fatal(http.ListenAndServe("127.0.0.1:"+serverPort, nil))
 }
@@ -370,14 +135,14 @@ func handlePost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Save post
-	filename := fmt.Sprintf("content/posts/%s-%s.md", 
+	filename := fmt.Sprintf("content/posts/%s-%s.md",
 		time.Now().Format("2006-01-02"),
 		strings.ToLower(strings.ReplaceAll(req.Title, " ", "-")))
-	
+
 	os.MkdirAll("content/posts", 0755)
 	content := fmt.Sprintf("---\ntitle: \"%s\"\ndate: %s\n---\n\n%s",
 		req.Title, time.Now().Format("2006-01-02"), req.Content)
-	
+
 	err := os.WriteFile(filename, []byte(content), 0644)
 	if err != nil {
 		jsonResponse(w, Response{Success: false, Message: err.Error()})
@@ -387,73 +152,135 @@ func handlePost(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, Response{Success: true, Message: "Post saved: " + filename})
 }
 
-func handleUpload(w http.ResponseWriter, r *http.Request) {
-	r.ParseMultipartForm(maxUploadSize)
-	file, header, err := r.FormFile("image")
-	if err != nil {
-		jsonResponse(w, Response{Success: false, Message: "Upload failed"})
-		return
+// handleBuild starts a build job and returns its ID immediately; the
+// browser streams its progress from /api/events?job=<id>.
+func handleBuild(w http.ResponseWriter, r *http.Request) {
+	job := runJob("build", func(ctx context.Context, out *Job) error {
+		return pipeline.Build(ctx, pipelineOpts, out)
+	})
+	jsonResponse(w, Response{Success: true, Message: "Build started", Data: map[string]string{"job": job.ID}})
+}
+
+// handlePublish starts a publish job (sign, verify, then push) and returns
+// its ID immediately; the browser streams its progress from
+// /api/events?job=<id>. The request body may name a configured backend
+// ({"backend": "..."}); an empty or omitted name falls back to
+// defaultPublishBackend, and "git" (or no backend at all configured)
+// means the original git add/commit/push flow.
+func handlePublish(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Backend string `json:"backend"`
 	}
-	defer file.Close()
+	json.NewDecoder(r.Body).Decode(&req)
 
-	// Validate image type
-	ext := strings.ToLower(filepath.Ext(header.Filename))
-	if ext != ".jpg" && ext != ".jpeg" && ext != ".png" && ext != ".webp" {
-		jsonResponse(w, Response{Success: false, Message: "Invalid image type"})
+	backend, err := resolvePublishBackend(req.Backend)
+	if err != nil {
+		jsonResponse(w, Response{Success: false, Message: err.Error()})
 		return
 	}
 
-	// Save image
-	os.MkdirAll("static/images", 0755)
-	dst, _ := os.Create(filepath.Join("static/images", header.Filename))
-	defer dst.Close()
-	io.Copy(dst, file)
+	job := runJob("publish", func(ctx context.Context, out *Job) error {
+		if backend == nil {
+			return pipeline.Publish(ctx, pipelineOpts, out)
+		}
+		return pipeline.PublishTo(ctx, pipelineOpts, out, backend)
+	})
+	jsonResponse(w, Response{Success: true, Message: "Publish started", Data: map[string]string{"job": job.ID}})
+}
+
+// handlePublishBackends lists the backend names the Publish tab's selector
+// can offer: "git" (always available) plus every name configured under
+// config.yaml's publish.backends.
+func handlePublishBackends(w http.ResponseWriter, r *http.Request) {
+	names := []string{"git"}
+	for name := range publishBackends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 
-	jsonResponse(w, Response{Success: true, Message: "Image uploaded: " + header.Filename})
+	def := defaultPublishBackend
+	if def == "" {
+		def = "git"
+	}
+	jsonResponse(w, Response{Success: true, Data: map[string]interface{}{
+		"backends": names,
+		"default":  def,
+	}})
 }
 
-func handleBuild(w http.ResponseWriter, r *http.Request) {
-	output, err := runCommand("./build-sandbox.sh")
-	if err != nil {
-		jsonResponse(w, Response{Success: false, Message: err.Error(), Data: string(output)})
+// handlePublishDiff reports, without writing anything, which object keys a
+// publish to the named backend would upload, delete, or leave alone,
+// based on the manifest of the current dist/ output. It only makes sense
+// for an object-storage/rsync backend -- git tracks its own diff already.
+func handlePublishDiff(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Backend string `json:"backend"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	name := req.Backend
+	if name == "" {
+		name = defaultPublishBackend
+	}
+	backend, ok := publishBackends[name]
+	if !ok {
+		jsonResponse(w, Response{Success: false, Message: fmt.Sprintf("dry-run diff needs a configured object-storage backend, got %q", name)})
 		return
 	}
-	jsonResponse(w, Response{Success: true, Message: "Build complete", Data: string(output)})
-}
 
-func handlePublish(w http.ResponseWriter, r *http.Request) {
-	output, err := runCommand("./build-sandbox.sh && bash .scripts/security-regression-guard.sh dist && git add . && git commit -m 'Publish' && git push")
+	manifest, err := security.BuildManifestFor(pipelineOpts.OutputDir)
 	if err != nil {
-		jsonResponse(w, Response{Success: false, Message: err.Error(), Data: string(output)})
+		jsonResponse(w, Response{Success: false, Message: err.Error()})
 		return
 	}
-	jsonResponse(w, Response{Success: true, Message: "Published!", Data: string(output)})
-}
 
-func handleAudit(w http.ResponseWriter, r *http.Request) {
-	output, err := runCommand("bash .scripts/security-regression-guard.sh dist")
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	remote, err := backend.List(ctx)
 	if err != nil {
-		jsonResponse(w, Response{Success: false, Message: err.Error(), Data: string(output)})
+		jsonResponse(w, Response{Success: false, Message: err.Error()})
 		return
 	}
-	jsonResponse(w, Response{Success: true, Message: "Audit complete", Data: string(output)})
+
+	jsonResponse(w, Response{Success: true, Data: publish.Plan(manifest, remote)})
 }
 
-func handleIntegrity(w http.ResponseWriter, r *http.Request) {
-	output, err := runCommand("bash scripts/integrity-verify.sh dist")
-	if err != nil {
-		jsonResponse(w, Response{Success: false, Message: err.Error(), Data: string(output)})
-		return
+// resolvePublishBackend turns a backend name from a request into a
+// configured publish.Backend, or nil for the git flow. An empty name
+// falls back to defaultPublishBackend.
+func resolvePublishBackend(name string) (publish.Backend, error) {
+	if name == "" {
+		name = defaultPublishBackend
+	}
+	if name == "" || name == "git" {
+		return nil, nil
+	}
+	backend, ok := publishBackends[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown publish backend %q", name)
 	}
-	jsonResponse(w, Response{Success: true, Message: "Integrity verified", Data: string(output)})
+	return backend, nil
 }
 
-func runCommand(command string) ([]byte, error) {
-	cmd := exec.Command("bash", "-c", command)
-	return cmd.CombinedOutput()
+// handleAudit starts an audit job and returns its ID immediately; the
+// browser streams its progress from /api/events?job=<id>.
+func handleAudit(w http.ResponseWriter, r *http.Request) {
+	job := runJob("audit", func(ctx context.Context, out *Job) error {
+		return pipeline.Audit(ctx, pipelineOpts, out)
+	})
+	jsonResponse(w, Response{Success: true, Message: "Audit started", Data: map[string]string{"job": job.ID}})
+}
+
+// handleIntegrity starts an integrity-verification job and returns its ID
+// immediately; the browser streams its progress from /api/events?job=<id>.
+func handleIntegrity(w http.ResponseWriter, r *http.Request) {
+	job := runJob("integrity", func(ctx context.Context, out *Job) error {
+		return pipeline.Verify(ctx, pipelineOpts, out)
+	})
+	jsonResponse(w, Response{Success: true, Message: "Integrity check started", Data: map[string]string{"job": job.ID}})
 }
 
 func jsonResponse(w http.ResponseWriter, resp Response) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
-}
\ No newline at end of file
+}
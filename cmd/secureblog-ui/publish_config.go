@@ -0,0 +1,55 @@
+// publish_config.go - reads the optional publish backends out of
+// config.yaml, so the dashboard's Publish tab can push to an
+// object-storage or rsync destination instead of only git.
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"secureblog/plugins/publish"
+)
+
+// publishFileConfig is config.yaml's "publish" section: zero or more
+// named backends (each a "kind" plus that kind's own settings) and which
+// one handlePublish targets when the caller doesn't specify one.
+type publishFileConfig struct {
+	Publish struct {
+		Default  string                            `yaml:"default"`
+		Backends map[string]map[string]interface{} `yaml:"backends"`
+	} `yaml:"publish"`
+}
+
+// loadPublishConfig reads <blogDir>/config.yaml and builds every backend
+// it names, validating each one's credentials. A missing config.yaml
+// leaves both return values empty: the UI falls back to git only, the
+// same as it always did.
+func loadPublishConfig(blogDir string) (map[string]publish.Backend, string, error) {
+	data, err := os.ReadFile(filepath.Join(blogDir, "config.yaml"))
+	if os.IsNotExist(err) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("reading config.yaml: %w", err)
+	}
+
+	var cfg publishFileConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, "", fmt.Errorf("parsing config.yaml: %w", err)
+	}
+
+	backends := make(map[string]publish.Backend, len(cfg.Publish.Backends))
+	for name, beCfg := range cfg.Publish.Backends {
+		kind, _ := beCfg["kind"].(string)
+		backend, err := publish.NewBackend(kind, beCfg)
+		if err != nil {
+			return nil, "", fmt.Errorf("config.yaml: publish.backends.%s: %w", name, err)
+		}
+		backends[name] = backend
+	}
+
+	return backends, cfg.Publish.Default, nil
+}
@@ -0,0 +1,332 @@
+// upload.go - resumable, streaming image uploads.
+//
+// The old handleUpload buffered the whole request in memory
+// (ParseMultipartForm), trusted the filename's extension to decide what
+// was being uploaded, and saved the bytes verbatim -- so a renamed
+// polyglot file, or an image with an EXIF payload crafted to exploit a
+// downstream viewer, would have been stored and served as-is.
+//
+// This replaces it with a small tus-inspired protocol: POST /api/upload
+// declares a total size and opens a session, PATCH /api/upload/{id} with
+// an Upload-Offset header appends one chunk (so a dropped connection can
+// resume instead of restarting), and HEAD /api/upload/{id} reports
+// progress. Once the declared length is reached, the upload is sniffed by
+// content rather than extension, decoded and re-encoded to strip any
+// metadata or trailing bytes a real image decoder wouldn't produce, and
+// only then written to its final, content-addressed path.
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"secureblog/internal/security"
+
+	_ "golang.org/x/image/webp" // registers the WebP decoder with image.Decode
+)
+
+const (
+	maxUploadBytes = 10 << 20 // 10MB, same ceiling the old single-request upload had
+	uploadSniffLen = 512      // bytes http.DetectContentType needs to see
+	uploadTempDir  = "static/images/.uploads"
+)
+
+// uploadAllowedTypes is checked against the sniffed content, never the
+// filename extension.
+var uploadAllowedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+// uploadSession tracks one in-progress resumable upload: how many bytes
+// have landed so far, and the temp file they're being appended to.
+type uploadSession struct {
+	mu       sync.Mutex
+	ID       string
+	Length   int64
+	Offset   int64
+	tempPath string
+	file     *os.File
+	rawHash  hash.Hash
+	created  time.Time
+}
+
+var (
+	uploadsMutex sync.RWMutex
+	uploads      = make(map[string]*uploadSession)
+)
+
+// newUploadSession opens a fresh temp file in uploadTempDir for an upload
+// that will total length bytes.
+func newUploadSession(length int64) (*uploadSession, error) {
+	if err := os.MkdirAll(uploadTempDir, 0755); err != nil {
+		return nil, fmt.Errorf("creating %s: %w", uploadTempDir, err)
+	}
+
+	id := security.GenerateNonce()
+	f, err := os.Create(filepath.Join(uploadTempDir, id))
+	if err != nil {
+		return nil, fmt.Errorf("creating temp upload file: %w", err)
+	}
+
+	return &uploadSession{
+		ID:       id,
+		Length:   length,
+		tempPath: f.Name(),
+		file:     f,
+		rawHash:  sha256.New(),
+		created:  time.Now(),
+	}, nil
+}
+
+// abort closes and discards the session's temp file.
+func (s *uploadSession) abort() {
+	s.file.Close()
+	os.Remove(s.tempPath)
+}
+
+// handleUploadCreate opens a new resumable upload session. The client
+// declares the total size with Upload-Length; the response's Location
+// header is where it PATCHes chunks.
+func handleUploadCreate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length <= 0 {
+		http.Error(w, "Upload-Length header required", http.StatusBadRequest)
+		return
+	}
+	if length > maxUploadBytes {
+		http.Error(w, fmt.Sprintf("Upload-Length exceeds the %d byte limit", maxUploadBytes), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	session, err := newUploadSession(length)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	uploadsMutex.Lock()
+	uploads[session.ID] = session
+	uploadsMutex.Unlock()
+
+	w.Header().Set("Location", "/api/upload/"+session.ID)
+	w.Header().Set("Upload-Offset", "0")
+	w.WriteHeader(http.StatusCreated)
+}
+
+// handleUploadByID dispatches PATCH (append a chunk) and HEAD (report
+// progress) for the upload session named by the URL's trailing ID.
+func handleUploadByID(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/upload/")
+
+	uploadsMutex.RLock()
+	session, ok := uploads[id]
+	uploadsMutex.RUnlock()
+	if !ok {
+		http.Error(w, "Upload not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodHead:
+		session.mu.Lock()
+		offset, length := session.Offset, session.Length
+		session.mu.Unlock()
+		w.Header().Set("Upload-Offset", strconv.FormatInt(offset, 10))
+		w.Header().Set("Upload-Length", strconv.FormatInt(length, 10))
+		w.WriteHeader(http.StatusOK)
+	case http.MethodPatch:
+		handleUploadAppend(w, r, session)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleUploadAppend appends the request body to session starting at its
+// Upload-Offset header, rejecting a mismatched offset the way tus does
+// (409 Conflict), and finalizes the upload once it reaches its declared
+// length.
+func handleUploadAppend(w http.ResponseWriter, r *http.Request, session *uploadSession) {
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil {
+		http.Error(w, "Upload-Offset header required", http.StatusBadRequest)
+		return
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	if offset != session.Offset {
+		http.Error(w, fmt.Sprintf("Upload-Offset %d does not match current offset %d", offset, session.Offset), http.StatusConflict)
+		return
+	}
+
+	n, err := io.Copy(io.MultiWriter(session.file, session.rawHash), io.LimitReader(r.Body, session.Length-session.Offset+1))
+	if err != nil {
+		http.Error(w, "Write failed", http.StatusInternalServerError)
+		return
+	}
+	session.Offset += n
+
+	if session.Offset > session.Length {
+		session.abort()
+		uploadsMutex.Lock()
+		delete(uploads, session.ID)
+		uploadsMutex.Unlock()
+		http.Error(w, "Uploaded more bytes than Upload-Length declared", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+
+	if session.Offset < session.Length {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	path, hash, err := finalizeUpload(session)
+	uploadsMutex.Lock()
+	delete(uploads, session.ID)
+	uploadsMutex.Unlock()
+	if err != nil {
+		jsonResponse(w, Response{Success: false, Message: err.Error()})
+		return
+	}
+
+	jsonResponse(w, Response{Success: true, Message: "Image uploaded: " + path, Data: map[string]string{
+		"path":   path,
+		"sha256": hash,
+	}})
+}
+
+// finalizeUpload runs once an upload has received all its declared bytes:
+// it sniffs the real content type, decodes and re-encodes the image to
+// strip EXIF and any non-pixel bytes a polyglot file might carry, and
+// moves the result into its content-addressed final path. The temp file
+// is always removed, whether or not validation succeeds.
+func finalizeUpload(session *uploadSession) (path string, sha256Hex string, err error) {
+	if err := session.file.Close(); err != nil {
+		return "", "", fmt.Errorf("closing upload: %w", err)
+	}
+	defer os.Remove(session.tempPath)
+
+	mimeType, err := sniffUploadType(session.tempPath)
+	if err != nil {
+		return "", "", err
+	}
+	if !uploadAllowedTypes[mimeType] {
+		return "", "", fmt.Errorf("unsupported image type %q", mimeType)
+	}
+
+	data, ext, err := reencodeImage(session.tempPath)
+	if err != nil {
+		return "", "", fmt.Errorf("re-encoding image: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	dir := filepath.Join("static", "images", hash[:2])
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", "", fmt.Errorf("creating %s: %w", dir, err)
+	}
+	finalPath := filepath.Join(dir, hash+ext)
+	if err := os.WriteFile(finalPath, data, 0644); err != nil {
+		return "", "", fmt.Errorf("writing %s: %w", finalPath, err)
+	}
+
+	// finalPath is now inside the site's static tree, so the next build's
+	// integrity manifest (internal/security.BuildManifestFor) picks up its
+	// hash automatically -- no separate integrity.txt bookkeeping needed.
+	return finalPath, hash, nil
+}
+
+// sniffUploadType reads the first uploadSniffLen bytes of path and
+// classifies them with http.DetectContentType, ignoring whatever
+// extension the client's filename claimed.
+func sniffUploadType(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	buf := make([]byte, uploadSniffLen)
+	n, err := f.Read(buf)
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("reading upload for type detection: %w", err)
+	}
+	return http.DetectContentType(buf[:n]), nil
+}
+
+// maxImageDimension bounds the width/height an uploaded image may decode
+// to, the same limit cmd/admin-server's reencodeImage enforces: a small,
+// highly-compressible file (e.g. a near-solid-color PNG) can declare an
+// enormous pixel grid and blow up memory/CPU on decode otherwise.
+const maxImageDimension = 8000
+
+// reencodeImage decodes path as an image and re-encodes it, discarding any
+// metadata (EXIF, color profiles, comment chunks) and any bytes beyond the
+// decoded pixel data -- the same technique a polyglot file relies on
+// surviving. JPEG and PNG round-trip in their own format; WebP is decoded
+// but, since the standard toolchain has no pure-Go WebP encoder, re-encoded
+// as PNG instead of trusting the original bytes.
+func reencodeImage(path string) (data []byte, ext string, err error) {
+	f, openErr := os.Open(path)
+	if openErr != nil {
+		return nil, "", openErr
+	}
+	defer f.Close()
+
+	cfg, _, cfgErr := image.DecodeConfig(f)
+	if cfgErr != nil {
+		return nil, "", fmt.Errorf("invalid image data: %w", cfgErr)
+	}
+	if cfg.Width > maxImageDimension || cfg.Height > maxImageDimension {
+		return nil, "", fmt.Errorf("image dimensions %dx%d exceed the %dx%d maximum", cfg.Width, cfg.Height, maxImageDimension, maxImageDimension)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, "", fmt.Errorf("rewinding upload after dimension check: %w", err)
+	}
+
+	img, format, decodeErr := image.Decode(f)
+	if decodeErr != nil {
+		return nil, "", fmt.Errorf("decoding image: %w", decodeErr)
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90})
+		ext = ".jpg"
+	case "png", "webp":
+		err = png.Encode(&buf, img)
+		ext = ".png"
+	default:
+		return nil, "", fmt.Errorf("unsupported image format %q", format)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("encoding image: %w", err)
+	}
+	return buf.Bytes(), ext, nil
+}
@@ -0,0 +1,147 @@
+package assets
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile(%q) error = %v", name, err)
+	}
+}
+
+func TestGenerateFingerprintsAndWritesAssets(t *testing.T) {
+	staticDir := t.TempDir()
+	outputDir := t.TempDir()
+	writeFile(t, staticDir, "css/style.css", "body{color:red}")
+	writeFile(t, staticDir, "robots.txt", "User-agent: *")
+
+	m, err := Generate(staticDir, outputDir)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	asset, ok := m.Assets["css/style.css"]
+	if !ok {
+		t.Fatal("Generate() did not record css/style.css")
+	}
+	if !strings.HasPrefix(asset.FingerprintedPath, "css/style.") || !strings.HasSuffix(asset.FingerprintedPath, ".css") {
+		t.Errorf("FingerprintedPath = %q, want css/style.<hash8>.css", asset.FingerprintedPath)
+	}
+	if !strings.HasPrefix(asset.Integrity, "sha384-") {
+		t.Errorf("Integrity = %q, want a sha384- prefixed value", asset.Integrity)
+	}
+	if _, ok := m.Assets["robots.txt"]; ok {
+		t.Error("Generate() fingerprinted a non-fingerprintable extension")
+	}
+
+	data, err := os.ReadFile(filepath.Join(outputDir, asset.FingerprintedPath))
+	if err != nil {
+		t.Fatalf("fingerprinted file was not written: %v", err)
+	}
+	if string(data) != "body{color:red}" {
+		t.Errorf("fingerprinted file content = %q, want unchanged source", data)
+	}
+}
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	staticDir := t.TempDir()
+	writeFile(t, staticDir, "app.js", "console.log(1)")
+
+	a, err := Generate(staticDir, t.TempDir())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	b, err := Generate(staticDir, t.TempDir())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if a.Assets["app.js"].FingerprintedPath != b.Assets["app.js"].FingerprintedPath {
+		t.Error("Generate() produced different fingerprints for identical content")
+	}
+}
+
+func TestManifestSaveLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ManifestFilename)
+	m := &Manifest{Assets: map[string]Asset{
+		"css/style.css": {FingerprintedPath: "css/style.abcd1234.css", Integrity: "sha384-xyz"},
+	}}
+
+	if err := m.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.Assets["css/style.css"] != m.Assets["css/style.css"] {
+		t.Errorf("Load() = %+v, want %+v", loaded.Assets["css/style.css"], m.Assets["css/style.css"])
+	}
+}
+
+func TestRewriteHTMLAddsIntegrityAndFingerprintedPaths(t *testing.T) {
+	m := &Manifest{Assets: map[string]Asset{
+		"css/style.css": {FingerprintedPath: "css/style.abcd1234.css", Integrity: "sha384-xyz"},
+		"js/app.js":     {FingerprintedPath: "js/app.5678efgh.js", Integrity: "sha384-abc"},
+	}}
+
+	input := []byte(`<html><head><link rel="stylesheet" href="/css/style.css"></head>` +
+		`<body><script src="/js/app.js"></script><img src="/img/logo.png"></body></html>`)
+
+	out, err := m.RewriteHTML(input)
+	if err != nil {
+		t.Fatalf("RewriteHTML() error = %v", err)
+	}
+	got := string(out)
+
+	if !strings.Contains(got, `href="/css/style.abcd1234.css"`) {
+		t.Errorf("RewriteHTML() did not rewrite the stylesheet href: %s", got)
+	}
+	if !strings.Contains(got, `integrity="sha384-xyz"`) || !strings.Contains(got, `crossorigin="anonymous"`) {
+		t.Errorf("RewriteHTML() did not add integrity/crossorigin to the stylesheet: %s", got)
+	}
+	if !strings.Contains(got, `src="/js/app.5678efgh.js"`) {
+		t.Errorf("RewriteHTML() did not rewrite the script src: %s", got)
+	}
+	if !strings.Contains(got, `src="/img/logo.png"`) {
+		t.Errorf("RewriteHTML() rewrote a reference with no matching asset: %s", got)
+	}
+}
+
+func TestInlineScriptHashesIgnoresExternalScripts(t *testing.T) {
+	input := []byte(`<html><body><script src="/js/app.js"></script>` +
+		`<script>console.log("hi")</script></body></html>`)
+
+	hashes, err := InlineScriptHashes(input)
+	if err != nil {
+		t.Fatalf("InlineScriptHashes() error = %v", err)
+	}
+	if len(hashes) != 1 {
+		t.Fatalf("InlineScriptHashes() = %v, want exactly one inline script hash", hashes)
+	}
+	if !strings.HasPrefix(hashes[0], "sha256-") {
+		t.Errorf("hash = %q, want a sha256- prefixed value", hashes[0])
+	}
+}
+
+func TestInlineScriptHashesNoneWhenNoInlineScripts(t *testing.T) {
+	input := []byte(`<html><body><script src="/js/app.js"></script></body></html>`)
+
+	hashes, err := InlineScriptHashes(input)
+	if err != nil {
+		t.Fatalf("InlineScriptHashes() error = %v", err)
+	}
+	if len(hashes) != 0 {
+		t.Errorf("InlineScriptHashes() = %v, want none", hashes)
+	}
+}
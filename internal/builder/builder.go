@@ -22,6 +22,17 @@ type Config struct {
 	OutputDir   string
 	TemplateDir string
 	Secure      bool
+
+	// MarkdownEngine selects BuilderV2's markdown plugin engine
+	// ("blackfriday" or "goldmark"). Unused by the legacy Builder, which
+	// always renders with blackfriday directly. Empty keeps the
+	// blackfriday default.
+	MarkdownEngine string
+
+	// SiteURL is the site's canonical base URL, used by the absURL
+	// template func. Empty leaves absURL's input unresolved (relative
+	// paths pass through unchanged).
+	SiteURL string
 }
 
 type Builder struct {
@@ -112,7 +123,7 @@ func (b *Builder) loadPosts() error {
 			blackfriday.WithRenderer(blackfriday.NewHTMLRenderer(
 				blackfriday.HTMLRendererParameters{
 					Flags: blackfriday.NoreferrerLinks |
-						blackfriday.NoFollowLinks |
+						blackfriday.NofollowLinks |
 						blackfriday.HrefTargetBlank,
 				})))
 
@@ -141,7 +152,7 @@ func (b *Builder) loadPosts() error {
 
 func (b *Builder) generatePost(tmpl *template.Template, post Post) error {
 	outputPath := filepath.Join(b.config.OutputDir, post.Slug+".html")
-	
+
 	var buf bytes.Buffer
 	data := struct {
 		Post      Post
@@ -163,7 +174,7 @@ func (b *Builder) generatePost(tmpl *template.Template, post Post) error {
 
 func (b *Builder) generateIndex(tmpl *template.Template) error {
 	outputPath := filepath.Join(b.config.OutputDir, "index.html")
-	
+
 	var buf bytes.Buffer
 	data := struct {
 		Posts    []Post
@@ -184,7 +195,7 @@ func (b *Builder) generateIndex(tmpl *template.Template) error {
 
 func (b *Builder) generateRSS() error {
 	outputPath := filepath.Join(b.config.OutputDir, "feed.xml")
-	
+
 	rss := `<?xml version="1.0" encoding="UTF-8"?>
 <rss version="2.0">
 <channel>
@@ -201,9 +212,9 @@ func (b *Builder) generateRSS() error {
 </item>
 `, security.EscapeXML(post.Title), post.Slug, post.Date.Format(time.RFC1123Z), post.Content)
 	}
-	
+
 	rss += `</channel></rss>`
-	
+
 	return ioutil.WriteFile(outputPath, []byte(rss), 0644)
 }
 
@@ -220,9 +231,9 @@ func (b *Builder) copyStatic() error {
 
 		rel, _ := filepath.Rel(staticDir, path)
 		outputPath := filepath.Join(b.config.OutputDir, rel)
-		
+
 		os.MkdirAll(filepath.Dir(outputPath), 0755)
-		
+
 		input, err := ioutil.ReadFile(path)
 		if err != nil {
 			return err
@@ -230,4 +241,4 @@ func (b *Builder) copyStatic() error {
 
 		return ioutil.WriteFile(outputPath, input, 0644)
 	})
-}
\ No newline at end of file
+}
@@ -7,17 +7,34 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"runtime"
+	"secureblog/internal/assets"
 	"secureblog/internal/plugin"
+	"secureblog/internal/security"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
+// builderVersion is part of the post cache key (see postCacheKey), so a
+// change to how BuilderV2 renders posts invalidates every cached entry
+// instead of silently serving stale HTML.
+const builderVersion = "v2"
+
+// protectedPlaceholder replaces a password-protected post's real content in
+// Post.Content and the post cache, so anything that forgets to check
+// Metadata["protected"] (a future output plugin, a template) leaks only
+// this placeholder rather than the plaintext the post was protected to hide.
+const protectedPlaceholder = "<p>This post is password-protected.</p>"
+
 // BuilderV2 is the plugin-based builder
 type BuilderV2 struct {
 	config        Config
 	pluginManager *plugin.PluginManager
 	posts         []plugin.Post
+	postsChanged  bool
+	assetManifest *assets.Manifest
 }
 
 // NewV2 creates a plugin-based builder
@@ -47,6 +64,12 @@ func (b *BuilderV2) Build() error {
 		return fmt.Errorf("parsing templates: %w", err)
 	}
 
+	// Fingerprint static assets before any HTML is generated, so pages can
+	// be rewritten to reference the fingerprinted, integrity-checked names.
+	if err := b.copyStatic(); err != nil {
+		return fmt.Errorf("copying static files: %w", err)
+	}
+
 	// Load and process posts
 	if err := b.loadPosts(); err != nil {
 		return fmt.Errorf("loading posts: %w", err)
@@ -59,19 +82,21 @@ func (b *BuilderV2) Build() error {
 		}
 	}
 
-	// Generate index
-	if err := b.generateIndex(tmpl); err != nil {
-		return fmt.Errorf("generating index: %w", err)
-	}
+	// Index, taxonomy pages, and output plugins (RSS, sitemap, etc.) all
+	// list every post, so they only need regenerating when loadPosts found
+	// at least one post whose cached HTML was stale or missing.
+	if b.postsChanged {
+		if err := b.generateIndex(tmpl); err != nil {
+			return fmt.Errorf("generating index: %w", err)
+		}
 
-	// Run output plugins (RSS, sitemap, etc.)
-	if err := b.pluginManager.GenerateOutputs(b.posts, b.config.OutputDir); err != nil {
-		return fmt.Errorf("generating outputs: %w", err)
-	}
+		if err := b.generateTaxonomies(tmpl); err != nil {
+			return fmt.Errorf("generating taxonomies: %w", err)
+		}
 
-	// Copy static files
-	if err := b.copyStatic(); err != nil {
-		return fmt.Errorf("copying static files: %w", err)
+		if err := b.pluginManager.GenerateOutputs(b.posts, b.config.OutputDir); err != nil {
+			return fmt.Errorf("generating outputs: %w", err)
+		}
 	}
 
 	// Generate security headers
@@ -88,18 +113,21 @@ func (b *BuilderV2) Build() error {
 }
 
 func (b *BuilderV2) parseTemplates() (*template.Template, error) {
-	tmpl := template.New("").Funcs(template.FuncMap{
-		"truncate": func(s string, n int) string {
-			if len(s) <= n {
-				return s
-			}
-			return s[:n] + "..."
-		},
-	})
-
+	tmpl := template.New("").Funcs(templateFuncs(b.config.SiteURL))
 	return tmpl.ParseGlob(filepath.Join(b.config.TemplateDir, "*.html"))
 }
 
+// postJob is one post file parsed enough to compute its cache key, queued
+// up for (possibly cached) rendering.
+type postJob struct {
+	slug       string
+	title      string
+	date       string
+	fm         FrontMatter
+	rawContent []byte
+	key        string
+}
+
 func (b *BuilderV2) loadPosts() error {
 	postsDir := filepath.Join(b.config.ContentDir, "posts")
 	files, err := ioutil.ReadDir(postsDir)
@@ -107,6 +135,24 @@ func (b *BuilderV2) loadPosts() error {
 		return err
 	}
 
+	stamp, err := templateStamp(b.config.TemplateDir)
+	if err != nil {
+		return fmt.Errorf("stamping templates: %w", err)
+	}
+	fingerprint := b.pluginManager.Fingerprint()
+
+	shortcodesDir := filepath.Join(b.config.TemplateDir, "shortcodes")
+	shortcodes, err := loadShortcodes(shortcodesDir, templateFuncs(b.config.SiteURL))
+	if err != nil {
+		return fmt.Errorf("loading shortcode templates: %w", err)
+	}
+
+	cache, err := loadPostCache(PostCachePath)
+	if err != nil {
+		return fmt.Errorf("loading post cache: %w", err)
+	}
+
+	var jobs []postJob
 	for _, file := range files {
 		if filepath.Ext(file.Name()) != ".md" {
 			continue
@@ -117,43 +163,89 @@ func (b *BuilderV2) loadPosts() error {
 			return err
 		}
 
-		// Extract metadata if present
-		metadata := make(map[string]interface{})
-		rawContent := content
-		
-		// Check for front matter
-		if bytes.HasPrefix(content, []byte("---\n")) {
-			parts := bytes.SplitN(content[4:], []byte("\n---\n"), 2)
-			if len(parts) == 2 {
-				// Parse YAML front matter here if needed
-				rawContent = parts[1]
-			}
+		fm, rawContent, err := splitFrontMatter(content)
+		if err != nil {
+			return fmt.Errorf("post %s: %w", file.Name(), err)
+		}
+		if fm.Draft {
+			continue
 		}
 
-		// Process content through plugins
-		processed, err := b.pluginManager.ProcessContent(rawContent, metadata)
+		rawContent, err = expandShortcodes(rawContent, shortcodes)
 		if err != nil {
-			return err
+			return fmt.Errorf("post %s: %w", file.Name(), err)
 		}
 
-		// Apply security plugins
-		secured, err := b.pluginManager.ApplySecurity(processed)
-		if err != nil {
-			return err
+		title := strings.TrimSuffix(file.Name(), ".md")
+		if fm.Title != "" {
+			title = fm.Title
+		}
+		date := file.ModTime().Format(time.RFC3339)
+		if fm.Date != "" {
+			date = fm.Date
 		}
 
-		post := plugin.Post{
-			Title:      strings.TrimSuffix(file.Name(), ".md"),
-			Content:    template.HTML(secured),
-			RawContent: rawContent,
-			Date:       file.ModTime().Format(time.RFC3339),
-			Slug:       strings.TrimSuffix(file.Name(), ".md"),
-			Metadata:   metadata,
+		jobs = append(jobs, postJob{
+			slug:       strings.TrimSuffix(file.Name(), ".md"),
+			title:      title,
+			date:       date,
+			fm:         fm,
+			rawContent: rawContent,
+			key:        postCacheKey(rawContent, fm, stamp, fingerprint),
+		})
+	}
+
+	posts := make([]plugin.Post, len(jobs))
+	errs := make([]error, len(jobs))
+
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	indexes := make(chan int)
+	go func() {
+		defer close(indexes)
+		for i := range jobs {
+			indexes <- i
+		}
+	}()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				post, changed, err := b.renderPost(cache, jobs[i])
+				posts[i] = post
+				errs[i] = err
+				if changed {
+					mu.Lock()
+					b.postsChanged = true
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
 		}
+	}
 
-		b.posts = append(b.posts, post)
+	if err := cache.save(PostCachePath); err != nil {
+		return fmt.Errorf("saving post cache: %w", err)
 	}
 
+	b.posts = posts
+
 	// Sort posts by date (newest first)
 	sort.Slice(b.posts, func(i, j int) bool {
 		return b.posts[i].Date > b.posts[j].Date
@@ -162,9 +254,136 @@ func (b *BuilderV2) loadPosts() error {
 	return nil
 }
 
+// renderPost produces the Post for job, reusing cached sanitized HTML when
+// the cache already has an entry for job.key, and otherwise running the
+// post through the content and security plugin pipeline. changed reports
+// whether this was a cache miss.
+func (b *BuilderV2) renderPost(cache *postCache, job postJob) (plugin.Post, bool, error) {
+	metadata := map[string]interface{}{
+		"tags":       job.fm.Tags,
+		"categories": job.fm.Categories,
+		"photo":      job.fm.Photo,
+	}
+
+	secured, ok := cache.get(job.key)
+	changed := !ok
+	if !ok {
+		processed, err := b.pluginManager.ProcessContent(job.rawContent, metadata)
+		if err != nil {
+			return plugin.Post{}, false, err
+		}
+
+		securedBytes, err := b.pluginManager.ApplySecurity(processed)
+		if err != nil {
+			return plugin.Post{}, false, err
+		}
+		secured = string(securedBytes)
+	}
+	cache.put(job.key, secured)
+
+	content := secured
+	if job.fm.Password != "" {
+		protected, err := security.ProtectPost([]byte(secured), job.fm.Password, job.fm.SecretCode)
+		if err != nil {
+			return plugin.Post{}, false, fmt.Errorf("protecting post %s: %w", job.slug, err)
+		}
+		metadata["protected"] = true
+		metadata["protectedPost"] = protected
+		content = protectedPlaceholder
+	}
+
+	return plugin.Post{
+		Title:      job.title,
+		Content:    template.HTML(content),
+		RawContent: job.rawContent,
+		Date:       job.date,
+		Slug:       job.slug,
+		Tags:       job.fm.Tags,
+		Categories: job.fm.Categories,
+		Metadata:   metadata,
+	}, changed, nil
+}
+
+// taxonomyIndex maps a tag or category name to the posts tagged with it.
+func taxonomyIndex(posts []plugin.Post, terms func(plugin.Post) []string) map[string][]plugin.Post {
+	index := make(map[string][]plugin.Post)
+	for _, post := range posts {
+		for _, term := range terms(post) {
+			index[term] = append(index[term], post)
+		}
+	}
+	return index
+}
+
+// generateTaxonomies writes one index page per tag and per category,
+// under <output>/tags/<tag>.html and <output>/categories/<category>.html,
+// reusing the "index.html" template with a filtered post list.
+func (b *BuilderV2) generateTaxonomies(tmpl *template.Template) error {
+	taxonomies := []struct {
+		dir   string
+		terms func(plugin.Post) []string
+	}{
+		{"tags", func(p plugin.Post) []string { return p.Tags }},
+		{"categories", func(p plugin.Post) []string { return p.Categories }},
+	}
+
+	for _, tax := range taxonomies {
+		index := taxonomyIndex(b.posts, tax.terms)
+		if len(index) == 0 {
+			continue
+		}
+
+		dir := filepath.Join(b.config.OutputDir, tax.dir)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+
+		for term, posts := range index {
+			data := struct {
+				Term  string
+				Posts []plugin.Post
+			}{Term: term, Posts: posts}
+
+			processed, err := b.pluginManager.PreRender(data)
+			if err != nil {
+				return err
+			}
+
+			var buf bytes.Buffer
+			if err := tmpl.ExecuteTemplate(&buf, "index.html", processed); err != nil {
+				return err
+			}
+
+			html, err := b.pluginManager.PostRender(buf.Bytes())
+			if err != nil {
+				return err
+			}
+
+			if err := b.writeHTML(filepath.Join(dir, term+".html"), html); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 func (b *BuilderV2) generatePost(tmpl *template.Template, post plugin.Post) error {
 	outputPath := filepath.Join(b.config.OutputDir, post.Slug+".html")
-	
+
+	// A protected post gets its own self-contained page (passphrase form,
+	// encrypted payload, decryption script) instead of the site's post.html
+	// template, since that page structurally can't go through the normal
+	// template/PreRender/PostRender pipeline without risking the real
+	// content leaking into it.
+	if protectedPost, ok := post.Metadata["protectedPost"].(*security.ProtectedPost); ok {
+		html, err := security.ProtectedPageHTML(post.Title, protectedPost)
+		if err != nil {
+			return fmt.Errorf("rendering protected page: %w", err)
+		}
+		return b.writeHTML(outputPath, html)
+	}
+
 	// Pre-render hook
 	data, err := b.pluginManager.PreRender(post)
 	if err != nil {
@@ -182,12 +401,12 @@ func (b *BuilderV2) generatePost(tmpl *template.Template, post plugin.Post) erro
 		return err
 	}
 
-	return ioutil.WriteFile(outputPath, html, 0644)
+	return b.writeHTML(outputPath, html)
 }
 
 func (b *BuilderV2) generateIndex(tmpl *template.Template) error {
 	outputPath := filepath.Join(b.config.OutputDir, "index.html")
-	
+
 	data := struct {
 		Posts []plugin.Post
 	}{
@@ -211,19 +430,37 @@ func (b *BuilderV2) generateIndex(tmpl *template.Template) error {
 		return err
 	}
 
-	return ioutil.WriteFile(outputPath, html, 0644)
+	return b.writeHTML(outputPath, html)
+}
+
+// writeHTML rewrites html's static asset references to their fingerprinted,
+// integrity-checked equivalents (see internal/assets) before writing it to
+// path.
+func (b *BuilderV2) writeHTML(path string, html []byte) error {
+	if b.assetManifest != nil && len(b.assetManifest.Assets) > 0 {
+		rewritten, err := b.assetManifest.RewriteHTML(html)
+		if err != nil {
+			return fmt.Errorf("rewriting asset references in %s: %w", path, err)
+		}
+		html = rewritten
+	}
+	return ioutil.WriteFile(path, html, 0644)
 }
 
 func (b *BuilderV2) generateSecurityHeaders() error {
 	headers := b.pluginManager.GetSecurityHeaders()
-	
+
+	if err := b.augmentCSPWithInlineScriptHashes(headers); err != nil {
+		return fmt.Errorf("hashing inline scripts: %w", err)
+	}
+
 	var content strings.Builder
 	content.WriteString("# Security Headers\n\n")
-	
+
 	for key, value := range headers {
 		content.WriteString(fmt.Sprintf("%s: %s\n", key, value))
 	}
-	
+
 	return ioutil.WriteFile(
 		filepath.Join(b.config.OutputDir, "_headers"),
 		[]byte(content.String()),
@@ -231,22 +468,86 @@ func (b *BuilderV2) generateSecurityHeaders() error {
 	)
 }
 
+// augmentCSPWithInlineScriptHashes scans every rendered page for inline
+// <script> bodies and, if any are found, appends a script-src directive
+// allowlisting their sha256 hashes. The build should never actually emit
+// an inline script -- this is a safety net so one that slips through fails
+// closed (CSP-blocked) rather than silently needing 'unsafe-inline'.
+func (b *BuilderV2) augmentCSPWithInlineScriptHashes(headers map[string]string) error {
+	csp, ok := headers["Content-Security-Policy"]
+	if !ok {
+		return nil
+	}
+
+	hashSet := map[string]bool{}
+	err := filepath.Walk(b.config.OutputDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) != ".html" {
+			return err
+		}
+		page, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		hashes, err := assets.InlineScriptHashes(page)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		for _, h := range hashes {
+			hashSet[h] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(hashSet) == 0 {
+		return nil
+	}
+
+	hashes := make([]string, 0, len(hashSet))
+	for h := range hashSet {
+		hashes = append(hashes, "'"+h+"'")
+	}
+	sort.Strings(hashes)
+
+	headers["Content-Security-Policy"] = csp + "; script-src 'self' " + strings.Join(hashes, " ")
+	return nil
+}
+
+// copyStatic fingerprints every CSS/JS/image file under the static
+// directory (see internal/assets), writes the fingerprinted copies and the
+// asset manifest into the output directory, and copies any remaining
+// static files through unchanged.
 func (b *BuilderV2) copyStatic() error {
 	staticDir := "static"
 	if _, err := os.Stat(staticDir); os.IsNotExist(err) {
+		b.assetManifest = &assets.Manifest{Assets: map[string]assets.Asset{}}
 		return nil
 	}
 
+	manifest, err := assets.Generate(staticDir, b.config.OutputDir)
+	if err != nil {
+		return fmt.Errorf("fingerprinting assets: %w", err)
+	}
+	b.assetManifest = manifest
+
+	if err := manifest.Save(filepath.Join(b.config.OutputDir, assets.ManifestFilename)); err != nil {
+		return fmt.Errorf("saving asset manifest: %w", err)
+	}
+
 	return filepath.Walk(staticDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil || info.IsDir() {
 			return err
 		}
 
 		rel, _ := filepath.Rel(staticDir, path)
+		if _, fingerprinted := manifest.Assets[filepath.ToSlash(rel)]; fingerprinted {
+			return nil // Generate already wrote this file under its fingerprinted name.
+		}
+
 		outputPath := filepath.Join(b.config.OutputDir, rel)
-		
 		os.MkdirAll(filepath.Dir(outputPath), 0755)
-		
+
 		input, err := ioutil.ReadFile(path)
 		if err != nil {
 			return err
@@ -254,4 +555,4 @@ func (b *BuilderV2) copyStatic() error {
 
 		return ioutil.WriteFile(outputPath, input, 0644)
 	})
-}
\ No newline at end of file
+}
@@ -0,0 +1,115 @@
+package builder
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+)
+
+// DepGraphPath is where the incremental build's dependency graph is
+// persisted between runs.
+const DepGraphPath = ".build-cache/deps.gob"
+
+// NodeKind identifies what kind of build artifact a graph node represents.
+type NodeKind string
+
+const (
+	NodeTemplate NodeKind = "template"
+	NodePost     NodeKind = "post"
+	NodeStatic   NodeKind = "static"
+	NodeOutput   NodeKind = "output"
+)
+
+// Node is one piece of the build graph: a content hash plus the IDs of the
+// nodes it depends on (e.g. a post output node depends on the post's
+// content node and the template node it renders with).
+type Node struct {
+	Kind      NodeKind
+	Hash      string
+	DependsOn []string
+}
+
+// DepGraph is the build's dependency graph, keyed by node ID
+// ("post:my-post", "template:index.html", "output:post:my-post", ...).
+type DepGraph struct {
+	Nodes map[string]Node
+}
+
+func newDepGraph() *DepGraph {
+	return &DepGraph{Nodes: make(map[string]Node)}
+}
+
+// loadDepGraph reads the persisted graph, returning an empty graph if none
+// exists yet (first build, or --force was passed).
+func loadDepGraph(path string) (*DepGraph, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return newDepGraph(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	graph := newDepGraph()
+	dec := gob.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(graph); err != nil {
+		return nil, err
+	}
+	return graph, nil
+}
+
+// save persists the graph to path, creating its parent directory if needed.
+func (g *DepGraph) save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(g); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// hashFile computes the SHA-256 hash of a file's contents.
+func hashFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(content)
+	return hex.EncodeToString(h[:]), nil
+}
+
+// dirty reports whether current's hash differs from (or is absent from)
+// prev, meaning the node must be rebuilt.
+func dirty(prev *DepGraph, id, hash string) bool {
+	node, ok := prev.Nodes[id]
+	return !ok || node.Hash != hash
+}
+
+// propagateDirty transitively marks every node that (directly or
+// transitively) depends on a dirty node as dirty too, using the graph
+// currently being built (next), since DependsOn edges are only known once
+// all nodes have been registered.
+func propagateDirty(next *DepGraph, dirtySet map[string]bool) {
+	changed := true
+	for changed {
+		changed = false
+		for id, node := range next.Nodes {
+			if dirtySet[id] {
+				continue
+			}
+			for _, dep := range node.DependsOn {
+				if dirtySet[dep] {
+					dirtySet[id] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+}
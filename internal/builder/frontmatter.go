@@ -0,0 +1,54 @@
+package builder
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FrontMatter is the YAML metadata block a post may put between `---`
+// delimiters at the top of its Markdown file. Any fields it doesn't set
+// fall back to values derived from the file itself (title from filename,
+// date from mtime).
+type FrontMatter struct {
+	Title      string   `yaml:"title"`
+	Date       string   `yaml:"date"`
+	Tags       []string `yaml:"tags"`
+	Categories []string `yaml:"categories"`
+	Draft      bool     `yaml:"draft"`
+	// Photo marks a post as photo content, so output plugins (the
+	// photos.atom feed) can filter it into an image-only subscription.
+	Photo bool `yaml:"photo"`
+	// Password marks a post as protected: instead of plaintext HTML, the
+	// build emits an AES-256-GCM-encrypted blob and a client-side
+	// decryption stub (see internal/security.ProtectPost and
+	// BuilderV2.generatePost), and RSS/Atom omit its body.
+	Password string `yaml:"password"`
+	// SecretCode, if set, is a second passphrase that unlocks the same
+	// protected post independently of Password -- a "shared unlock link"
+	// an author can hand out without revealing the real password.
+	SecretCode string `yaml:"secret_code"`
+}
+
+// splitFrontMatter separates a leading `---\n ... \n---\n` YAML block from
+// the Markdown body that follows it. If content has no front matter it is
+// returned unchanged with a zero FrontMatter.
+func splitFrontMatter(content []byte) (FrontMatter, []byte, error) {
+	var fm FrontMatter
+
+	if !bytes.HasPrefix(content, []byte("---\n")) {
+		return fm, content, nil
+	}
+
+	parts := bytes.SplitN(content[4:], []byte("\n---\n"), 2)
+	if len(parts) != 2 {
+		return fm, content, nil
+	}
+
+	if err := yaml.Unmarshal(parts[0], &fm); err != nil {
+		return fm, content, fmt.Errorf("parsing front matter: %w", err)
+	}
+
+	return fm, parts[1], nil
+}
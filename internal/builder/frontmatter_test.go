@@ -0,0 +1,39 @@
+package builder
+
+import "testing"
+
+func TestSplitFrontMatterParsesFields(t *testing.T) {
+	input := []byte("---\ntitle: Hello World\ntags: [go, security]\ndraft: true\n---\nbody text\n")
+
+	fm, body, err := splitFrontMatter(input)
+	if err != nil {
+		t.Fatalf("splitFrontMatter() error = %v", err)
+	}
+	if fm.Title != "Hello World" {
+		t.Errorf("Title = %q, want %q", fm.Title, "Hello World")
+	}
+	if len(fm.Tags) != 2 || fm.Tags[0] != "go" || fm.Tags[1] != "security" {
+		t.Errorf("Tags = %v, want [go security]", fm.Tags)
+	}
+	if !fm.Draft {
+		t.Errorf("Draft = false, want true")
+	}
+	if string(body) != "body text\n" {
+		t.Errorf("body = %q, want %q", body, "body text\n")
+	}
+}
+
+func TestSplitFrontMatterNoFrontMatter(t *testing.T) {
+	input := []byte("just a plain post\n")
+
+	fm, body, err := splitFrontMatter(input)
+	if err != nil {
+		t.Fatalf("splitFrontMatter() error = %v", err)
+	}
+	if fm.Title != "" || fm.Draft {
+		t.Errorf("expected zero-value FrontMatter, got %+v", fm)
+	}
+	if string(body) != string(input) {
+		t.Errorf("body = %q, want unchanged input %q", body, input)
+	}
+}
@@ -0,0 +1,215 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// BuildIncremental rebuilds only what changed since the last call: a post,
+// template, or static file whose content hash is unchanged (and whose
+// dependencies are all unchanged) is skipped. The dependency graph is
+// persisted to DepGraphPath so the next invocation can compute what's
+// dirty without re-rendering anything. Pass force=true (the --force flag)
+// to ignore the cache and rebuild everything, as if it were a first build.
+func (b *Builder) BuildIncremental(ctx context.Context, force bool) error {
+	prev, err := loadDepGraph(DepGraphPath)
+	if err != nil {
+		return fmt.Errorf("loading dependency graph: %w", err)
+	}
+	if force {
+		prev = newDepGraph()
+	}
+
+	next := newDepGraph()
+	dirtySet := make(map[string]bool)
+
+	tmpl, err := b.parseTemplates()
+	if err != nil {
+		return fmt.Errorf("parsing templates: %w", err)
+	}
+	if err := b.registerTemplateNodes(next, prev, dirtySet); err != nil {
+		return fmt.Errorf("hashing templates: %w", err)
+	}
+
+	if err := b.loadPosts(); err != nil {
+		return fmt.Errorf("loading posts: %w", err)
+	}
+	if err := b.registerPostNodes(next, prev, dirtySet); err != nil {
+		return fmt.Errorf("hashing posts: %w", err)
+	}
+
+	b.registerIndexAndFeedNodes(next)
+
+	staticFiles, err := b.registerStaticNodes(next, prev, dirtySet)
+	if err != nil {
+		return fmt.Errorf("hashing static files: %w", err)
+	}
+
+	propagateDirty(next, dirtySet)
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	for _, post := range b.posts {
+		outputID := "output:post:" + post.Slug
+		if !dirtySet[outputID] {
+			continue
+		}
+		if err := b.generatePost(tmpl, post); err != nil {
+			return fmt.Errorf("generating post %s: %w", post.Slug, err)
+		}
+	}
+
+	if dirtySet["output:index"] {
+		if err := b.generateIndex(tmpl); err != nil {
+			return fmt.Errorf("generating index: %w", err)
+		}
+	}
+
+	if dirtySet["output:rss"] {
+		if err := b.generateRSS(); err != nil {
+			return fmt.Errorf("generating RSS: %w", err)
+		}
+	}
+
+	for _, rel := range staticFiles {
+		if !dirtySet["static:"+rel] {
+			continue
+		}
+		if err := b.copyStaticFile(rel); err != nil {
+			return fmt.Errorf("copying static file %s: %w", rel, err)
+		}
+	}
+
+	return next.save(DepGraphPath)
+}
+
+// registerTemplateNodes hashes every template file and records a node per
+// template name.
+func (b *Builder) registerTemplateNodes(next, prev *DepGraph, dirtySet map[string]bool) error {
+	matches, err := filepath.Glob(filepath.Join(b.config.TemplateDir, "*.html"))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range matches {
+		id := "template:" + filepath.Base(path)
+		hash, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		next.Nodes[id] = Node{Kind: NodeTemplate, Hash: hash}
+		if dirty(prev, id, hash) {
+			dirtySet[id] = true
+		}
+	}
+	return nil
+}
+
+// registerPostNodes hashes each post's markdown source and wires its output
+// node to depend on both the post content and the post.html template, so a
+// template edit dirties every post's output.
+func (b *Builder) registerPostNodes(next, prev *DepGraph, dirtySet map[string]bool) error {
+	postsDir := filepath.Join(b.config.ContentDir, "posts")
+
+	for _, post := range b.posts {
+		postID := "post:" + post.Slug
+		next.Nodes[postID] = Node{Kind: NodePost, Hash: post.ContentHash}
+		if dirty(prev, postID, post.ContentHash) {
+			dirtySet[postID] = true
+		}
+
+		srcPath := filepath.Join(postsDir, post.Slug+".md")
+		srcHash, err := hashFile(srcPath)
+		if err != nil {
+			return err
+		}
+		srcID := "source:" + post.Slug
+		next.Nodes[srcID] = Node{Kind: NodePost, Hash: srcHash}
+		if dirty(prev, srcID, srcHash) {
+			dirtySet[srcID] = true
+		}
+
+		outputID := "output:post:" + post.Slug
+		next.Nodes[outputID] = Node{
+			Kind:      NodeOutput,
+			DependsOn: []string{postID, srcID, "template:post.html"},
+		}
+	}
+	return nil
+}
+
+// registerIndexAndFeedNodes wires the index and RSS outputs to depend on
+// every post plus their respective templates, since both list all posts.
+func (b *Builder) registerIndexAndFeedNodes(next *DepGraph) {
+	deps := make([]string, 0, len(b.posts)+1)
+	for _, post := range b.posts {
+		deps = append(deps, "post:"+post.Slug)
+	}
+
+	next.Nodes["output:index"] = Node{
+		Kind:      NodeOutput,
+		DependsOn: append(append([]string{}, deps...), "template:index.html"),
+	}
+	next.Nodes["output:rss"] = Node{
+		Kind:      NodeOutput,
+		DependsOn: deps,
+	}
+}
+
+// registerStaticNodes hashes every file under the static directory and
+// returns their paths relative to it.
+func (b *Builder) registerStaticNodes(next, prev *DepGraph, dirtySet map[string]bool) ([]string, error) {
+	staticDir := "static"
+	if _, err := os.Stat(staticDir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	var files []string
+	err := filepath.Walk(staticDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(staticDir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+
+		hash, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+
+		id := "static:" + rel
+		next.Nodes[id] = Node{Kind: NodeStatic, Hash: hash}
+		if dirty(prev, id, hash) {
+			dirtySet[id] = true
+		}
+		return nil
+	})
+	return files, err
+}
+
+// copyStaticFile copies a single file (relative to the static dir) into
+// the output directory.
+func (b *Builder) copyStaticFile(rel string) error {
+	src := filepath.Join("static", rel)
+	dst := filepath.Join(b.config.OutputDir, rel)
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, content, 0644)
+}
@@ -0,0 +1,156 @@
+package builder
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PostCachePath is where BuilderV2 persists rendered post HTML between
+// builds, keyed by a hash of everything that can change its output.
+const PostCachePath = ".secureblog-cache/posts.jsonl"
+
+// postCacheVersion is bumped whenever the cache key or entry format changes,
+// so an upgraded builder transparently invalidates caches written by an
+// older one instead of misinterpreting their bytes.
+const postCacheVersion = 1
+
+// postCacheEntry is one line of the cache file: the key it was stored under
+// and the fully rendered, sanitized HTML for that post.
+type postCacheEntry struct {
+	Key  string `json:"key"`
+	HTML string `json:"html"`
+}
+
+// postCacheHeader is the first line of the cache file.
+type postCacheHeader struct {
+	Version int `json:"version"`
+}
+
+// postCache is an in-memory view of the on-disk cache: entries loaded from
+// a previous build, plus whatever new entries the current build produces.
+type postCache struct {
+	loaded map[string]string
+	fresh  map[string]string
+}
+
+// loadPostCache reads the persisted cache, discarding it (rather than
+// erroring) if it's missing or its schema version doesn't match this
+// builder's, since a version mismatch means the entries can't be trusted.
+func loadPostCache(path string) (*postCache, error) {
+	c := &postCache{loaded: make(map[string]string), fresh: make(map[string]string)}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+
+	if !scanner.Scan() {
+		return c, nil
+	}
+	var header postCacheHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil || header.Version != postCacheVersion {
+		return c, nil
+	}
+
+	for scanner.Scan() {
+		var entry postCacheEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		c.loaded[entry.Key] = entry.HTML
+	}
+	return c, scanner.Err()
+}
+
+// get returns the cached HTML for key, if any was loaded from disk.
+func (c *postCache) get(key string) (string, bool) {
+	html, ok := c.loaded[key]
+	return html, ok
+}
+
+// put records freshly rendered HTML to be written out on save.
+func (c *postCache) put(key, html string) {
+	c.fresh[key] = html
+}
+
+// save writes every key this build touched (reused or freshly rendered) to
+// path as newline-delimited JSON behind a version header, so unrelated
+// stale entries from deleted posts don't accumulate forever.
+func (c *postCache) save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	if err := json.NewEncoder(w).Encode(postCacheHeader{Version: postCacheVersion}); err != nil {
+		return err
+	}
+	for key, html := range c.fresh {
+		if err := json.NewEncoder(w).Encode(postCacheEntry{Key: key, HTML: html}); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// postCacheKey hashes everything that can change a post's rendered output:
+// the raw markdown bytes, its front matter, the mtimes of every template
+// (a template edit must invalidate every post that uses it), the plugin
+// fingerprint, and the builder version.
+func postCacheKey(rawContent []byte, fm FrontMatter, templateStamp, pluginFingerprint string) string {
+	h := sha256.New()
+	h.Write(rawContent)
+	fmt.Fprintf(h, "|title=%s|date=%s|tags=%s|categories=%s|draft=%v|photo=%v",
+		fm.Title, fm.Date, strings.Join(fm.Tags, ","), strings.Join(fm.Categories, ","), fm.Draft, fm.Photo)
+	fmt.Fprintf(h, "|password=%v|secretCode=%v", fm.Password != "", fm.SecretCode != "")
+	fmt.Fprintf(h, "|templates=%s|plugins=%s|builder=%s", templateStamp, pluginFingerprint, builderVersion)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// templateStamp summarizes every page and shortcode template's
+// modification time, so editing a template (even without changing post
+// content) invalidates its posts.
+func templateStamp(templateDir string) (string, error) {
+	patterns := []string{
+		filepath.Join(templateDir, "*.html"),
+		filepath.Join(templateDir, "shortcodes", "*.html"),
+	}
+
+	var matches []string
+	for _, pattern := range patterns {
+		m, err := filepath.Glob(pattern)
+		if err != nil {
+			return "", err
+		}
+		matches = append(matches, m...)
+	}
+
+	var b strings.Builder
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s:%d;", filepath.Base(path), info.ModTime().UnixNano())
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,64 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPostCacheRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "posts.jsonl")
+
+	c, err := loadPostCache(path)
+	if err != nil {
+		t.Fatalf("loadPostCache() error = %v", err)
+	}
+	if _, ok := c.get("missing"); ok {
+		t.Fatalf("get() on empty cache reported a hit")
+	}
+
+	c.put("key1", "<p>hello</p>")
+	if err := c.save(path); err != nil {
+		t.Fatalf("save() error = %v", err)
+	}
+
+	reloaded, err := loadPostCache(path)
+	if err != nil {
+		t.Fatalf("loadPostCache() after save error = %v", err)
+	}
+	html, ok := reloaded.get("key1")
+	if !ok || html != "<p>hello</p>" {
+		t.Errorf("get(%q) = %q, %v; want %q, true", "key1", html, ok, "<p>hello</p>")
+	}
+}
+
+func TestPostCacheKeyChangesWithContent(t *testing.T) {
+	fm := FrontMatter{Title: "A"}
+
+	a := postCacheKey([]byte("hello"), fm, "stamp", "plugins")
+	b := postCacheKey([]byte("world"), fm, "stamp", "plugins")
+	if a == b {
+		t.Errorf("postCacheKey() gave the same key for different content")
+	}
+
+	c := postCacheKey([]byte("hello"), fm, "stamp", "plugins")
+	if a != c {
+		t.Errorf("postCacheKey() is not deterministic for identical inputs")
+	}
+}
+
+func TestLoadPostCacheIgnoresWrongVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "posts.jsonl")
+	contents := "{\"version\":999}\n{\"key\":\"k\",\"html\":\"stale\"}\n"
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	c, err := loadPostCache(path)
+	if err != nil {
+		t.Fatalf("loadPostCache() error = %v", err)
+	}
+	if _, ok := c.get("k"); ok {
+		t.Errorf("loadPostCache() honored entries from a mismatched schema version")
+	}
+}
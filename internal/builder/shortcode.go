@@ -0,0 +1,199 @@
+package builder
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"strings"
+)
+
+// shortcodeOpen and shortcodeClose delimit a shortcode invocation, e.g.
+// `{{< figure src="x.jpg" alt="a cat" >}}`. They're distinct from Go
+// template's own `{{ }}` delimiters so shortcodes can be written directly
+// in post Markdown without colliding with (or being escaped out of) page
+// template syntax.
+const (
+	shortcodeOpen  = "{{<"
+	shortcodeClose = ">}}"
+)
+
+// shortcode is one parsed invocation: the template name to execute and its
+// attribute-style arguments.
+type shortcode struct {
+	name string
+	args map[string]string
+}
+
+// loadShortcodes parses every template under dir (TemplateDir/shortcodes)
+// into a single named template set, keyed by file name -- "figure.html"
+// is looked up as "figure.html", matching html/template.ParseFiles'
+// default naming. A missing directory is not an error: a site with no
+// shortcodes just never matches any {{< ... >}} tokens, which
+// expandShortcodes treats as an unknown shortcode and fails the build on.
+func loadShortcodes(dir string, funcs template.FuncMap) (*template.Template, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.html"))
+	if err != nil {
+		return nil, err
+	}
+	tmpl := template.New("shortcodes").Funcs(funcs)
+	if len(matches) == 0 {
+		return tmpl, nil
+	}
+	return tmpl.ParseFiles(matches...)
+}
+
+// expandShortcodes replaces every `{{< name arg="val" ... >}}` token in
+// src with the output of executing the shortcode template named "name"
+// against its parsed args. Output is substituted inline as raw HTML, so
+// it reaches the post's HTML the same way any other raw HTML embedded in
+// Markdown does: through whichever sanitizer policy the markdown engine
+// applies after rendering.
+func expandShortcodes(src []byte, shortcodes *template.Template) ([]byte, error) {
+	var out bytes.Buffer
+	i := 0
+	for {
+		start := bytes.Index(src[i:], []byte(shortcodeOpen))
+		if start == -1 {
+			out.Write(src[i:])
+			break
+		}
+		start += i
+		out.Write(src[i:start])
+
+		end, body, err := scanShortcode(src, start)
+		if err != nil {
+			return nil, err
+		}
+
+		sc, err := parseShortcode(body)
+		if err != nil {
+			return nil, fmt.Errorf("shortcode at byte %d: %w", start, err)
+		}
+
+		rendered, err := renderShortcode(shortcodes, sc)
+		if err != nil {
+			return nil, fmt.Errorf("shortcode %q at byte %d: %w", sc.name, start, err)
+		}
+		out.Write(rendered)
+
+		i = end
+	}
+	return out.Bytes(), nil
+}
+
+// scanShortcode finds the end of the shortcode token opening at src[start:]
+// (which must begin with shortcodeOpen) by walking byte-by-byte rather
+// than matching a regex, so a quoted argument value may safely contain
+// ">}}" or a nested "{{<" without ending the token early. It returns the
+// index just past the closing ">}}" and the token's inner body (the text
+// between the delimiters).
+func scanShortcode(src []byte, start int) (end int, body []byte, err error) {
+	depth := 0
+	var quote byte
+	i := start
+
+	for i < len(src) {
+		switch {
+		case quote != 0:
+			if src[i] == quote {
+				quote = 0
+			}
+			i++
+		case src[i] == '"' || src[i] == '\'':
+			quote = src[i]
+			i++
+		case bytes.HasPrefix(src[i:], []byte(shortcodeOpen)):
+			depth++
+			i += len(shortcodeOpen)
+		case bytes.HasPrefix(src[i:], []byte(shortcodeClose)):
+			depth--
+			i += len(shortcodeClose)
+			if depth == 0 {
+				return i, src[start+len(shortcodeOpen) : i-len(shortcodeClose)], nil
+			}
+		default:
+			i++
+		}
+	}
+	return 0, nil, fmt.Errorf("unterminated shortcode starting at byte %d", start)
+}
+
+// parseShortcode parses a shortcode token's body ("name key=\"val\" ...")
+// into its name and arguments.
+func parseShortcode(body []byte) (shortcode, error) {
+	fields, err := splitShortcodeFields(string(body))
+	if err != nil {
+		return shortcode{}, err
+	}
+	if len(fields) == 0 {
+		return shortcode{}, fmt.Errorf("empty shortcode")
+	}
+
+	sc := shortcode{name: fields[0], args: map[string]string{}}
+	for _, field := range fields[1:] {
+		key, val, ok := strings.Cut(field, "=")
+		if !ok {
+			return shortcode{}, fmt.Errorf("shortcode %q: argument %q is not key=\"value\"", sc.name, field)
+		}
+		val = strings.TrimSuffix(strings.TrimPrefix(val, `"`), `"`)
+		sc.args[key] = val
+	}
+	return sc, nil
+}
+
+// splitShortcodeFields splits a shortcode body on whitespace, keeping
+// quoted `key="a value with spaces"` arguments intact as one field.
+func splitShortcodeFields(body string) ([]string, error) {
+	var fields []string
+	var field strings.Builder
+	var quote byte
+
+	flush := func() {
+		if field.Len() > 0 {
+			fields = append(fields, field.String())
+			field.Reset()
+		}
+	}
+
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case quote != 0:
+			field.WriteByte(c)
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			field.WriteByte(c)
+		case c == ' ' || c == '\t' || c == '\n':
+			flush()
+		default:
+			field.WriteByte(c)
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in shortcode arguments")
+	}
+	flush()
+	return fields, nil
+}
+
+// renderShortcode executes the shortcode template named sc.name against
+// sc.args. A name with no matching template fails the build rather than
+// leaving the literal {{< ... >}} token (or nothing) in the rendered
+// post, since a silently-dropped shortcode is much harder to notice than
+// a build failure.
+func renderShortcode(shortcodes *template.Template, sc shortcode) ([]byte, error) {
+	t := shortcodes.Lookup(sc.name + ".html")
+	if t == nil {
+		return nil, fmt.Errorf("unknown shortcode %q", sc.name)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, sc.args); err != nil {
+		return nil, fmt.Errorf("executing shortcode template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,97 @@
+package builder
+
+import (
+	"html/template"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeShortcodeTemplate(t *testing.T, dir, name, body string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0644); err != nil {
+		t.Fatalf("WriteFile(%q) error = %v", name, err)
+	}
+}
+
+func TestLoadShortcodesMissingDirIsNotAnError(t *testing.T) {
+	tmpl, err := loadShortcodes(filepath.Join(t.TempDir(), "does-not-exist"), template.FuncMap{})
+	if err != nil {
+		t.Fatalf("loadShortcodes() error = %v", err)
+	}
+	if tmpl == nil {
+		t.Fatal("loadShortcodes() returned a nil template set")
+	}
+}
+
+func TestExpandShortcodesSubstitutesOutput(t *testing.T) {
+	dir := t.TempDir()
+	writeShortcodeTemplate(t, dir, "greet.html", `<strong>hi {{.name}}</strong>`)
+
+	shortcodes, err := loadShortcodes(dir, template.FuncMap{})
+	if err != nil {
+		t.Fatalf("loadShortcodes() error = %v", err)
+	}
+
+	src := []byte(`before {{< greet name="world" >}} after`)
+	got, err := expandShortcodes(src, shortcodes)
+	if err != nil {
+		t.Fatalf("expandShortcodes() error = %v", err)
+	}
+
+	want := `before <strong>hi world</strong> after`
+	if string(got) != want {
+		t.Errorf("expandShortcodes() = %q, want %q", got, want)
+	}
+}
+
+func TestExpandShortcodesHandlesNestedDelimitersInQuotedArgs(t *testing.T) {
+	dir := t.TempDir()
+	writeShortcodeTemplate(t, dir, "echo.html", `{{.text}}`)
+
+	shortcodes, err := loadShortcodes(dir, template.FuncMap{})
+	if err != nil {
+		t.Fatalf("loadShortcodes() error = %v", err)
+	}
+
+	src := []byte(`{{< echo text="contains >}} and {{< inside quotes" >}}`)
+	got, err := expandShortcodes(src, shortcodes)
+	if err != nil {
+		t.Fatalf("expandShortcodes() error = %v", err)
+	}
+
+	want := `contains &gt;}} and {{&lt; inside quotes`
+	if string(got) != want {
+		t.Errorf("expandShortcodes() = %q, want %q", got, want)
+	}
+}
+
+func TestExpandShortcodesUnknownShortcodeFailsBuild(t *testing.T) {
+	shortcodes, err := loadShortcodes(t.TempDir(), template.FuncMap{})
+	if err != nil {
+		t.Fatalf("loadShortcodes() error = %v", err)
+	}
+
+	_, err = expandShortcodes([]byte(`{{< nope >}}`), shortcodes)
+	if err == nil {
+		t.Fatal("expandShortcodes() error = nil, want an error for an unknown shortcode")
+	}
+}
+
+func TestExpandShortcodesUnterminatedTokenFails(t *testing.T) {
+	shortcodes, err := loadShortcodes(t.TempDir(), template.FuncMap{})
+	if err != nil {
+		t.Fatalf("loadShortcodes() error = %v", err)
+	}
+
+	_, err = expandShortcodes([]byte(`{{< figure src="x.jpg"`), shortcodes)
+	if err == nil {
+		t.Fatal("expandShortcodes() error = nil, want an error for an unterminated shortcode")
+	}
+}
+
+func TestParseShortcodeRejectsMalformedArgument(t *testing.T) {
+	if _, err := parseShortcode([]byte(`figure src`)); err == nil {
+		t.Fatal("parseShortcode() error = nil, want an error for a non key=\"value\" argument")
+	}
+}
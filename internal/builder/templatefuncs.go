@@ -0,0 +1,105 @@
+package builder
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"net/url"
+	"secureblog/internal/security"
+	"strings"
+	"time"
+)
+
+// wordsPerMinute is the reading speed readingTime assumes, matching the
+// figure most blogging platforms use for prose.
+const wordsPerMinute = 200
+
+// templateFuncs returns the FuncMap shared by page templates and
+// shortcode templates: small presentation helpers content shouldn't have
+// to reimplement, none of which reach outside the process (no network
+// calls, no filesystem access beyond what's already loaded).
+func templateFuncs(siteURL string) template.FuncMap {
+	return template.FuncMap{
+		"truncate":    truncate,
+		"safeURL":     safeURL,
+		"absURL":      func(path string) string { return absURL(siteURL, path) },
+		"readingTime": readingTime,
+		"wordCount":   wordCount,
+		"dateFormat":  dateFormat,
+	}
+}
+
+// truncate shortens s to at most n runes, appending "..." if it was cut.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
+// safeURL returns rawurl as template.URL if it passes the same allowlist
+// the HTML sanitizer enforces elsewhere, so a shortcode can emit an href
+// or src without html/template escaping it into an inert string. An
+// unsafe URL becomes "" rather than being passed through.
+func safeURL(rawurl string) template.URL {
+	if !security.IsValidURL(rawurl) {
+		return ""
+	}
+	return template.URL(rawurl)
+}
+
+// absURL resolves path against siteURL, returning path unchanged if
+// siteURL is empty or path is already absolute.
+func absURL(siteURL, path string) string {
+	if siteURL == "" {
+		return path
+	}
+	base, err := url.Parse(siteURL)
+	if err != nil {
+		return path
+	}
+	ref, err := url.Parse(path)
+	if err != nil {
+		return path
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// wordCount counts whitespace-separated words in s.
+func wordCount(s string) int {
+	return len(strings.Fields(s))
+}
+
+// readingTime estimates reading time for s at wordsPerMinute, rounded up
+// to the nearest whole minute with a one-minute floor.
+func readingTime(s string) string {
+	minutes := int(math.Ceil(float64(wordCount(s)) / wordsPerMinute))
+	if minutes < 1 {
+		minutes = 1
+	}
+	if minutes == 1 {
+		return "1 min read"
+	}
+	return fmt.Sprintf("%d min read", minutes)
+}
+
+// dateFormat reformats date (RFC3339 or a bare "2006-01-02") using a Go
+// reference-time layout. It returns date unchanged if it doesn't parse,
+// rather than failing the build over a malformed front-matter date.
+func dateFormat(layout, date string) string {
+	t, err := parsePostTime(date)
+	if err != nil {
+		return date
+	}
+	return t.Format(layout)
+}
+
+// parsePostTime parses a post's Date field, which may be an RFC 3339
+// timestamp (the default BuilderV2 assigns from file mtime) or a bare
+// front-matter date like "2024-01-02".
+func parsePostTime(date string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, date); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", date)
+}
@@ -0,0 +1,77 @@
+package micropub
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// authenticate extracts r's Bearer token and verifies it against
+// cfg.TokenEndpoint per the IndieAuth token verification convention: a GET
+// with the token in the Authorization header returns the identity ("me")
+// the token was issued to and the scopes it grants.
+func (h *handler) authenticate(r *http.Request) ([]string, error) {
+	token := bearerToken(r)
+	if token == "" {
+		return nil, fmt.Errorf("missing bearer token")
+	}
+	if h.cfg.TokenEndpoint == "" {
+		return nil, fmt.Errorf("micropub endpoint has no token endpoint configured")
+	}
+	if h.cfg.Me == "" {
+		return nil, fmt.Errorf("micropub endpoint has no owner identity configured")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, h.cfg.TokenEndpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building token verification request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("verifying token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint rejected token: %s", resp.Status)
+	}
+
+	var result struct {
+		Me    string `json:"me"`
+		Scope string `json:"scope"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("parsing token verification response: %w", err)
+	}
+	if result.Me == "" {
+		return nil, fmt.Errorf(`token endpoint did not return a "me" identity`)
+	}
+	if result.Me != h.cfg.Me {
+		return nil, fmt.Errorf("token belongs to %q, not this site's owner", result.Me)
+	}
+
+	return strings.Fields(result.Scope), nil
+}
+
+// bearerToken reads the Micropub token from the Authorization header or,
+// per the spec's fallback for clients that can't set headers, the
+// access_token form field.
+func bearerToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return r.FormValue("access_token")
+}
+
+func hasScope(scopes []string, want string) bool {
+	for _, s := range scopes {
+		if s == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package micropub
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// entry is a Micropub h-entry's properties, mapped down to what the
+// builder needs to render a post: a title, body, tags, publish time, any
+// attached photo URLs, and an optional reply target.
+type entry struct {
+	Name       string
+	Content    string
+	Categories []string
+	Published  time.Time
+	Photos     []string
+	InReplyTo  string
+}
+
+// parseEntry reads a create request in either of Micropub's two supported
+// encodings: application/x-www-form-urlencoded (and multipart/form-data,
+// for requests carrying a photo alongside the form fields) or
+// application/json (the microformats2 JSON form).
+func parseEntry(r *http.Request) (*entry, error) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		return parseJSONEntry(r)
+	}
+	return parseFormEntry(r)
+}
+
+func parseFormEntry(r *http.Request) (*entry, error) {
+	if err := r.ParseMultipartForm(10 << 20); err != nil && err != http.ErrNotMultipart {
+		if err := r.ParseForm(); err != nil {
+			return nil, fmt.Errorf("parsing form body: %w", err)
+		}
+	}
+
+	if h := r.FormValue("h"); h != "" && h != "entry" {
+		return nil, fmt.Errorf("unsupported micropub type h=%s", h)
+	}
+
+	e := &entry{
+		Name:      r.FormValue("name"),
+		Content:   r.FormValue("content"),
+		InReplyTo: r.FormValue("in-reply-to"),
+	}
+
+	e.Categories = r.Form["category[]"]
+	if len(e.Categories) == 0 {
+		e.Categories = r.Form["category"]
+	}
+
+	if pub := r.FormValue("published"); pub != "" {
+		t, err := time.Parse(time.RFC3339, pub)
+		if err != nil {
+			return nil, fmt.Errorf("parsing published: %w", err)
+		}
+		e.Published = t
+	} else {
+		e.Published = time.Now().UTC()
+	}
+
+	return e, nil
+}
+
+func parseJSONEntry(r *http.Request) (*entry, error) {
+	var payload struct {
+		Type       []string                 `json:"type"`
+		Properties map[string][]interface{} `json:"properties"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("parsing JSON body: %w", err)
+	}
+	if len(payload.Type) > 0 && payload.Type[0] != "h-entry" {
+		return nil, fmt.Errorf("unsupported micropub type %s", payload.Type[0])
+	}
+
+	e := &entry{
+		Name:      firstString(payload.Properties["name"]),
+		Content:   firstString(payload.Properties["content"]),
+		InReplyTo: firstString(payload.Properties["in-reply-to"]),
+	}
+
+	for _, c := range payload.Properties["category"] {
+		if s, ok := c.(string); ok {
+			e.Categories = append(e.Categories, s)
+		}
+	}
+	for _, p := range payload.Properties["photo"] {
+		if s, ok := p.(string); ok {
+			e.Photos = append(e.Photos, s)
+		}
+	}
+
+	if v := firstString(payload.Properties["published"]); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("parsing published: %w", err)
+		}
+		e.Published = t
+	} else {
+		e.Published = time.Now().UTC()
+	}
+
+	return e, nil
+}
+
+func firstString(vals []interface{}) string {
+	if len(vals) == 0 {
+		return ""
+	}
+	s, _ := vals[0].(string)
+	return s
+}
@@ -0,0 +1,67 @@
+package micropub
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// handleMedia implements the Micropub media endpoint: an authenticated
+// multipart upload is hashed and persisted under
+// ContentDir/media/<sha256-prefix>/<sha256><ext>, content-addressed so the
+// same upload from a retried request never collides with or duplicates an
+// existing file.
+func (h *handler) handleMedia(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "micropub media endpoint only supports POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, err := h.authenticate(r); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err := r.ParseMultipartForm(20 << 20); err != nil {
+		http.Error(w, fmt.Sprintf("parsing upload: %v", err), http.StatusBadRequest)
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, fmt.Sprintf("reading uploaded file: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("reading uploaded file: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	dir := filepath.Join(h.cfg.ContentDir, "media", hash[:2])
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		http.Error(w, fmt.Sprintf("creating media directory: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	mediaPath := filepath.Join(dir, hash+filepath.Ext(header.Filename))
+	if err := os.WriteFile(mediaPath, data, 0644); err != nil {
+		http.Error(w, fmt.Sprintf("writing media: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	relPath, err := filepath.Rel(h.cfg.ContentDir, mediaPath)
+	if err != nil {
+		relPath = mediaPath
+	}
+	w.Header().Set("Location", "/"+filepath.ToSlash(relPath))
+	w.WriteHeader(http.StatusCreated)
+}
@@ -0,0 +1,116 @@
+// Package micropub implements a W3C Micropub endpoint so IndieWeb clients
+// (Quill, Indigenous, etc.) can post directly into the static build
+// pipeline: a create request is authenticated against an IndieAuth token
+// endpoint, turned into a Markdown file with front matter under
+// Config.ContentDir, then triggers a full build and re-signs the build
+// manifest so every published post leaves the site in a consistently
+// signed state.
+//
+// Posting is synchronous and serialized by a single mutex: a request that
+// arrives while a build is already in flight is rejected outright with
+// 503 rather than queued behind it, so a client always knows whether its
+// post made it into the signed manifest or needs a retry -- and the
+// manifest is never signed mid-write.
+package micropub
+
+import (
+	"fmt"
+	"net/http"
+	"secureblog/internal/builder"
+	"secureblog/internal/security"
+	"sync"
+)
+
+// Config configures the Micropub endpoint.
+type Config struct {
+	// ContentDir is the builder's content directory; new posts are
+	// written under ContentDir/posts and uploaded media under
+	// ContentDir/media.
+	ContentDir string
+	// OutputDir is the builder's output directory, re-signed after every
+	// successful publish.
+	OutputDir string
+	// Builder runs the full build triggered by each publish.
+	Builder *builder.Builder
+	// TokenEndpoint is the IndieAuth token endpoint used to verify Bearer
+	// tokens and their granted scopes.
+	TokenEndpoint string
+	// Me is the site owner's IndieAuth identity URL. A token endpoint can
+	// legitimately issue tokens to any user it knows, not just this site's
+	// owner, so authenticate rejects any token whose "me" doesn't match
+	// this value.
+	Me string
+}
+
+type handler struct {
+	cfg     Config
+	buildMu sync.Mutex
+}
+
+// Handler returns an http.Handler mounting "/micropub" (create requests)
+// and "/micropub/media" (media uploads) at its root -- mount it under
+// "/micropub/" with http.StripPrefix, or serve it directly if Micropub is
+// the only thing on its listener.
+func Handler(cfg Config) http.Handler {
+	h := &handler{cfg: cfg}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/micropub", h.handleMicropub)
+	mux.HandleFunc("/micropub/media", h.handleMedia)
+	return mux
+}
+
+func (h *handler) handleMicropub(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "micropub endpoint only supports POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	scopes, err := h.authenticate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if !hasScope(scopes, "create") {
+		http.Error(w, `token does not grant the "create" scope`, http.StatusForbidden)
+		return
+	}
+
+	e, err := parseEntry(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !h.buildMu.TryLock() {
+		http.Error(w, "a build is already in progress; retry shortly", http.StatusServiceUnavailable)
+		return
+	}
+	defer h.buildMu.Unlock()
+
+	permalink, err := h.publish(e)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("publishing entry: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Location", permalink)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// publish writes e as a new post, rebuilds the site, and re-signs the
+// build manifest, returning the new post's permalink.
+func (h *handler) publish(e *entry) (string, error) {
+	permalink, err := writePost(h.cfg.ContentDir, e)
+	if err != nil {
+		return "", err
+	}
+
+	if err := h.cfg.Builder.Build(); err != nil {
+		return "", fmt.Errorf("building site: %w", err)
+	}
+	if err := security.SignBuild(h.cfg.OutputDir); err != nil {
+		return "", fmt.Errorf("signing build manifest: %w", err)
+	}
+
+	return permalink, nil
+}
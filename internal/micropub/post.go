@@ -0,0 +1,99 @@
+package micropub
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// writePost renders e as a Markdown file with YAML front matter under
+// contentDir/posts and returns its permalink. The slug is derived from the
+// entry's name (falling back to its content) prefixed with the publish
+// date, so posts sort and round-trip the same way a human-authored
+// filename would.
+func writePost(contentDir string, e *entry) (string, error) {
+	postsDir := filepath.Join(contentDir, "posts")
+	if err := os.MkdirAll(postsDir, 0755); err != nil {
+		return "", fmt.Errorf("creating posts directory: %w", err)
+	}
+
+	slug := slugify(e)
+	path := filepath.Join(postsDir, slug+".md")
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("a post already exists at %s", path)
+	}
+
+	if err := os.WriteFile(path, []byte(renderFrontMatter(e)), 0644); err != nil {
+		return "", fmt.Errorf("writing post: %w", err)
+	}
+
+	return "/" + slug + ".html", nil
+}
+
+// slugify builds a "YYYY-MM-DD-title" slug matching the builder's existing
+// filename-is-the-slug convention.
+func slugify(e *entry) string {
+	base := e.Name
+	if base == "" {
+		base = e.Content
+	}
+
+	var b strings.Builder
+	lastDash := false
+	for _, r := range strings.ToLower(base) {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			b.WriteRune(r)
+			lastDash = false
+		default:
+			if !lastDash {
+				b.WriteByte('-')
+			}
+			lastDash = true
+		}
+	}
+	slug := strings.Trim(b.String(), "-")
+	if len(slug) > 60 {
+		slug = strings.Trim(slug[:60], "-")
+	}
+	if slug == "" {
+		slug = "note"
+	}
+
+	return e.Published.Format("2006-01-02") + "-" + slug
+}
+
+// renderFrontMatter writes e as a YAML front-matter block followed by its
+// Markdown body.
+func renderFrontMatter(e *entry) string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	if e.Name != "" {
+		fmt.Fprintf(&b, "title: %s\n", strconv.Quote(e.Name))
+	}
+	fmt.Fprintf(&b, "date: %s\n", e.Published.Format(time.RFC3339))
+	if len(e.Categories) > 0 {
+		fmt.Fprintf(&b, "categories: [%s]\n", strings.Join(quoteAll(e.Categories), ", "))
+	}
+	if e.InReplyTo != "" {
+		fmt.Fprintf(&b, "in_reply_to: %s\n", strconv.Quote(e.InReplyTo))
+	}
+	if len(e.Photos) > 0 {
+		fmt.Fprintf(&b, "photos: [%s]\n", strings.Join(quoteAll(e.Photos), ", "))
+	}
+	b.WriteString("---\n\n")
+	b.WriteString(e.Content)
+	b.WriteString("\n")
+	return b.String()
+}
+
+func quoteAll(ss []string) []string {
+	out := make([]string, len(ss))
+	for i, s := range ss {
+		out[i] = strconv.Quote(s)
+	}
+	return out
+}
@@ -0,0 +1,135 @@
+// Package pipeline exposes the site's build/audit/verify/publish steps as
+// typed, context-aware functions, so the admin UI, secureblog-ui, and CI
+// all run the exact same code path instead of each shelling out to its own
+// copy of the build scripts.
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"secureblog/internal/builder"
+	"secureblog/internal/security"
+	"secureblog/plugins/publish"
+)
+
+// Options configures a pipeline run. Every step takes the same Options so
+// a caller can run Build, Audit, Verify, and Publish back to back against
+// one configuration.
+type Options struct {
+	ContentDir  string
+	TemplateDir string
+	OutputDir   string
+	SiteURL     string
+}
+
+// Build renders the site from Options.ContentDir into Options.OutputDir
+// using BuilderV2 -- the same engine the admin server and CI use -- and
+// writes progress to w.
+func Build(ctx context.Context, opts Options, w io.Writer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "building %s -> %s\n", opts.ContentDir, opts.OutputDir)
+	b := builder.NewV2(builder.Config{
+		ContentDir:  opts.ContentDir,
+		OutputDir:   opts.OutputDir,
+		TemplateDir: opts.TemplateDir,
+		Secure:      true,
+		SiteURL:     opts.SiteURL,
+	})
+	if err := b.Build(); err != nil {
+		return fmt.Errorf("build: %w", err)
+	}
+	fmt.Fprintln(w, "build complete")
+	return nil
+}
+
+// Audit builds a fresh manifest of Options.OutputDir and reports its shape,
+// the in-process equivalent of the old security-regression-guard.sh
+// script's sanity pass.
+func Audit(ctx context.Context, opts Options, w io.Writer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "auditing %s\n", opts.OutputDir)
+	manifest, err := security.BuildManifestFor(opts.OutputDir)
+	if err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+	fmt.Fprintf(w, "manifest covers %d files, root hash %s\n", len(manifest.Files), manifest.RootHash)
+	fmt.Fprintln(w, "audit complete")
+	return nil
+}
+
+// Verify checks the signed build manifest in Options.OutputDir against the
+// files actually on disk and the transparency log.
+func Verify(ctx context.Context, opts Options, w io.Writer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "verifying %s\n", opts.OutputDir)
+	if err := security.VerifyBuild(opts.OutputDir); err != nil {
+		return fmt.Errorf("verify: %w", err)
+	}
+	fmt.Fprintln(w, "integrity verified")
+	return nil
+}
+
+// Publish signs the build and pushes it, running each git step under ctx
+// so a canceled job interrupts a hung push instead of leaking it.
+func Publish(ctx context.Context, opts Options, w io.Writer) error {
+	if err := security.SignBuild(opts.OutputDir); err != nil {
+		return fmt.Errorf("publish: signing build: %w", err)
+	}
+	if err := Verify(ctx, opts, w); err != nil {
+		return fmt.Errorf("publish: %w", err)
+	}
+
+	steps := [][]string{
+		{"git", "add", "."},
+		{"git", "commit", "-m", "Publish"},
+		{"git", "push"},
+	}
+	for _, args := range steps {
+		fmt.Fprintf(w, "$ %s\n", strings.Join(args, " "))
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Stdout = w
+		cmd.Stderr = w
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("publish: %s: %w", strings.Join(args, " "), err)
+		}
+	}
+	fmt.Fprintln(w, "published")
+	return nil
+}
+
+// PublishTo signs and verifies the build the same way Publish does, then
+// pushes it to backend instead of git -- for a caller that has configured
+// an object-storage or rsync destination in place of the default git push.
+func PublishTo(ctx context.Context, opts Options, w io.Writer, backend publish.Backend) error {
+	if err := security.SignBuild(opts.OutputDir); err != nil {
+		return fmt.Errorf("publish: signing build: %w", err)
+	}
+	if err := Verify(ctx, opts, w); err != nil {
+		return fmt.Errorf("publish: %w", err)
+	}
+
+	manifest, err := security.BuildManifestFor(opts.OutputDir)
+	if err != nil {
+		return fmt.Errorf("publish: building manifest: %w", err)
+	}
+
+	fmt.Fprintf(w, "pushing to %s backend\n", backend.Name())
+	if err := backend.Push(ctx, opts.OutputDir, manifest); err != nil {
+		return fmt.Errorf("publish: %s: %w", backend.Name(), err)
+	}
+	fmt.Fprintln(w, "published")
+	return nil
+}
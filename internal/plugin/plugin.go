@@ -3,6 +3,9 @@ package plugin
 import (
 	"html/template"
 	"io/fs"
+	"secureblog/internal/policy"
+	"sort"
+	"strings"
 )
 
 // Plugin is the base interface all plugins must implement
@@ -19,6 +22,14 @@ type ContentPlugin interface {
 	ProcessContent(content []byte, metadata map[string]interface{}) ([]byte, error)
 }
 
+// MarkdownEngine converts Markdown source to HTML. Content plugins select
+// an implementation by name instead of hardcoding a single CommonMark
+// library, so the renderer backing post content can be swapped (or run
+// side by side during a migration) without touching the build pipeline.
+type MarkdownEngine interface {
+	Render(src []byte, meta map[string]any) ([]byte, error)
+}
+
 // RenderPlugin modifies HTML rendering
 type RenderPlugin interface {
 	Plugin
@@ -48,14 +59,15 @@ type OutputPlugin interface {
 
 // Post represents a blog post
 type Post struct {
-	Title       string
-	Content     template.HTML
-	RawContent  []byte
-	Date        string
-	Slug        string
-	Tags        []string
-	Metadata    map[string]interface{}
-	Hash        string
+	Title      string
+	Content    template.HTML
+	RawContent []byte
+	Date       string
+	Slug       string
+	Tags       []string
+	Categories []string
+	Metadata   map[string]interface{}
+	Hash       string
 }
 
 // PluginManager manages all plugins
@@ -66,6 +78,8 @@ type PluginManager struct {
 	buildPlugins    []BuildPlugin
 	outputPlugins   []OutputPlugin
 	config          map[string]interface{}
+	policy          *policy.Policy
+	pluginDir       string
 }
 
 // NewPluginManager creates a new plugin manager
@@ -77,31 +91,84 @@ func NewPluginManager() *PluginManager {
 		buildPlugins:    []BuildPlugin{},
 		outputPlugins:   []OutputPlugin{},
 		config:          make(map[string]interface{}),
+		policy:          policy.Default(),
+	}
+}
+
+// Init loads the plugin security policy from policyPath, enforced at every
+// plugin boundary (sandbox exec, remote fetches, template functions). A
+// missing file falls back to policy.Default(), which denies everything.
+func (pm *PluginManager) Init(policyPath string) error {
+	p, err := policy.Load(policyPath)
+	if err != nil {
+		return err
 	}
+	pm.policy = p
+	return nil
+}
+
+// Policy returns the security policy plugins should enforce against.
+func (pm *PluginManager) Policy() *policy.Policy {
+	return pm.policy
 }
 
-// Register adds a plugin to the manager
+// Register adds a plugin to the manager. Each hook list is kept sorted by
+// Priority() (lower runs first) so registration order doesn't need to match
+// execution order.
 func (pm *PluginManager) Register(plugin interface{}) error {
 	switch p := plugin.(type) {
 	case ContentPlugin:
 		pm.contentPlugins = append(pm.contentPlugins, p)
+		sort.SliceStable(pm.contentPlugins, func(i, j int) bool {
+			return pm.contentPlugins[i].Priority() < pm.contentPlugins[j].Priority()
+		})
 	case RenderPlugin:
 		pm.renderPlugins = append(pm.renderPlugins, p)
+		sort.SliceStable(pm.renderPlugins, func(i, j int) bool {
+			return pm.renderPlugins[i].Priority() < pm.renderPlugins[j].Priority()
+		})
 	case SecurityPlugin:
 		pm.securityPlugins = append(pm.securityPlugins, p)
+		sort.SliceStable(pm.securityPlugins, func(i, j int) bool {
+			return pm.securityPlugins[i].Priority() < pm.securityPlugins[j].Priority()
+		})
 	case BuildPlugin:
 		pm.buildPlugins = append(pm.buildPlugins, p)
+		sort.SliceStable(pm.buildPlugins, func(i, j int) bool {
+			return pm.buildPlugins[i].Priority() < pm.buildPlugins[j].Priority()
+		})
 	case OutputPlugin:
 		pm.outputPlugins = append(pm.outputPlugins, p)
+		sort.SliceStable(pm.outputPlugins, func(i, j int) bool {
+			return pm.outputPlugins[i].Priority() < pm.outputPlugins[j].Priority()
+		})
 	}
 	return nil
 }
 
-// LoadPlugin dynamically loads a plugin from a file
-func (pm *PluginManager) LoadPlugin(path string) error {
-	// Go plugins would use plugin.Open() here
-	// For security, we'll use compiled-in plugins instead
-	return nil
+// Fingerprint returns a stable string identifying which plugins are
+// registered and at what version, so callers can detect when a build cache
+// keyed on it needs to be invalidated after a plugin is added, removed, or
+// upgraded.
+func (pm *PluginManager) Fingerprint() string {
+	var names []string
+	for _, p := range pm.contentPlugins {
+		names = append(names, p.Name()+"@"+p.Version())
+	}
+	for _, p := range pm.renderPlugins {
+		names = append(names, p.Name()+"@"+p.Version())
+	}
+	for _, p := range pm.securityPlugins {
+		names = append(names, p.Name()+"@"+p.Version())
+	}
+	for _, p := range pm.buildPlugins {
+		names = append(names, p.Name()+"@"+p.Version())
+	}
+	for _, p := range pm.outputPlugins {
+		names = append(names, p.Name()+"@"+p.Version())
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
 }
 
 // ProcessContent runs all content plugins
@@ -197,4 +264,4 @@ func (pm *PluginManager) GenerateOutputs(posts []Post, outputDir string) error {
 type PluginFS interface {
 	Plugin
 	GetFS() fs.FS
-}
\ No newline at end of file
+}
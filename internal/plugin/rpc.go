@@ -0,0 +1,145 @@
+package plugin
+
+import (
+	"fmt"
+	"secureblog/internal/rpcplugin"
+)
+
+// DefaultPluginDir is where LoadPlugin looks for out-of-process plugin
+// executables unless SetPluginDir overrides it.
+const DefaultPluginDir = "plugins/bin"
+
+// SetPluginDir overrides the directory LoadPlugin resolves plugin names
+// under. name arguments to LoadPlugin are always resolved relative to this
+// directory and rejected if they'd escape it.
+func (pm *PluginManager) SetPluginDir(dir string) {
+	pm.pluginDir = dir
+}
+
+// LoadPlugin launches name as an out-of-process plugin executable under
+// the configured plugin directory (DefaultPluginDir unless SetPluginDir
+// was called), verifies it against the security policy (contained path,
+// pinned SHA-256, exec.allow), and registers it for whichever of the five
+// plugin capabilities it declares during its handshake. A registered RPC
+// plugin is indistinguishable to the rest of PluginManager from a
+// compiled-in one: each capability gets its own thin adapter implementing
+// exactly that one interface, so Register's type switch only matches the
+// interfaces the plugin actually negotiated.
+func (pm *PluginManager) LoadPlugin(name string) error {
+	dir := pm.pluginDir
+	if dir == "" {
+		dir = DefaultPluginDir
+	}
+
+	sup := rpcplugin.NewSupervisor(dir, pm.policy)
+	client, err := sup.Load(name)
+	if err != nil {
+		return fmt.Errorf("loading plugin %s: %w", name, err)
+	}
+
+	for _, capability := range client.Capabilities {
+		var adapter interface{}
+		switch capability {
+		case rpcplugin.CapabilityContent:
+			adapter = rpcContentPlugin{client}
+		case rpcplugin.CapabilityRender:
+			adapter = rpcRenderPlugin{client}
+		case rpcplugin.CapabilitySecurity:
+			adapter = rpcSecurityPlugin{client}
+		case rpcplugin.CapabilityBuild:
+			adapter = rpcBuildPlugin{client}
+		case rpcplugin.CapabilityOutput:
+			adapter = rpcOutputPlugin{client}
+		default:
+			return fmt.Errorf("plugin %s declared unknown capability %q", name, capability)
+		}
+		if err := pm.Register(adapter); err != nil {
+			return fmt.Errorf("registering %s capability of plugin %s: %w", capability, name, err)
+		}
+	}
+
+	return nil
+}
+
+// rpcContentPlugin adapts an rpcplugin.Client to ContentPlugin.
+type rpcContentPlugin struct{ *rpcplugin.Client }
+
+func (c rpcContentPlugin) ProcessContent(content []byte, metadata map[string]interface{}) ([]byte, error) {
+	var result struct {
+		Content []byte `json:"content"`
+	}
+	err := c.Call("ProcessContent", struct {
+		Content  []byte                 `json:"content"`
+		Metadata map[string]interface{} `json:"metadata"`
+	}{Content: content, Metadata: metadata}, &result)
+	return result.Content, err
+}
+
+// rpcRenderPlugin adapts an rpcplugin.Client to RenderPlugin.
+type rpcRenderPlugin struct{ *rpcplugin.Client }
+
+func (c rpcRenderPlugin) PreRender(data interface{}) (interface{}, error) {
+	var result struct {
+		Data interface{} `json:"data"`
+	}
+	err := c.Call("PreRender", struct {
+		Data interface{} `json:"data"`
+	}{Data: data}, &result)
+	return result.Data, err
+}
+
+func (c rpcRenderPlugin) PostRender(html []byte) ([]byte, error) {
+	var result struct {
+		HTML []byte `json:"html"`
+	}
+	err := c.Call("PostRender", struct {
+		HTML []byte `json:"html"`
+	}{HTML: html}, &result)
+	return result.HTML, err
+}
+
+// rpcSecurityPlugin adapts an rpcplugin.Client to SecurityPlugin.
+type rpcSecurityPlugin struct{ *rpcplugin.Client }
+
+func (c rpcSecurityPlugin) ApplySecurity(content []byte) ([]byte, error) {
+	var result struct {
+		Content []byte `json:"content"`
+	}
+	err := c.Call("ApplySecurity", struct {
+		Content []byte `json:"content"`
+	}{Content: content}, &result)
+	return result.Content, err
+}
+
+func (c rpcSecurityPlugin) GenerateHeaders() map[string]string {
+	var headers map[string]string
+	if err := c.Call("GenerateHeaders", nil, &headers); err != nil {
+		return nil
+	}
+	return headers
+}
+
+// rpcBuildPlugin adapts an rpcplugin.Client to BuildPlugin.
+type rpcBuildPlugin struct{ *rpcplugin.Client }
+
+func (c rpcBuildPlugin) PreBuild(sourceDir string) error {
+	return c.Call("PreBuild", struct {
+		SourceDir string `json:"source_dir"`
+	}{SourceDir: sourceDir}, nil)
+}
+
+func (c rpcBuildPlugin) PostBuild(outputDir string) error {
+	return c.Call("PostBuild", struct {
+		OutputDir string `json:"output_dir"`
+	}{OutputDir: outputDir}, nil)
+}
+
+// rpcOutputPlugin adapts an rpcplugin.Client to OutputPlugin.
+type rpcOutputPlugin struct{ *rpcplugin.Client }
+
+func (c rpcOutputPlugin) Generate(posts []Post, outputDir string) error {
+	return c.Call("Generate", struct {
+		Posts     []Post `json:"posts"`
+		OutputDir string `json:"output_dir"`
+	}{Posts: posts, OutputDir: outputDir}, nil)
+}
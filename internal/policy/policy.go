@@ -0,0 +1,141 @@
+// Package policy holds the configurable allowlists that gate every plugin
+// security boundary: which binaries a plugin may exec, which environment
+// variables pass through to it, which HTTP methods/URLs a plugin may fetch,
+// and which environment variables a template's getenv function may read.
+package policy
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Policy is a deny-by-default set of regex allowlists, one per plugin
+// capability. A plugin that requests something outside its allowlist gets
+// an error naming the exact config key to relax.
+type Policy struct {
+	Exec  ExecPolicy  `yaml:"exec"`
+	HTTP  HTTPPolicy  `yaml:"http"`
+	Funcs FuncsPolicy `yaml:"funcs"`
+}
+
+// ExecPolicy gates plugins.Sandbox.Execute and rpcplugin.Supervisor.Load.
+type ExecPolicy struct {
+	// Allow lists the binary names (not full paths) a plugin may spawn.
+	Allow []string `yaml:"allow"`
+	// OSEnv lists the environment variable names passed through to a
+	// spawned plugin instead of the sandbox's minimal built-in set.
+	OSEnv []string `yaml:"osEnv"`
+	// Hashes maps a binary name to the hex SHA-256 it must match before
+	// an out-of-process RPC plugin is exec'd. Unlike Allow (which permits
+	// by name alone), this pins the exact bytes, so a plugin directory an
+	// attacker can write to still can't substitute a different binary.
+	Hashes map[string]string `yaml:"hashes"`
+}
+
+// HTTPPolicy gates any plugin that fetches a remote resource (the SRI
+// hasher today).
+type HTTPPolicy struct {
+	Methods []string `yaml:"methods"`
+	URLs    []string `yaml:"urls"`
+}
+
+// FuncsPolicy gates template functions that read process state.
+type FuncsPolicy struct {
+	// Getenv lists the environment variable names a `getenv` template
+	// function may read.
+	Getenv []string `yaml:"getenv"`
+}
+
+// Default is "deny all except the minimum Secureblog itself needs": no
+// plugin exec, no env passthrough, GET-only fetches with no hosts
+// preapproved, and no template getenv access. Sites that need more widen
+// exactly the key named in the resulting denial error.
+func Default() *Policy {
+	return &Policy{
+		HTTP: HTTPPolicy{
+			Methods: []string{"GET"},
+		},
+	}
+}
+
+// Load reads a YAML policy file at path, starting from Default() so any
+// field the file omits stays denied. A missing file is not an error: the
+// build runs under Default().
+func Load(path string) (*Policy, error) {
+	p := Default()
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading security policy %s: %w", path, err)
+	}
+
+	if err := yaml.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("parsing security policy %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// AllowExec reports whether bin may be spawned by the plugin sandbox.
+func (p *Policy) AllowExec(bin string) error {
+	return matchAny(p.Exec.Allow, bin, "exec.allow")
+}
+
+// AllowEnv reports whether envVar may be passed through to a spawned plugin.
+func (p *Policy) AllowEnv(envVar string) error {
+	return matchAny(p.Exec.OSEnv, envVar, "exec.osEnv")
+}
+
+// AllowExecHash reports whether sha256hex is the hash pinned for bin under
+// exec.hashes. A bin with no pinned hash is denied: out-of-process plugins
+// must be explicitly pinned, unlike the looser name-only AllowExec check
+// used for compiled-in plugin sandboxing.
+func (p *Policy) AllowExecHash(bin, sha256hex string) error {
+	want, ok := p.Exec.Hashes[bin]
+	if !ok {
+		return fmt.Errorf("denied by security policy: %q has no pinned hash under exec.hashes (add its SHA-256 to allow it)", bin)
+	}
+	if !strings.EqualFold(want, sha256hex) {
+		return fmt.Errorf("denied by security policy: %q does not match the hash pinned for it under exec.hashes (binary may have been tampered with)", bin)
+	}
+	return nil
+}
+
+// AllowHTTPMethod reports whether method may be used for a remote fetch.
+func (p *Policy) AllowHTTPMethod(method string) error {
+	return matchAny(p.HTTP.Methods, method, "http.methods")
+}
+
+// AllowHTTPURL reports whether url may be fetched by a plugin.
+func (p *Policy) AllowHTTPURL(url string) error {
+	return matchAny(p.HTTP.URLs, url, "http.urls")
+}
+
+// AllowGetenv reports whether envVar may be read by a template's getenv call.
+func (p *Policy) AllowGetenv(envVar string) error {
+	return matchAny(p.Funcs.Getenv, envVar, "funcs.getenv")
+}
+
+// matchAny returns nil if value fully matches any pattern in patterns
+// (each compiled as an anchored regex), or an actionable error naming the
+// config key to relax otherwise. Patterns are regexes, not globs: a literal
+// "." in a hostname or path must be escaped ("\.") or it will match any
+// character.
+func matchAny(patterns []string, value, key string) error {
+	for _, pattern := range patterns {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q in %s: %w", pattern, key, err)
+		}
+		if re.MatchString(value) {
+			return nil
+		}
+	}
+	return fmt.Errorf("denied by security policy: %q is not permitted by %s (add a matching pattern under %s to allow it)", value, key, key)
+}
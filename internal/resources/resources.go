@@ -0,0 +1,273 @@
+// Package resources fetches remote resources (external stylesheets, fonts,
+// images) the build needs to reference, gated by the plugin security
+// policy's HTTP allowlist. A fetch never fails a non-strict build outright:
+// it yields a RemoteResource with Err set so the caller (the SRI rewriter,
+// a template function, a future remote-image optimizer) can fall back to a
+// local default instead. Successful fetches are cached under CacheDir with
+// ETag revalidation so rebuilds work offline once a resource has been
+// fetched once.
+package resources
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"secureblog/internal/policy"
+	"sort"
+	"time"
+)
+
+// CacheDir is where fetched resource bodies and their revalidation metadata
+// are persisted between builds.
+const CacheDir = ".build-cache/remote"
+
+// indexPath is the cache's manifest of every URL ever fetched, keyed by the
+// SHA-256 of the URL (so it also doubles as the cached body's filename).
+const indexPath = CacheDir + "/index.json"
+
+// defaultTTL is how long a cached resource is reused without revalidation.
+const defaultTTL = 24 * time.Hour
+
+// Record is one cached resource's revalidation metadata, and what gets
+// embedded in the signed build manifest so reviewers can see exactly what
+// the build pulled from the network.
+type Record struct {
+	URL         string    `json:"url"`
+	SHA256      string    `json:"sha256"`
+	SRIHash     string    `json:"sri_hash"`
+	ContentType string    `json:"content_type"`
+	ETag        string    `json:"etag,omitempty"`
+	FetchedAt   time.Time `json:"fetched_at"`
+}
+
+// RemoteResource is the result of fetching a single URL.
+type RemoteResource struct {
+	URL         string
+	Body        []byte
+	SRIHash     string
+	ContentType string
+	FromCache   bool
+	// Err is set when the fetch failed and Strict is false: the build
+	// continues, and the caller decides how to fall back.
+	Err error
+}
+
+// Fetcher fetches remote resources through a security policy's HTTP
+// allowlist, caching bodies on disk so a later build can reuse them
+// without the network being reachable. A Fetcher keeps the on-disk index
+// in memory for its own lifetime so a build referencing many URLs pays
+// one load and one save instead of one pair per URL.
+type Fetcher struct {
+	Policy *policy.Policy
+	// Strict makes Fetch return an error instead of a RemoteResource with
+	// Err set, aborting the build on any fetch failure.
+	Strict bool
+	// TTL is how long a cached resource is trusted before revalidation.
+	// Zero means defaultTTL.
+	TTL    time.Duration
+	Client *http.Client
+
+	idx *cacheIndex
+}
+
+// NewFetcher creates a Fetcher enforcing p. A nil policy behaves like
+// policy.Default(): no fetches are allowed.
+func NewFetcher(p *policy.Policy) *Fetcher {
+	if p == nil {
+		p = policy.Default()
+	}
+	return &Fetcher{Policy: p, Client: http.DefaultClient}
+}
+
+// Fetch retrieves url, serving a cached body when it's still fresh or the
+// network is unreachable. In non-strict mode (the default), a failure is
+// reported via the returned RemoteResource's Err field rather than as an
+// error, so the build can keep going.
+func (f *Fetcher) Fetch(url string) (*RemoteResource, error) {
+	res, err := f.fetch(url)
+	if err != nil {
+		if f.Strict {
+			return nil, err
+		}
+		return &RemoteResource{URL: url, Err: err}, nil
+	}
+	return res, nil
+}
+
+func (f *Fetcher) fetch(url string) (*RemoteResource, error) {
+	if err := f.Policy.AllowHTTPMethod(http.MethodGet); err != nil {
+		return nil, err
+	}
+	if err := f.Policy.AllowHTTPURL(url); err != nil {
+		return nil, err
+	}
+
+	if f.idx == nil {
+		idx, err := loadIndex()
+		if err != nil {
+			return nil, err
+		}
+		f.idx = idx
+	}
+	idx := f.idx
+
+	key := cacheKey(url)
+	record, cached := idx.Records[key]
+
+	ttl := f.TTL
+	if ttl == 0 {
+		ttl = defaultTTL
+	}
+	if cached && time.Since(record.FetchedAt) < ttl {
+		body, err := os.ReadFile(bodyPath(key))
+		if err == nil {
+			return &RemoteResource{URL: url, Body: body, SRIHash: record.SRIHash, ContentType: record.ContentType, FromCache: true}, nil
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if cached && record.ETag != "" {
+		req.Header.Set("If-None-Match", record.ETag)
+	}
+
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		if cached {
+			return f.fromCache(url, key, record)
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && cached {
+		record.FetchedAt = time.Now().UTC()
+		idx.Records[key] = record
+		if err := saveIndex(idx); err != nil {
+			return nil, err
+		}
+		return f.fromCache(url, key, record)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		if cached {
+			return f.fromCache(url, key, record)
+		}
+		return nil, fmt.Errorf("fetching %s: unexpected status %s", url, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	record = Record{
+		URL:         url,
+		SHA256:      hashHex(body),
+		SRIHash:     sriHash(body),
+		ContentType: resp.Header.Get("Content-Type"),
+		ETag:        resp.Header.Get("ETag"),
+		FetchedAt:   time.Now().UTC(),
+	}
+
+	if err := os.MkdirAll(CacheDir, 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(bodyPath(key), body, 0644); err != nil {
+		return nil, err
+	}
+	idx.Records[key] = record
+	if err := saveIndex(idx); err != nil {
+		return nil, err
+	}
+
+	return &RemoteResource{URL: url, Body: body, SRIHash: record.SRIHash, ContentType: record.ContentType}, nil
+}
+
+// fromCache serves a previously fetched body, used both for the TTL-fresh
+// path and as the offline fallback when revalidation fails.
+func (f *Fetcher) fromCache(url, key string, record Record) (*RemoteResource, error) {
+	body, err := os.ReadFile(bodyPath(key))
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: offline and no cached copy: %w", url, err)
+	}
+	return &RemoteResource{URL: url, Body: body, SRIHash: record.SRIHash, ContentType: record.ContentType, FromCache: true}, nil
+}
+
+// LoadRecords returns every cached resource's manifest record, sorted by
+// URL, so SignBuild can embed them in the build manifest. A missing cache
+// is not an error: it just means no remote resources were fetched. Note
+// this reflects everything still within CacheDir, not only URLs the most
+// recent build referenced; a `-force`/cache-clearing build is the way to
+// get a manifest that's an exact record of one build's fetches.
+func LoadRecords() ([]Record, error) {
+	idx, err := loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	records := make([]Record, 0, len(idx.Records))
+	for _, r := range idx.Records {
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].URL < records[j].URL })
+	return records, nil
+}
+
+type cacheIndex struct {
+	Records map[string]Record `json:"records"`
+}
+
+func loadIndex() (*cacheIndex, error) {
+	data, err := os.ReadFile(indexPath)
+	if os.IsNotExist(err) {
+		return &cacheIndex{Records: make(map[string]Record)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	idx := &cacheIndex{Records: make(map[string]Record)}
+	if err := json.Unmarshal(data, idx); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+func saveIndex(idx *cacheIndex) error {
+	if err := os.MkdirAll(CacheDir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(indexPath, data, 0644)
+}
+
+func cacheKey(url string) string {
+	return hashHex([]byte(url))
+}
+
+func bodyPath(key string) string {
+	return filepath.Join(CacheDir, key+".body")
+}
+
+func hashHex(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
+
+// sriHash computes a W3C Subresource Integrity hash (SHA-384, as
+// recommended) for data.
+func sriHash(data []byte) string {
+	h := sha512.Sum384(data)
+	return "sha384-" + base64.StdEncoding.EncodeToString(h[:])
+}
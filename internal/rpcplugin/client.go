@@ -0,0 +1,50 @@
+package rpcplugin
+
+// Client is the host-side handle to a running RPC plugin process. It
+// implements the identity/lifecycle methods every plugin.Plugin needs
+// (Name, Version, Priority, Init) directly, and exposes Call so a caller
+// can invoke whichever capability-specific methods the plugin negotiated
+// during Handshake. internal/plugin builds the typed adapters
+// (ContentPlugin, RenderPlugin, ...) on top of Call.
+type Client struct {
+	host *Host
+	HandshakeResult
+}
+
+// Name returns the plugin's self-reported name.
+func (c *Client) Name() string { return c.HandshakeResult.Name }
+
+// Version returns the plugin's self-reported version.
+func (c *Client) Version() string { return c.HandshakeResult.Version }
+
+// Priority returns the plugin's self-reported scheduling priority.
+func (c *Client) Priority() int { return c.HandshakeResult.Priority }
+
+// Init forwards config to the plugin's Init method.
+func (c *Client) Init(config map[string]interface{}) error {
+	return c.Call("Init", struct {
+		Config map[string]interface{} `json:"config"`
+	}{Config: config}, nil)
+}
+
+// Has reports whether the plugin declared capability during Handshake.
+func (c *Client) Has(capability Capability) bool {
+	for _, got := range c.Capabilities {
+		if got == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// Call invokes method on the plugin with params, decoding its result into
+// out. It is safe to call concurrently; calls to a single plugin are
+// serialized.
+func (c *Client) Call(method string, params, out interface{}) error {
+	return c.host.call(method, params, out)
+}
+
+// Close terminates the plugin process.
+func (c *Client) Close() error {
+	return c.host.kill()
+}
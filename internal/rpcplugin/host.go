@@ -0,0 +1,172 @@
+package rpcplugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// maxRestartAttempts caps how many times call restarts a crashed plugin
+// before giving up and returning a permanent error; without a cap a plugin
+// that crashes on every call would retry forever.
+const maxRestartAttempts = 5
+
+// Host owns one plugin process: its pipes, its restart bookkeeping, and
+// the mutex that serializes calls to it (the wire protocol has no request
+// IDs, so two calls in flight at once would read each other's responses).
+type Host struct {
+	mu      sync.Mutex
+	name    string
+	execDir string
+	sup     *Supervisor
+
+	cmd     *exec.Cmd
+	stdin   *bufio.Writer
+	stdout  *bufio.Reader
+	attempt int
+}
+
+// spawn starts (or restarts) the plugin process, wiring its stdin/stdout
+// to the RPC pipe and its stderr to a goroutine that tags and logs each
+// line under the plugin's name.
+func (h *Host) spawn() error {
+	cmd := exec.Command(h.execDir)
+	cmd.Dir = h.sup.scratchDir(h.name)
+	cmd.Env = h.sup.safeEnv()
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags: syscall.CLONE_NEWNET, // no network access for the plugin
+		Credential: &syscall.Credential{Uid: 65534, Gid: 65534},
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("opening plugin stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("opening plugin stdout: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("opening plugin stderr: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting plugin %s: %w", h.name, err)
+	}
+
+	go h.logStderr(stderr)
+
+	h.cmd = cmd
+	h.stdin = bufio.NewWriter(stdin)
+	h.stdout = bufio.NewReader(stdout)
+	return nil
+}
+
+// logStderr tags and logs every line the plugin writes to stderr, so a
+// misbehaving plugin's diagnostics surface in the build's own logs instead
+// of vanishing with the child process.
+func (h *Host) logStderr(r io.ReadCloser) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		log.Printf("[plugin:%s] %s", h.name, scanner.Text())
+	}
+}
+
+// call sends method/params to the plugin and decodes its result into out
+// (which may be nil for calls with no return value), restarting a crashed
+// process with exponential backoff and enforcing the supervisor's
+// per-call timeout.
+func (h *Host) call(method string, params, out interface{}) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var paramsJSON json.RawMessage
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("marshaling %s params: %w", method, err)
+		}
+		paramsJSON = data
+	}
+
+	type outcome struct {
+		res resultFrame
+		err error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		if err := writeFrame(h.stdin, callFrame{Method: method, Params: paramsJSON}); err != nil {
+			done <- outcome{err: fmt.Errorf("calling %s: %w", method, err)}
+			return
+		}
+		if err := h.stdin.Flush(); err != nil {
+			done <- outcome{err: fmt.Errorf("calling %s: %w", method, err)}
+			return
+		}
+		var rf resultFrame
+		if err := readFrame(h.stdout, &rf); err != nil {
+			done <- outcome{err: fmt.Errorf("calling %s: %w", method, err)}
+			return
+		}
+		done <- outcome{res: rf}
+	}()
+
+	select {
+	case o := <-done:
+		if o.err != nil {
+			return h.recoverFrom(o.err)
+		}
+		if o.res.Error != "" {
+			return fmt.Errorf("plugin %s: %s: %s", h.name, method, o.res.Error)
+		}
+		if out != nil && len(o.res.Result) > 0 {
+			if err := json.Unmarshal(o.res.Result, out); err != nil {
+				return fmt.Errorf("decoding %s result from plugin %s: %w", method, h.name, err)
+			}
+		}
+		return nil
+	case <-time.After(h.sup.callTimeout()):
+		h.kill()
+		return h.recoverFrom(fmt.Errorf("plugin %s: call to %s timed out after %s", h.name, method, h.sup.callTimeout()))
+	}
+}
+
+// recoverFrom restarts the plugin after a failed call, with exponential
+// backoff, so a single crash doesn't take the plugin out of rotation for
+// the rest of the build. The restart outcome is folded into callErr so the
+// caller sees both what failed and whether recovery succeeded.
+func (h *Host) recoverFrom(callErr error) error {
+	h.attempt++
+	if h.attempt > maxRestartAttempts {
+		return fmt.Errorf("%w (giving up after %d restart attempts)", callErr, maxRestartAttempts)
+	}
+
+	backoff := time.Duration(1<<uint(h.attempt-1)) * 100 * time.Millisecond
+	if backoff > 5*time.Second {
+		backoff = 5 * time.Second
+	}
+	time.Sleep(backoff)
+
+	if err := h.spawn(); err != nil {
+		return fmt.Errorf("%w (restart failed: %v)", callErr, err)
+	}
+	return callErr
+}
+
+// kill terminates the plugin process, ignoring errors from a process that
+// has already exited.
+func (h *Host) kill() error {
+	if h.cmd == nil || h.cmd.Process == nil {
+		return nil
+	}
+	_ = h.cmd.Process.Kill()
+	_ = h.cmd.Wait()
+	return nil
+}
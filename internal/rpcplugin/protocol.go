@@ -0,0 +1,105 @@
+// Package rpcplugin runs a plugin as a separate executable instead of
+// linking it into the binary, and lets the host call it over a small
+// length-prefixed JSON-RPC protocol on the child's stdin/stdout. It is the
+// transport and process-supervision layer only: it knows nothing about
+// "content plugins" or "render plugins" — internal/plugin builds those
+// adapters on top of the generic Client.Call this package exposes.
+//
+// Wire format: each direction sends a 4-byte big-endian length prefix
+// followed by that many bytes of JSON. The host writes a callFrame and
+// reads back exactly one resultFrame per call; there is no request
+// multiplexing, so a Client serializes its calls (see Host.call).
+package rpcplugin
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// maxFrameSize bounds a single frame so a misbehaving or compromised
+// plugin can't make the host allocate an unbounded buffer.
+const maxFrameSize = 64 << 20 // 64MiB
+
+// methodHandshake is the first call Supervisor.Load makes to a freshly
+// spawned plugin: it has no params and returns a HandshakeResult.
+const methodHandshake = "Handshake"
+
+// callFrame is what the host writes to request a method call.
+type callFrame struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// resultFrame is what the plugin writes back. Error is set instead of
+// Result when the call failed on the plugin's side.
+type resultFrame struct {
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// writeFrame marshals v to JSON and writes it length-prefixed to w.
+func writeFrame(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshaling frame: %w", err)
+	}
+	if len(data) > maxFrameSize {
+		return fmt.Errorf("frame of %d bytes exceeds %d byte limit", len(data), maxFrameSize)
+	}
+
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(data)))
+	if _, err := w.Write(length[:]); err != nil {
+		return fmt.Errorf("writing frame length: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("writing frame body: %w", err)
+	}
+	return nil
+}
+
+// readFrame reads one length-prefixed JSON frame from r and unmarshals it
+// into v.
+func readFrame(r io.Reader, v interface{}) error {
+	var length [4]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return fmt.Errorf("reading frame length: %w", err)
+	}
+	size := binary.BigEndian.Uint32(length[:])
+	if size > maxFrameSize {
+		return fmt.Errorf("frame of %d bytes exceeds %d byte limit", size, maxFrameSize)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return fmt.Errorf("reading frame body: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("unmarshaling frame: %w", err)
+	}
+	return nil
+}
+
+// Capability names one of the five plugin.Plugin sub-interfaces an
+// out-of-process plugin can implement. A plugin declares which it supports
+// in its Handshake response.
+type Capability string
+
+const (
+	CapabilityContent  Capability = "content"
+	CapabilityRender   Capability = "render"
+	CapabilitySecurity Capability = "security"
+	CapabilityBuild    Capability = "build"
+	CapabilityOutput   Capability = "output"
+)
+
+// HandshakeResult is the plugin process's answer to the Handshake call:
+// its identity, scheduling priority, and the capabilities it implements.
+type HandshakeResult struct {
+	Name         string       `json:"name"`
+	Version      string       `json:"version"`
+	Priority     int          `json:"priority"`
+	Capabilities []Capability `json:"capabilities"`
+}
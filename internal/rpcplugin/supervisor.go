@@ -0,0 +1,145 @@
+package rpcplugin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"secureblog/internal/policy"
+	"strings"
+	"time"
+)
+
+// defaultCallTimeout bounds how long a single RPC call may take before the
+// plugin is considered wedged and killed.
+const defaultCallTimeout = 10 * time.Second
+
+// Supervisor launches and supervises out-of-process plugin executables
+// under PluginDir. Every launch is gated by Policy: the resolved path must
+// stay inside PluginDir, and the binary's SHA-256 must match the
+// policy-pinned hash for its name, so a writable plugin directory alone
+// isn't enough to get an unapproved binary executed.
+type Supervisor struct {
+	PluginDir   string
+	Policy      *policy.Policy
+	CallTimeout time.Duration
+
+	// ScratchRoot holds each plugin's private, write-only working
+	// directory (the only path it's allowed to write under). Defaults to
+	// a "secureblog-plugins" directory under os.TempDir().
+	ScratchRoot string
+}
+
+// NewSupervisor creates a Supervisor rooted at pluginDir, enforcing p (or
+// policy.Default(), which denies every exec, if p is nil).
+func NewSupervisor(pluginDir string, p *policy.Policy) *Supervisor {
+	if p == nil {
+		p = policy.Default()
+	}
+	return &Supervisor{PluginDir: pluginDir, Policy: p}
+}
+
+// Load resolves name under PluginDir, verifies it cannot escape that
+// directory and that its content hash is policy-allowed, launches it
+// sandboxed, and performs the initial handshake. The returned Client
+// reports which plugin capabilities the process implements.
+func (s *Supervisor) Load(name string) (*Client, error) {
+	execPath, err := s.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verifyHash(name, execPath); err != nil {
+		return nil, err
+	}
+	if err := s.Policy.AllowExec(name); err != nil {
+		return nil, err
+	}
+
+	h := &Host{name: name, execDir: execPath, sup: s}
+	if err := h.spawn(); err != nil {
+		return nil, fmt.Errorf("launching plugin %s: %w", name, err)
+	}
+
+	var hs HandshakeResult
+	if err := h.call(methodHandshake, nil, &hs); err != nil {
+		h.kill()
+		return nil, fmt.Errorf("handshaking with plugin %s: %w", name, err)
+	}
+
+	return &Client{host: h, HandshakeResult: hs}, nil
+}
+
+// resolve joins PluginDir and name, then rejects the result unless it is
+// still lexically contained in PluginDir -- the ".." escape a malicious or
+// misconfigured plugin name could otherwise use to exec an arbitrary path.
+func (s *Supervisor) resolve(name string) (string, error) {
+	root, err := filepath.Abs(s.PluginDir)
+	if err != nil {
+		return "", fmt.Errorf("resolving plugin directory: %w", err)
+	}
+	candidate, err := filepath.Abs(filepath.Join(root, name))
+	if err != nil {
+		return "", fmt.Errorf("resolving plugin path: %w", err)
+	}
+	if candidate != root && !strings.HasPrefix(candidate, root+string(filepath.Separator)) {
+		return "", fmt.Errorf("refusing to load plugin %q: resolves outside plugin directory %s", name, root)
+	}
+	return candidate, nil
+}
+
+// verifyHash checks execPath's SHA-256 against the policy's pinned hash
+// for name, so a plugin directory an attacker can write to (but not the
+// policy file) still can't get a substituted binary executed.
+func (s *Supervisor) verifyHash(name, execPath string) error {
+	f, err := os.Open(execPath)
+	if err != nil {
+		return fmt.Errorf("opening plugin %s: %w", name, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return fmt.Errorf("hashing plugin %s: %w", name, err)
+	}
+	return s.Policy.AllowExecHash(name, hex.EncodeToString(h.Sum(nil)))
+}
+
+// scratchDir returns (creating if necessary) name's private working
+// directory: the only place on disk it's allowed to write.
+func (s *Supervisor) scratchDir(name string) string {
+	root := s.ScratchRoot
+	if root == "" {
+		root = filepath.Join(os.TempDir(), "secureblog-plugins")
+	}
+	dir := filepath.Join(root, name)
+	os.MkdirAll(dir, 0700)
+	return dir
+}
+
+// safeEnv returns the minimal environment passed to a spawned plugin, plus
+// any variable explicitly allowed by Policy (exec.osEnv) -- the same
+// convention plugins.Sandbox uses for compiled-in plugin execution.
+func (s *Supervisor) safeEnv() []string {
+	env := []string{
+		"PATH=/usr/bin:/bin",
+		"HOME=/tmp",
+		"USER=nobody",
+	}
+	for _, kv := range os.Environ() {
+		name := strings.SplitN(kv, "=", 2)[0]
+		if s.Policy.AllowEnv(name) == nil {
+			env = append(env, kv)
+		}
+	}
+	return env
+}
+
+// callTimeout returns CallTimeout, or defaultCallTimeout if unset.
+func (s *Supervisor) callTimeout() time.Duration {
+	if s.CallTimeout > 0 {
+		return s.CallTimeout
+	}
+	return defaultCallTimeout
+}
@@ -0,0 +1,132 @@
+// Package sandbox builds commands that run deploy/build scripts inside a
+// disposable, locked-down container instead of executing them directly on
+// the host bash the admin server happens to be running under.
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Runtime selects which container engine (if any) runs the sandboxed
+// command.
+type Runtime string
+
+const (
+	RuntimeDocker Runtime = "docker"
+	RuntimePodman Runtime = "podman"
+	// RuntimeNative runs the script directly on the host with no
+	// container isolation at all. It exists for local development only
+	// and should never be selected for a web-exposed admin server.
+	RuntimeNative Runtime = "native"
+)
+
+// Config describes how to sandbox a build/deploy script.
+type Config struct {
+	// Runtime selects docker, podman, or native.
+	Runtime Runtime
+	// Image is the sandbox image name, e.g. "secureblog-builder". The
+	// digest pinned in LockPath is appended to it before every run, so a
+	// mutable tag can never be swapped out from under the admin.
+	Image string
+	// LockPath is the sandbox.lock file pinning Image to a digest.
+	LockPath string
+	// ContentDir and BuildDir are host paths bind-mounted read-only and
+	// read-write (respectively) into the container.
+	ContentDir string
+	BuildDir   string
+}
+
+// DefaultConfig is the sandbox configuration assumed when the admin hasn't
+// overridden it: docker, the repo's pinned builder image, ./content and
+// ./build.
+func DefaultConfig() Config {
+	return Config{
+		Runtime:    RuntimeDocker,
+		Image:      "secureblog-builder",
+		LockPath:   "sandbox.lock",
+		ContentDir: "content",
+		BuildDir:   "build",
+	}
+}
+
+// ParseRuntime validates s as one of the supported runtime names.
+func ParseRuntime(s string) (Runtime, error) {
+	switch Runtime(s) {
+	case RuntimeDocker, RuntimePodman, RuntimeNative:
+		return Runtime(s), nil
+	default:
+		return "", fmt.Errorf("unknown sandbox runtime %q (want docker, podman, or native)", s)
+	}
+}
+
+// readPinnedDigest reads the image digest pinned in c.LockPath, so every
+// sandboxed run references the exact image that was verified/built, not
+// whatever a mutable tag currently resolves to.
+func (c Config) readPinnedDigest() (string, error) {
+	data, err := os.ReadFile(c.LockPath)
+	if err != nil {
+		return "", fmt.Errorf("reading %s (run `make build-sandbox-image` to generate it): %w", c.LockPath, err)
+	}
+	digest := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(digest, "sha256:") {
+		return "", fmt.Errorf("%s does not contain a sha256 digest", c.LockPath)
+	}
+	return digest, nil
+}
+
+// Command builds the exec.Cmd that runs scriptPath (plus args) inside the
+// sandbox, or directly on the host for RuntimeNative. The returned *exec.Cmd
+// is ready for the caller to Start and stream stdout/stderr from exactly
+// like any other command (see Server.startJob).
+func (c Config) Command(scriptPath string, args ...string) (*exec.Cmd, error) {
+	switch c.Runtime {
+	case RuntimeDocker:
+		return c.containerCommand("docker", scriptPath, args...)
+	case RuntimePodman:
+		return c.containerCommand("podman", scriptPath, args...)
+	case RuntimeNative:
+		return exec.Command("bash", append([]string{scriptPath}, args...)...), nil
+	default:
+		return nil, fmt.Errorf("unknown sandbox runtime %q", c.Runtime)
+	}
+}
+
+func (c Config) containerCommand(binary, scriptPath string, args ...string) (*exec.Cmd, error) {
+	if _, err := exec.LookPath(binary); err != nil {
+		return nil, fmt.Errorf("sandbox runtime %q selected but %q is not on PATH: %w", binary, binary, err)
+	}
+
+	digest, err := c.readPinnedDigest()
+	if err != nil {
+		return nil, err
+	}
+
+	contentDir, err := filepath.Abs(c.ContentDir)
+	if err != nil {
+		return nil, fmt.Errorf("resolving content dir: %w", err)
+	}
+	buildDir, err := filepath.Abs(c.BuildDir)
+	if err != nil {
+		return nil, fmt.Errorf("resolving build dir: %w", err)
+	}
+
+	runArgs := []string{
+		"run", "--rm",
+		"--read-only",
+		"--network=none",
+		"--cap-drop=ALL",
+		"--security-opt=no-new-privileges",
+		"--user=nobody",
+		"-v", contentDir + ":/src/content:ro",
+		"-v", buildDir + ":/src/build:rw",
+		fmt.Sprintf("%s@%s", c.Image, digest),
+		scriptPath,
+	}
+	runArgs = append(runArgs, args...)
+
+	return exec.Command(binary, runArgs...), nil
+}
@@ -0,0 +1,74 @@
+package security
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SigningKeyPath and SigningPubKeyPath are the default locations of the
+// build's Ed25519 signing key pair. The public half is kept separate so a
+// verifier never needs access to the private key.
+const (
+	SigningKeyPath    = ".transparency/signing.key"
+	SigningPubKeyPath = ".transparency/signing.pub"
+)
+
+// LoadOrGenerateSigningKey reads the Ed25519 private key at path, generating
+// and persisting a new key pair if none exists yet. This is the
+// locally-held signing identity; keyless signing swaps this out for an
+// ephemeral key minted from an OIDC token, via GenerateEphemeralSigningKey.
+func LoadOrGenerateSigningKey(path string) (ed25519.PrivateKey, error) {
+	if data, err := os.ReadFile(path); err == nil {
+		key, err := hex.DecodeString(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("parsing signing key: %w", err)
+		}
+		return ed25519.PrivateKey(key), nil
+	}
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generating signing key: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("creating key directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(priv)), 0600); err != nil {
+		return nil, fmt.Errorf("persisting signing key: %w", err)
+	}
+	if err := os.WriteFile(SigningPubKeyPath, []byte(hex.EncodeToString(pub)), 0644); err != nil {
+		return nil, fmt.Errorf("persisting public key: %w", err)
+	}
+
+	return priv, nil
+}
+
+// LoadPublicKey reads the Ed25519 public key persisted alongside the
+// signing key at LoadOrGenerateSigningKey time.
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading public key: %w", err)
+	}
+	key, err := hex.DecodeString(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("parsing public key: %w", err)
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// GenerateEphemeralSigningKey creates a one-off Ed25519 key that is never
+// written to disk, for keyless (OIDC-backed) signing where the private key
+// must not outlive the build that used it.
+func GenerateEphemeralSigningKey() (ed25519.PrivateKey, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generating ephemeral signing key: %w", err)
+	}
+	return priv, nil
+}
@@ -0,0 +1,159 @@
+package security
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"secureblog/internal/resources"
+	"sort"
+	"time"
+)
+
+// ToolVersion identifies the build tool that produced a manifest.
+const ToolVersion = "secureblog-builder/1"
+
+// ManifestFile is a single file entry in a BuildManifest, keyed by its
+// slash-separated path relative to the output directory.
+type ManifestFile struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// RemoteResourceEntry records one URL the build fetched at render time
+// (e.g. for an SRI hash), so reviewers can see exactly what the build
+// pulled from the network without re-running it.
+type RemoteResourceEntry struct {
+	URL         string `json:"url"`
+	SHA256      string `json:"sha256"`
+	SRIHash     string `json:"sri_hash"`
+	ContentType string `json:"content_type,omitempty"`
+	FetchedAt   string `json:"fetched_at"`
+}
+
+// BuildManifest is the canonical, signable description of a build's output
+// tree: every file's content hash plus a Merkle root over those hashes.
+type BuildManifest struct {
+	Version         string                `json:"version"`
+	BuildTimestamp  string                `json:"build_timestamp"`
+	ToolVersion     string                `json:"tool_version"`
+	RootHash        string                `json:"root_hash"`
+	Files           []ManifestFile        `json:"files"`
+	RemoteResources []RemoteResourceEntry `json:"remote_resources,omitempty"`
+}
+
+// BuildManifestFor walks outputDir and produces a canonical manifest: paths
+// are sorted so the JSON encoding (and therefore its digest) is stable
+// across builds that produce byte-identical output.
+func BuildManifestFor(outputDir string) (*BuildManifest, error) {
+	var files []ManifestFile
+
+	err := filepath.Walk(outputDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(outputDir, path)
+		if err != nil {
+			return err
+		}
+
+		h := sha256.Sum256(content)
+		files = append(files, ManifestFile{
+			Path:   filepath.ToSlash(rel),
+			SHA256: hex.EncodeToString(h[:]),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walking %s: %w", outputDir, err)
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	leaves := make([][32]byte, len(files))
+	for i, f := range files {
+		digest, err := hex.DecodeString(f.SHA256)
+		if err != nil {
+			return nil, err
+		}
+		leaves[i] = leafHash(digest)
+	}
+	root := merkleRoot(leaves)
+
+	remote, err := resources.LoadRecords()
+	if err != nil {
+		return nil, fmt.Errorf("loading remote resource cache: %w", err)
+	}
+	entries := make([]RemoteResourceEntry, len(remote))
+	for i, r := range remote {
+		entries[i] = RemoteResourceEntry{
+			URL:         r.URL,
+			SHA256:      r.SHA256,
+			SRIHash:     r.SRIHash,
+			ContentType: r.ContentType,
+			FetchedAt:   r.FetchedAt.UTC().Format(time.RFC3339),
+		}
+	}
+
+	return &BuildManifest{
+		Version:         "1",
+		BuildTimestamp:  time.Now().UTC().Format(time.RFC3339),
+		ToolVersion:     ToolVersion,
+		RootHash:        hex.EncodeToString(root[:]),
+		Files:           files,
+		RemoteResources: entries,
+	}, nil
+}
+
+// Canonical returns the manifest's canonical JSON encoding: the same bytes
+// for the same content regardless of build machine, since Files is sorted
+// and struct fields encode in a fixed declaration order.
+func (m *BuildManifest) Canonical() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+// Digest returns the SHA-256 digest of the manifest's canonical encoding.
+func (m *BuildManifest) Digest() (string, error) {
+	canonical, err := m.Canonical()
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(canonical)
+	return hex.EncodeToString(h[:]), nil
+}
+
+// Diff reports every path whose hash differs (or is missing) between two
+// manifests, used by the `reproduce` verification flow.
+func (m *BuildManifest) Diff(other *BuildManifest) []string {
+	want := make(map[string]string, len(other.Files))
+	for _, f := range other.Files {
+		want[f.Path] = f.SHA256
+	}
+
+	var diffs []string
+	seen := make(map[string]bool, len(m.Files))
+	for _, f := range m.Files {
+		seen[f.Path] = true
+		if expected, ok := want[f.Path]; !ok {
+			diffs = append(diffs, fmt.Sprintf("unexpected file: %s", f.Path))
+		} else if expected != f.SHA256 {
+			diffs = append(diffs, fmt.Sprintf("hash mismatch: %s", f.Path))
+		}
+	}
+	for path := range want {
+		if !seen[path] {
+			diffs = append(diffs, fmt.Sprintf("missing file: %s", path))
+		}
+	}
+
+	sort.Strings(diffs)
+	return diffs
+}
@@ -0,0 +1,51 @@
+package security
+
+import "crypto/sha256"
+
+// merkle.go implements an RFC 6962-style left-balanced Merkle tree, shared
+// by the build manifest's content-tree root hash and the build transparency
+// log's checkpoint root.
+
+const (
+	leafHashPrefix byte = 0x00
+	nodeHashPrefix byte = 0x01
+)
+
+// leafHash returns the RFC 6962 leaf hash for data.
+func leafHash(data []byte) [32]byte {
+	return sha256.Sum256(append([]byte{leafHashPrefix}, data...))
+}
+
+// nodeHash returns the RFC 6962 interior node hash for a left/right pair.
+func nodeHash(left, right [32]byte) [32]byte {
+	buf := make([]byte, 0, 1+len(left)+len(right))
+	buf = append(buf, nodeHashPrefix)
+	buf = append(buf, left[:]...)
+	buf = append(buf, right[:]...)
+	return sha256.Sum256(buf)
+}
+
+// merkleRoot computes the root of a left-balanced Merkle tree over leaves,
+// matching RFC 6962's Merkle Tree Hash (MTH) computation.
+func merkleRoot(leaves [][32]byte) [32]byte {
+	switch len(leaves) {
+	case 0:
+		return sha256.Sum256(nil)
+	case 1:
+		return leaves[0]
+	default:
+		k := largestPowerOfTwoLessThan(len(leaves))
+		left := merkleRoot(leaves[:k])
+		right := merkleRoot(leaves[k:])
+		return nodeHash(left, right)
+	}
+}
+
+// largestPowerOfTwoLessThan returns the largest power of two strictly less than n.
+func largestPowerOfTwoLessThan(n int) int {
+	k := 1
+	for k*2 < n {
+		k *= 2
+	}
+	return k
+}
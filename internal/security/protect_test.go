@@ -0,0 +1,122 @@
+package security
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"crypto/sha256"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// unwrapForTest mirrors the browser-side decryption in assets/protect.js,
+// so the round trip is verified end-to-end without a browser.
+func unwrapForTest(t *testing.T, pp *ProtectedPost, wrapped WrappedKey, passphrase string) []byte {
+	t.Helper()
+
+	salt, err := base64.StdEncoding.DecodeString(wrapped.Salt)
+	if err != nil {
+		t.Fatalf("decoding salt: %v", err)
+	}
+	wrapKey := pbkdf2.Key([]byte(passphrase), salt, pp.KDFIterations, protectKeyLength, sha256.New)
+
+	nonce, err := base64.StdEncoding.DecodeString(wrapped.Nonce)
+	if err != nil {
+		t.Fatalf("decoding wrap nonce: %v", err)
+	}
+	wrappedCEK, err := base64.StdEncoding.DecodeString(wrapped.WrappedKey)
+	if err != nil {
+		t.Fatalf("decoding wrapped key: %v", err)
+	}
+
+	block, err := aes.NewCipher(wrapKey)
+	if err != nil {
+		t.Fatalf("aes.NewCipher: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatalf("cipher.NewGCM: %v", err)
+	}
+	contentKey, err := gcm.Open(nil, nonce, wrappedCEK, nil)
+	if err != nil {
+		t.Fatalf("unwrapping content key: %v", err)
+	}
+
+	contentNonce, err := base64.StdEncoding.DecodeString(pp.ContentNonce)
+	if err != nil {
+		t.Fatalf("decoding content nonce: %v", err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(pp.ContentCiphertext)
+	if err != nil {
+		t.Fatalf("decoding content ciphertext: %v", err)
+	}
+
+	contentBlock, err := aes.NewCipher(contentKey)
+	if err != nil {
+		t.Fatalf("aes.NewCipher (content): %v", err)
+	}
+	contentGCM, err := cipher.NewGCM(contentBlock)
+	if err != nil {
+		t.Fatalf("cipher.NewGCM (content): %v", err)
+	}
+	plaintext, err := contentGCM.Open(nil, contentNonce, ciphertext, nil)
+	if err != nil {
+		t.Fatalf("decrypting content: %v", err)
+	}
+	return plaintext
+}
+
+func TestProtectPostPasswordRoundTrip(t *testing.T) {
+	pp, err := ProtectPost([]byte("<p>secret</p>"), "hunter2", "")
+	if err != nil {
+		t.Fatalf("ProtectPost() error = %v", err)
+	}
+	if pp.SecretCode != nil {
+		t.Fatalf("SecretCode = %+v, want nil when none was given", pp.SecretCode)
+	}
+
+	got := unwrapForTest(t, pp, pp.Password, "hunter2")
+	if string(got) != "<p>secret</p>" {
+		t.Errorf("decrypted content = %q, want %q", got, "<p>secret</p>")
+	}
+}
+
+func TestProtectPostSecretCodeUnlocksIndependently(t *testing.T) {
+	pp, err := ProtectPost([]byte("<p>secret</p>"), "hunter2", "shared-link-code")
+	if err != nil {
+		t.Fatalf("ProtectPost() error = %v", err)
+	}
+	if pp.SecretCode == nil {
+		t.Fatalf("SecretCode = nil, want a wrapped key")
+	}
+
+	got := unwrapForTest(t, pp, *pp.SecretCode, "shared-link-code")
+	if string(got) != "<p>secret</p>" {
+		t.Errorf("decrypted content via secret_code = %q, want %q", got, "<p>secret</p>")
+	}
+}
+
+func TestProtectedPageHTMLEmbedsDataAndScript(t *testing.T) {
+	pp, err := ProtectPost([]byte("<p>secret</p>"), "hunter2", "")
+	if err != nil {
+		t.Fatalf("ProtectPost() error = %v", err)
+	}
+
+	page, err := ProtectedPageHTML("My Post", pp)
+	if err != nil {
+		t.Fatalf("ProtectedPageHTML() error = %v", err)
+	}
+
+	html := string(page)
+	if !strings.Contains(html, pp.ContentCiphertext) {
+		t.Errorf("page does not embed the content ciphertext")
+	}
+	if !strings.Contains(html, "My Post") {
+		t.Errorf("page does not contain the post title")
+	}
+	if strings.Contains(html, "__JS__") || strings.Contains(html, "__DATA__") || strings.Contains(html, "__TITLE__") {
+		t.Errorf("page left an unsubstituted template placeholder: %s", html)
+	}
+}
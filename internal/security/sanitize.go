@@ -0,0 +1,211 @@
+package security
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Policy defines what an allowlist Sanitizer accepts: which elements may
+// appear, which attributes each of those elements may carry, and which URL
+// schemes are permitted in href/src attributes. Anything not listed is
+// dropped rather than escaped.
+type Policy struct {
+	Elements   map[string]bool
+	Attributes map[string]map[string]bool
+	Schemes    map[string]bool
+}
+
+// urlAttributes names the attributes whose values are URLs and therefore
+// subject to Policy.Schemes.
+var urlAttributes = map[string]bool{"href": true, "src": true}
+
+// danglingElements are never kept, even unwrapped: their content (raw
+// script/style text, or nodes the parser wouldn't have allowed as siblings)
+// is discarded along with the element itself.
+var danglingElements = map[string]bool{
+	"script": true, "style": true, "iframe": true,
+	"object": true, "embed": true, "noscript": true,
+}
+
+// DefaultPolicy is the allowlist used for blog content: the small set of
+// elements blackfriday emits for CommonMark markdown, the containers the
+// built-in shortcodes (figure, callout) wrap their output in, and the URL
+// schemes a post link or image can reasonably use. "class" is only ever
+// allowed on those containers, and only to carry a shortcode's own
+// styling hook -- never free-form content -- so CSP can keep
+// `style-src 'self'` with no inline styles.
+func DefaultPolicy() *Policy {
+	return &Policy{
+		Elements: map[string]bool{
+			"p": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+			"a": true, "code": true, "pre": true, "blockquote": true,
+			"ul": true, "ol": true, "li": true, "em": true, "strong": true, "img": true,
+			"div": true, "figure": true, "figcaption": true,
+		},
+		Attributes: map[string]map[string]bool{
+			"a":          {"href": true, "title": true, "rel": true, "target": true},
+			"img":        {"src": true, "alt": true, "title": true, "width": true, "height": true},
+			"div":        {"class": true},
+			"figure":     {"class": true},
+			"figcaption": {"class": true},
+		},
+		Schemes: map[string]bool{"http": true, "https": true, "mailto": true},
+	}
+}
+
+// GFMPolicy extends DefaultPolicy with the extra elements GitHub Flavored
+// Markdown renderers (tables, task lists, strikethrough, footnotes) and
+// class-based syntax highlighting emit. Classes are only ever allowed on
+// "pre", "code", and "span" -- the chroma highlighter's token wrappers --
+// so CSP can keep `style-src 'self'` with no inline styles.
+func GFMPolicy() *Policy {
+	p := DefaultPolicy()
+
+	for _, el := range []string{
+		"table", "thead", "tbody", "tr", "th", "td",
+		"del", "input", "sup", "hr", "span",
+	} {
+		p.Elements[el] = true
+	}
+
+	for _, heading := range []string{"h1", "h2", "h3", "h4", "h5", "h6"} {
+		p.Attributes[heading] = map[string]bool{"id": true}
+	}
+	p.Attributes["a"]["id"] = true
+	p.Attributes["input"] = map[string]bool{"type": true, "checked": true, "disabled": true}
+	p.Attributes["pre"] = map[string]bool{"class": true}
+	p.Attributes["code"] = map[string]bool{"class": true}
+	p.Attributes["span"] = map[string]bool{"class": true}
+
+	return p
+}
+
+// Sanitizer rewrites arbitrary HTML into the subset permitted by its Policy
+// by walking a real parse tree, rather than pattern-matching on the source
+// text: disallowed elements are unwrapped (their children, if any, are kept
+// as siblings of where the element was), disallowed attributes are dropped,
+// and URL-valued attributes with a scheme outside the allowlist are dropped.
+type Sanitizer struct {
+	policy *Policy
+}
+
+// NewSanitizer creates a Sanitizer enforcing policy. A nil policy behaves
+// like DefaultPolicy().
+func NewSanitizer(policy *Policy) *Sanitizer {
+	if policy == nil {
+		policy = DefaultPolicy()
+	}
+	return &Sanitizer{policy: policy}
+}
+
+// Sanitize parses s as an HTML fragment and re-renders it with every
+// element, attribute, and URL scheme outside the Sanitizer's Policy
+// removed.
+func (s *Sanitizer) Sanitize(input string) string {
+	root := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(input), root)
+	if err != nil {
+		return ""
+	}
+	for _, n := range nodes {
+		root.AppendChild(n)
+	}
+
+	s.sanitizeChildren(root)
+
+	var buf bytes.Buffer
+	for c := root.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(&buf, c); err != nil {
+			return ""
+		}
+	}
+	return buf.String()
+}
+
+// sanitizeChildren walks n's children in order, recursing into each before
+// deciding whether to keep, unwrap, or drop it.
+func (s *Sanitizer) sanitizeChildren(n *html.Node) {
+	child := n.FirstChild
+	for child != nil {
+		next := child.NextSibling
+		s.sanitizeNode(n, child)
+		child = next
+	}
+}
+
+// sanitizeNode enforces the policy on child, a child of parent.
+func (s *Sanitizer) sanitizeNode(parent, child *html.Node) {
+	if child.Type != html.ElementNode {
+		return
+	}
+
+	s.sanitizeChildren(child)
+
+	if s.policy.Elements[child.Data] {
+		child.Attr = s.sanitizeAttrs(child.Data, child.Attr)
+		return
+	}
+
+	if danglingElements[child.Data] {
+		parent.RemoveChild(child)
+		return
+	}
+
+	// Disallowed but otherwise harmless wrapper (e.g. <div>, <span>): keep
+	// its already-sanitized children in its place, drop the element itself.
+	for grandchild := child.FirstChild; grandchild != nil; {
+		next := grandchild.NextSibling
+		child.RemoveChild(grandchild)
+		parent.InsertBefore(grandchild, child)
+		grandchild = next
+	}
+	parent.RemoveChild(child)
+}
+
+func (s *Sanitizer) sanitizeAttrs(tag string, attrs []html.Attribute) []html.Attribute {
+	allowed := s.policy.Attributes[tag]
+	out := make([]html.Attribute, 0, len(attrs))
+	for _, a := range attrs {
+		if !allowed[a.Key] {
+			continue
+		}
+		if urlAttributes[a.Key] && !s.schemeAllowed(a.Val) {
+			continue
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// schemeAllowed reports whether rawurl is relative (no scheme) or uses a
+// scheme in the Sanitizer's Policy.
+func (s *Sanitizer) schemeAllowed(rawurl string) bool {
+	return schemeAllowed(rawurl, s.policy.Schemes)
+}
+
+// schemeAllowed reports whether rawurl is relative (no scheme) or uses one
+// of schemes.
+func schemeAllowed(rawurl string, schemes map[string]bool) bool {
+	u, err := url.Parse(strings.TrimSpace(rawurl))
+	if err != nil {
+		return false
+	}
+	if u.Scheme == "" {
+		return true
+	}
+	return schemes[strings.ToLower(u.Scheme)]
+}
+
+// IsValidURL reports whether rawurl is safe to emit in an href/src
+// attribute under DefaultPolicy: relative, or using an allowlisted
+// scheme (http, https, mailto). It applies the same check Sanitizer uses
+// on parsed HTML, exported so content plugins that emit their own links
+// (e.g. a Markdown engine's link renderer) can reject dangerous targets
+// before the sanitizer stage ever sees them.
+func IsValidURL(rawurl string) bool {
+	return schemeAllowed(rawurl, DefaultPolicy().Schemes)
+}
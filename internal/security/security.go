@@ -1,13 +1,14 @@
 package security
 
 import (
+	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"html"
 	"io/ioutil"
-	"os"
 	"path/filepath"
 	"strings"
 )
@@ -19,14 +20,16 @@ func GenerateNonce() string {
 	return hex.EncodeToString(b)
 }
 
-// SanitizeHTML removes potentially dangerous HTML
+// defaultSanitizer backs SanitizeHTML. It enforces DefaultPolicy, the
+// allowlist used everywhere in the build pipeline that renders untrusted or
+// semi-trusted HTML (markdown output, plugin output, template content).
+var defaultSanitizer = NewSanitizer(DefaultPolicy())
+
+// SanitizeHTML removes HTML outside DefaultPolicy's allowlist by parsing s
+// as a real HTML tree and re-rendering only the permitted elements,
+// attributes, and URL schemes. Use NewSanitizer for a different policy.
 func SanitizeHTML(s string) string {
-	// Remove script tags and event handlers
-	s = strings.ReplaceAll(s, "<script", "&lt;script")
-	s = strings.ReplaceAll(s, "</script>", "&lt;/script&gt;")
-	s = strings.ReplaceAll(s, "javascript:", "")
-	s = strings.ReplaceAll(s, "on", "&#111;n")
-	return s
+	return defaultSanitizer.Sanitize(s)
 }
 
 // EscapeHTML escapes HTML special characters
@@ -44,114 +47,165 @@ func EscapeXML(s string) string {
 	return s
 }
 
-// SignBuild creates integrity hashes for all files
+// manifestPath and signaturePath are where SignBuild writes the canonical
+// build manifest and its detached signature, inside outputDir.
+func manifestPath(outputDir string) string  { return filepath.Join(outputDir, "manifest.json") }
+func signaturePath(outputDir string) string { return filepath.Join(outputDir, "manifest.json.sig") }
+
+// SignBuild produces a canonical, signed build manifest (sorted file
+// hashes plus a content-tree root hash), signs it with the build's Ed25519
+// key, and appends the signed manifest's digest to the append-only
+// transparency log under TransparencyDir. This replaces the old plaintext
+// integrity.txt with a verifiable supply-chain artifact.
 func SignBuild(outputDir string) error {
-	manifest := make(map[string]string)
+	manifest, err := BuildManifestFor(outputDir)
+	if err != nil {
+		return fmt.Errorf("building manifest: %w", err)
+	}
 
-	err := filepath.Walk(outputDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil || info.IsDir() {
-			return err
-		}
+	canonical, err := manifest.Canonical()
+	if err != nil {
+		return fmt.Errorf("encoding manifest: %w", err)
+	}
 
-		content, err := ioutil.ReadFile(path)
-		if err != nil {
-			return err
-		}
+	key, err := LoadOrGenerateSigningKey(SigningKeyPath)
+	if err != nil {
+		return fmt.Errorf("loading signing key: %w", err)
+	}
 
-		h := sha256.Sum256(content)
-		hash := hex.EncodeToString(h[:])
-		
-		rel, _ := filepath.Rel(outputDir, path)
-		manifest[rel] = hash
+	signature := ed25519.Sign(key, canonical)
 
-		return nil
-	})
+	if err := ioutil.WriteFile(manifestPath(outputDir), canonical, 0644); err != nil {
+		return fmt.Errorf("writing manifest: %w", err)
+	}
+	if err := ioutil.WriteFile(signaturePath(outputDir), []byte(hex.EncodeToString(signature)), 0644); err != nil {
+		return fmt.Errorf("writing manifest signature: %w", err)
+	}
 
+	digest, err := manifest.Digest()
 	if err != nil {
-		return err
+		return fmt.Errorf("digesting manifest: %w", err)
 	}
 
-	// Write manifest
-	var manifestContent strings.Builder
-	for file, hash := range manifest {
-		manifestContent.WriteString(fmt.Sprintf("%s:%s\n", file, hash))
+	if _, err := AppendToLog(TransparencyDir, digest, key); err != nil {
+		return fmt.Errorf("appending to transparency log: %w", err)
 	}
 
-	return ioutil.WriteFile(
-		filepath.Join(outputDir, "integrity.txt"),
-		[]byte(manifestContent.String()),
-		0644,
-	)
+	return nil
 }
 
-// VerifyBuild checks integrity of all files
+// VerifyBuild checks that outputDir matches its signed manifest: the
+// manifest signature is valid and the manifest's digest is present in the
+// transparency log with a validly-signed checkpoint.
 func VerifyBuild(outputDir string) error {
-	manifestPath := filepath.Join(outputDir, "integrity.txt")
-	content, err := ioutil.ReadFile(manifestPath)
+	canonical, err := ioutil.ReadFile(manifestPath(outputDir))
 	if err != nil {
 		return fmt.Errorf("reading manifest: %w", err)
 	}
 
-	lines := strings.Split(string(content), "\n")
-	for _, line := range lines {
-		if line == "" {
-			continue
-		}
+	sigHex, err := ioutil.ReadFile(signaturePath(outputDir))
+	if err != nil {
+		return fmt.Errorf("reading manifest signature: %w", err)
+	}
+	signature, err := hex.DecodeString(string(sigHex))
+	if err != nil {
+		return fmt.Errorf("parsing manifest signature: %w", err)
+	}
 
-		parts := strings.Split(line, ":")
-		if len(parts) != 2 {
-			continue
-		}
+	pub, err := LoadPublicKey(SigningPubKeyPath)
+	if err != nil {
+		return fmt.Errorf("loading public key: %w", err)
+	}
+	if !ed25519.Verify(pub, canonical, signature) {
+		return fmt.Errorf("manifest signature verification failed")
+	}
 
-		file, expectedHash := parts[0], parts[1]
-		
-		// Skip manifest itself
-		if file == "integrity.txt" {
-			continue
-		}
+	var manifest BuildManifest
+	if err := json.Unmarshal(canonical, &manifest); err != nil {
+		return fmt.Errorf("parsing manifest: %w", err)
+	}
 
-		filePath := filepath.Join(outputDir, file)
+	for _, file := range manifest.Files {
+		filePath := filepath.Join(outputDir, file.Path)
 		content, err := ioutil.ReadFile(filePath)
 		if err != nil {
-			return fmt.Errorf("reading %s: %w", file, err)
+			return fmt.Errorf("reading %s: %w", file.Path, err)
 		}
 
 		h := sha256.Sum256(content)
-		actualHash := hex.EncodeToString(h[:])
-
-		if actualHash != expectedHash {
-			return fmt.Errorf("integrity check failed for %s", file)
+		if hex.EncodeToString(h[:]) != file.SHA256 {
+			return fmt.Errorf("integrity check failed for %s", file.Path)
 		}
 	}
 
-	return nil
+	digest, err := manifest.Digest()
+	if err != nil {
+		return fmt.Errorf("digesting manifest: %w", err)
+	}
+
+	return VerifyLogContains(TransparencyDir, digest, pub)
+}
+
+// ReproduceBuild rebuilds outputDir is assumed to have already happened by
+// the caller; ReproduceBuild just diffs its freshly-computed manifest
+// against the known-good manifest recorded at knownGoodPath, reporting any
+// path whose content hash doesn't match.
+func ReproduceBuild(outputDir, knownGoodPath string) ([]string, error) {
+	rebuilt, err := BuildManifestFor(outputDir)
+	if err != nil {
+		return nil, fmt.Errorf("building manifest: %w", err)
+	}
+
+	data, err := ioutil.ReadFile(knownGoodPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading known-good manifest: %w", err)
+	}
+
+	var knownGood BuildManifest
+	if err := json.Unmarshal(data, &knownGood); err != nil {
+		return nil, fmt.Errorf("parsing known-good manifest: %w", err)
+	}
+
+	return rebuilt.Diff(&knownGood), nil
+}
+
+// maxSecurityHeaders is the canonical maximum-security header set: a
+// locked-down CSP plus the usual hardening headers. It backs both
+// GenerateHeaders (a static _headers file for Netlify/Cloudflare/etc.) and
+// SecurityHeaders (live responses from the dev server).
+var maxSecurityHeaders = []struct{ Name, Value string }{
+	{"Content-Security-Policy", "default-src 'none'; style-src 'self'; img-src 'self' data:; form-action 'none'; frame-ancestors 'none'; base-uri 'none'; upgrade-insecure-requests"},
+	{"X-Frame-Options", "DENY"},
+	{"X-Content-Type-Options", "nosniff"},
+	{"X-XSS-Protection", "1; mode=block"},
+	{"Referrer-Policy", "no-referrer"},
+	{"Permissions-Policy", "geolocation=(), microphone=(), camera=(), payment=(), usb=(), magnetometer=(), gyroscope=(), accelerometer=()"},
+	{"Strict-Transport-Security", "max-age=31536000; includeSubDomains; preload"},
+}
+
+// SecurityHeaders returns the maxSecurityHeaders set as a map, for callers
+// (such as the dev server) that set headers on live HTTP responses rather
+// than writing a static host-config file.
+func SecurityHeaders() map[string]string {
+	h := make(map[string]string, len(maxSecurityHeaders))
+	for _, kv := range maxSecurityHeaders {
+		h[kv.Name] = kv.Value
+	}
+	return h
 }
 
 // GenerateHeaders creates security headers for web server
 func GenerateHeaders(outputDir string) error {
-	headers := `# Security Headers for Nginx/Apache/CloudFlare
-
-# Content Security Policy - Maximum Security
-Content-Security-Policy: default-src 'none'; style-src 'self'; img-src 'self' data:; form-action 'none'; frame-ancestors 'none'; base-uri 'none'; upgrade-insecure-requests
-
-# Other Security Headers
-X-Frame-Options: DENY
-X-Content-Type-Options: nosniff
-X-XSS-Protection: 1; mode=block
-Referrer-Policy: no-referrer
-Permissions-Policy: geolocation=(), microphone=(), camera=(), payment=(), usb=(), magnetometer=(), gyroscope=(), accelerometer=()
-
-# HSTS (if using HTTPS)
-Strict-Transport-Security: max-age=31536000; includeSubDomains; preload
-
-# Remove server identification
-Server: 
-X-Powered-By: 
-`
-	
+	var b strings.Builder
+	b.WriteString("# Security Headers for Nginx/Apache/CloudFlare\n\n")
+	for _, kv := range maxSecurityHeaders {
+		fmt.Fprintf(&b, "%s: %s\n", kv.Name, kv.Value)
+	}
+	b.WriteString("\n# Remove server identification\nServer: \nX-Powered-By: \n")
+
 	return ioutil.WriteFile(
 		filepath.Join(outputDir, "_headers"),
-		[]byte(headers),
+		[]byte(b.String()),
 		0644,
 	)
 }
\ No newline at end of file
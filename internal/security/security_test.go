@@ -1,6 +1,8 @@
 package security
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -8,12 +10,12 @@ import (
 func TestGenerateNonce(t *testing.T) {
 	nonce1 := GenerateNonce()
 	nonce2 := GenerateNonce()
-	
+
 	// Nonces should be unique
 	if nonce1 == nonce2 {
 		t.Error("GenerateNonce should produce unique values")
 	}
-	
+
 	// Nonces should be 32 characters (16 bytes hex encoded)
 	if len(nonce1) != 32 {
 		t.Errorf("Nonce length should be 32, got %d", len(nonce1))
@@ -27,27 +29,37 @@ func TestSanitizeHTML(t *testing.T) {
 		want  string
 	}{
 		{
-			name:  "Script tags",
+			name:  "Script tags are dropped entirely",
 			input: "<script>alert('xss')</script>",
-			want:  "&lt;script&gt;alert('xss')&lt;/script&gt;",
+			want:  "",
 		},
 		{
-			name:  "JavaScript protocol",
+			name:  "JavaScript protocol is stripped from href",
 			input: `<a href="javascript:alert('xss')">click</a>`,
-			want:  `<a href="alert('xss')">click</a>`,
+			want:  `<a>click</a>`,
 		},
 		{
-			name:  "Event handlers",
-			input: `<div onclick="alert('xss')">test</div>`,
-			want:  `<div &#111;nclick="alert('xss')">test</div>`,
+			name:  "Event handler attributes are dropped",
+			input: `<span onclick="alert('xss')">test</span>`,
+			want:  `test`,
 		},
 		{
-			name:  "Clean HTML",
+			name:  "Clean HTML in the allowlist passes through unchanged",
 			input: `<p>This is clean</p>`,
 			want:  `<p>This is clean</p>`,
 		},
+		{
+			name:  "Benign words containing \"on\" are left alone",
+			input: `<p>Sit on the button</p>`,
+			want:  `<p>Sit on the button</p>`,
+		},
+		{
+			name:  "http(s) and mailto links are preserved",
+			input: `<a href="https://example.com">link</a> <a href="mailto:a@example.com">mail</a>`,
+			want:  `<a href="https://example.com">link</a> <a href="mailto:a@example.com">mail</a>`,
+		},
 	}
-	
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			got := SanitizeHTML(tt.input)
@@ -68,7 +80,7 @@ func TestEscapeHTML(t *testing.T) {
 		{`"quotes"`, "&#34;quotes&#34;"},
 		{"normal text", "normal text"},
 	}
-	
+
 	for _, tt := range tests {
 		got := EscapeHTML(tt.input)
 		if got != tt.want {
@@ -83,20 +95,20 @@ func TestGenerateHeaders(t *testing.T) {
 		"X-Frame-Options":         "DENY",
 		"X-Content-Type-Options":  "nosniff",
 	}
-	
+
 	// Check required headers are present
 	requiredHeaders := []string{
 		"Content-Security-Policy",
-		"X-Frame-Options", 
+		"X-Frame-Options",
 		"X-Content-Type-Options",
 	}
-	
+
 	for _, h := range requiredHeaders {
 		if _, ok := headers[h]; !ok {
 			t.Errorf("Required header %s is missing", h)
 		}
 	}
-	
+
 	// Check CSP is strict
 	csp := headers["Content-Security-Policy"]
 	if !strings.Contains(csp, "default-src 'none'") {
@@ -107,11 +119,11 @@ func TestGenerateHeaders(t *testing.T) {
 func TestSignBuild(t *testing.T) {
 	// Create temporary directory
 	tempDir := t.TempDir()
-	
+
 	// Create test files
 	testFile := "test.html"
 	testContent := []byte("<html><body>Test</body></html>")
-	
+
 	err := os.WriteFile(
 		filepath.Join(tempDir, testFile),
 		testContent,
@@ -120,19 +132,21 @@ func TestSignBuild(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create test file: %v", err)
 	}
-	
+
 	// Sign the build
 	err = SignBuild(tempDir)
 	if err != nil {
 		t.Fatalf("SignBuild failed: %v", err)
 	}
-	
-	// Check integrity file exists
-	integrityPath := filepath.Join(tempDir, "integrity.txt")
-	if _, err := os.Stat(integrityPath); os.IsNotExist(err) {
-		t.Error("Integrity file was not created")
+
+	// Check signed manifest exists
+	if _, err := os.Stat(filepath.Join(tempDir, "manifest.json")); os.IsNotExist(err) {
+		t.Error("Build manifest was not created")
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "manifest.json.sig")); os.IsNotExist(err) {
+		t.Error("Manifest signature was not created")
 	}
-	
+
 	// Verify the build
 	err = VerifyBuild(tempDir)
 	if err != nil {
@@ -151,12 +165,88 @@ func TestNoJavaScriptViolations(t *testing.T) {
 		"setTimeout(",
 		"setInterval(",
 	}
-	
+
 	safeContent := "This is safe HTML content without any JavaScript"
-	
+
 	for _, pattern := range badPatterns {
 		if strings.Contains(safeContent, pattern) {
 			t.Errorf("Safe content should not contain pattern: %s", pattern)
 		}
 	}
-}
\ No newline at end of file
+}
+
+func TestSanitizerUnwrapsDisallowedElements(t *testing.T) {
+	s := NewSanitizer(DefaultPolicy())
+
+	got := s.Sanitize(`<span class="wrapper"><p>kept</p></span>`)
+	want := `<p>kept</p>`
+	if got != want {
+		t.Errorf("Sanitize() = %q, want %q", got, want)
+	}
+}
+
+func TestSanitizerKeepsAllowlistedContainersWithClass(t *testing.T) {
+	s := NewSanitizer(DefaultPolicy())
+
+	got := s.Sanitize(`<div class="callout callout-warning"><p>careful</p></div>`)
+	want := `<div class="callout callout-warning"><p>careful</p></div>`
+	if got != want {
+		t.Errorf("Sanitize() = %q, want %q", got, want)
+	}
+}
+
+func TestSanitizerDropsDisallowedAttributes(t *testing.T) {
+	s := NewSanitizer(DefaultPolicy())
+
+	got := s.Sanitize(`<img src="https://example.com/a.png" onerror="evil()" style="color:red">`)
+	want := `<img src="https://example.com/a.png"/>`
+	if got != want {
+		t.Errorf("Sanitize() = %q, want %q", got, want)
+	}
+}
+
+func TestSanitizerRejectsDisallowedSchemes(t *testing.T) {
+	s := NewSanitizer(DefaultPolicy())
+
+	got := s.Sanitize(`<img src="data:image/png;base64,AAAA">`)
+	want := `<img/>`
+	if got != want {
+		t.Errorf("Sanitize() = %q, want %q", got, want)
+	}
+}
+
+func TestSanitizerCustomPolicy(t *testing.T) {
+	policy := &Policy{
+		Elements:   map[string]bool{"p": true},
+		Attributes: map[string]map[string]bool{},
+		Schemes:    map[string]bool{},
+	}
+	s := NewSanitizer(policy)
+
+	got := s.Sanitize(`<p>text</p><a href="https://example.com">link</a>`)
+	want := `<p>text</p>link`
+	if got != want {
+		t.Errorf("Sanitize() = %q, want %q", got, want)
+	}
+}
+
+func TestIsValidURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://example.com/post", true},
+		{"http://example.com/post", true},
+		{"mailto:a@example.com", true},
+		{"/relative/path", true},
+		{"javascript:alert(1)", false},
+		{"data:text/html,<script>alert(1)</script>", false},
+		{"vbscript:evil()", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsValidURL(tt.url); got != tt.want {
+			t.Errorf("IsValidURL(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package security
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// TransparencyDir is where the append-only build log and its checkpoint live.
+const TransparencyDir = ".transparency"
+
+// LogEntry is one append-only record in the transparency log: the digest of
+// a signed build manifest, chained to the log root that preceded it.
+type LogEntry struct {
+	Timestamp      string `json:"timestamp"`
+	ManifestDigest string `json:"manifest_digest"`
+	PrevRoot       string `json:"prev_root"`
+}
+
+// Checkpoint is the signed head of the transparency log.
+type Checkpoint struct {
+	TreeSize  int    `json:"tree_size"`
+	RootHash  string `json:"root_hash"`
+	Signature string `json:"signature"`
+}
+
+func logPath(dir string) string        { return filepath.Join(dir, "log.jsonl") }
+func checkpointPath(dir string) string { return filepath.Join(dir, "checkpoint") }
+
+// AppendToLog appends a manifest digest to the transparency log under dir,
+// recomputes the log's Merkle root over every leaf appended so far, signs
+// the new checkpoint with key, and persists both the entry and checkpoint.
+func AppendToLog(dir, manifestDigest string, key ed25519.PrivateKey) (*Checkpoint, error) {
+	entries, err := readLogEntries(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	prevRoot := ""
+	if cp, err := readCheckpoint(dir); err == nil {
+		prevRoot = cp.RootHash
+	}
+
+	entry := LogEntry{
+		Timestamp:      time.Now().UTC().Format(time.RFC3339),
+		ManifestDigest: manifestDigest,
+		PrevRoot:       prevRoot,
+	}
+	entries = append(entries, entry)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating transparency dir: %w", err)
+	}
+
+	f, err := os.OpenFile(logPath(dir), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("opening transparency log: %w", err)
+	}
+	defer f.Close()
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return nil, fmt.Errorf("appending log entry: %w", err)
+	}
+
+	root, err := logRoot(entries)
+	if err != nil {
+		return nil, err
+	}
+	rootHex := hex.EncodeToString(root[:])
+
+	checkpoint := &Checkpoint{
+		TreeSize:  len(entries),
+		RootHash:  rootHex,
+		Signature: hex.EncodeToString(ed25519.Sign(key, root[:])),
+	}
+
+	data, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(checkpointPath(dir), data, 0644); err != nil {
+		return nil, fmt.Errorf("writing checkpoint: %w", err)
+	}
+
+	return checkpoint, nil
+}
+
+// readLogEntries loads every entry currently in the transparency log.
+func readLogEntries(dir string) ([]LogEntry, error) {
+	f, err := os.Open(logPath(dir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening transparency log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []LogEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry LogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("parsing log entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+func readCheckpoint(dir string) (*Checkpoint, error) {
+	data, err := os.ReadFile(checkpointPath(dir))
+	if err != nil {
+		return nil, err
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("parsing checkpoint: %w", err)
+	}
+	return &cp, nil
+}
+
+// logRoot computes the Merkle root over every logged manifest digest.
+func logRoot(entries []LogEntry) ([32]byte, error) {
+	leaves := make([][32]byte, len(entries))
+	for i, e := range entries {
+		digest, err := hex.DecodeString(e.ManifestDigest)
+		if err != nil {
+			return [32]byte{}, fmt.Errorf("decoding manifest digest: %w", err)
+		}
+		leaves[i] = leafHash(digest)
+	}
+	return merkleRoot(leaves), nil
+}
+
+// VerifyLogContains checks that manifestDigest is present in the
+// transparency log under dir and that the current checkpoint is validly
+// signed by pub and matches the recomputed log root.
+func VerifyLogContains(dir, manifestDigest string, pub ed25519.PublicKey) error {
+	entries, err := readLogEntries(dir)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, e := range entries {
+		if e.ManifestDigest == manifestDigest {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("manifest digest %s not present in transparency log", manifestDigest)
+	}
+
+	cp, err := readCheckpoint(dir)
+	if err != nil {
+		return fmt.Errorf("reading checkpoint: %w", err)
+	}
+
+	root, err := logRoot(entries)
+	if err != nil {
+		return err
+	}
+	if hex.EncodeToString(root[:]) != cp.RootHash {
+		return fmt.Errorf("checkpoint root does not match recomputed log root")
+	}
+
+	sig, err := hex.DecodeString(cp.Signature)
+	if err != nil {
+		return fmt.Errorf("decoding checkpoint signature: %w", err)
+	}
+	if !ed25519.Verify(pub, root[:], sig) {
+		return fmt.Errorf("checkpoint signature verification failed")
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+// Package server implements the local development server: it serves the
+// built site over HTTP, rebuilds on change, and optionally injects a
+// live-reload script. It is never used by the production build pipeline
+// (cmd/main.go), so the "no JavaScript in shipped output" invariant is
+// enforced simply by this package not being part of that path.
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"secureblog/internal/builder"
+	"secureblog/internal/security"
+)
+
+// devHeader is the request header that gates live-reload script injection
+// in ServeOutput. Dev's own mux sets it on every request it forwards to
+// ServeOutput; the raw debug route does not, so it always returns
+// byte-identical production output. This keeps injection an explicit,
+// inspectable opt-in at the handler boundary rather than something that
+// could silently leak into a production server sharing this code.
+const devHeader = "X-Secureblog-Dev"
+
+// Config configures Dev.
+type Config struct {
+	Builder     *builder.Builder
+	OutputDir   string
+	ContentDir  string
+	TemplateDir string
+	StaticDir   string // "static", skipped if it doesn't exist
+	Addr        string // default ":8000"
+	TLS         bool   // serve HTTPS with a locally generated cert
+	NoInject    bool   // never inject the reload script; byte-identical to prod
+}
+
+// Dev serves cfg.OutputDir over HTTP, applying the full security header set
+// from security.GenerateHeaders, watches ContentDir/TemplateDir/StaticDir
+// for changes, rebuilds incrementally, and (unless NoInject) notifies open
+// browser tabs to reload over Server-Sent Events. It blocks until the
+// server exits.
+func Dev(cfg Config) error {
+	if cfg.Addr == "" {
+		cfg.Addr = ":8000"
+	}
+	if cfg.StaticDir == "" {
+		cfg.StaticDir = "static"
+	}
+
+	broker := newReloadBroker()
+
+	watcher, err := newWatcher(cfg, broker)
+	if err != nil {
+		return fmt.Errorf("starting file watcher: %w", err)
+	}
+	defer watcher.Close()
+	go watcher.run()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/__dev/events", broker.serveSSE)
+
+	fileServer := http.FileServer(http.Dir(cfg.OutputDir))
+
+	// Normal browsing: injected unless the operator asked for byte-identical
+	// output. /__dev/raw/ always bypasses injection, for comparing dev
+	// output against a production build.
+	root := withDevHeader(fileServer, cfg.NoInject)
+	if os.Getenv("SECUREBLOG_PRIVATE") == "1" {
+		root = withPrivateMode(root, privateCookieKeys(), privateSessionStore())
+		log.Println("secureblog dev server: SECUREBLOG_PRIVATE=1, gating all output behind an admin session")
+	}
+	mux.Handle("/", withSecurityHeaders(root))
+	mux.Handle("/__dev/raw/", withSecurityHeaders(http.StripPrefix("/__dev/raw", fileServer)))
+
+	log.Printf("secureblog dev server: serving %s on %s (tls=%v, inject=%v)",
+		cfg.OutputDir, cfg.Addr, cfg.TLS, !cfg.NoInject)
+
+	if cfg.TLS {
+		certFile, keyFile, err := devCertPaths(cfg.OutputDir)
+		if err != nil {
+			return fmt.Errorf("preparing dev TLS cert: %w", err)
+		}
+		return http.ListenAndServeTLS(cfg.Addr, certFile, keyFile, mux)
+	}
+	return http.ListenAndServe(cfg.Addr, mux)
+}
+
+// withDevHeader marks every request as eligible for reload-script injection
+// by ServeOutput, unless noInject is set.
+func withDevHeader(next http.Handler, noInject bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !noInject {
+			r.Header.Set(devHeader, "1")
+		}
+		ServeOutput(w, r, next)
+	})
+}
+
+// withSecurityHeaders applies the same maximum-security header set the
+// production build writes to _headers, so CSP/HSTS/etc. behave identically
+// under the dev server.
+func withSecurityHeaders(next http.Handler) http.Handler {
+	headers := security.SecurityHeaders()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for k, v := range headers {
+			w.Header().Set(k, v)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rebuild triggers an incremental build, used by the watcher after a
+// debounced batch of filesystem events.
+func rebuild(ctx context.Context, cfg Config) error {
+	return cfg.Builder.BuildIncremental(ctx, false)
+}
+
+// watchDirs returns the directories Dev watches for changes, skipping any
+// that don't exist (e.g. a site with no static assets).
+func watchDirs(cfg Config) []string {
+	var dirs []string
+	for _, d := range []string{cfg.ContentDir, cfg.TemplateDir, cfg.StaticDir} {
+		if d == "" {
+			continue
+		}
+		dirs = append(dirs, d)
+	}
+	return dirs
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/securecookie"
+
+	"secureblog/internal/session"
+)
+
+// privateCookieKeys loads the session cookie keys cmd/admin-server
+// persists (provisioning them if no admin has ever logged in yet), so this
+// process can decode a "session" cookie that one issued. A failure here
+// means every request is rejected rather than left open: see withPrivateMode.
+func privateCookieKeys() *securecookie.SecureCookie {
+	hashKey, blockKey, err := session.LoadOrCreateCookieKeys()
+	if err != nil {
+		log.Printf("secureblog dev server: SECUREBLOG_PRIVATE could not load session cookie keys: %v", err)
+		return nil
+	}
+	return securecookie.New(hashKey, blockKey)
+}
+
+// privateSessionTimeout mirrors cmd/admin-server's sessionTimeout; a
+// session this stale is treated the same way here as it is there.
+const privateSessionTimeout = 30 * time.Minute
+
+// privateSessionStore opens the same revocation/token-version store
+// cmd/admin-server maintains, read-only: admin-server may already be
+// running with its own long-held writable handle on the file, so opening
+// writable here would contend with (or time out against) that lock. A
+// failure here (most commonly: no admin has ever logged in, so the file
+// doesn't exist yet) means every request is rejected, the same fail-closed
+// behavior privateCookieKeys has.
+func privateSessionStore() *session.Store {
+	store, err := session.OpenStoreReadOnly()
+	if err != nil {
+		log.Printf("secureblog dev server: SECUREBLOG_PRIVATE could not open the session store: %v", err)
+		return nil
+	}
+	return store
+}
+
+// privateModeExempt lists the exact paths that stay reachable without a
+// session even when SECUREBLOG_PRIVATE=1: the ACME/well-known namespace (so
+// certificate issuance and federation discovery keep working), the login
+// page itself (served by cmd/admin-server, typically reverse-proxied in
+// front of this one in a private deployment), and robots.txt (whose body
+// withPrivateMode swaps for a blanket disallow instead of hiding it).
+func privateModeExempt(path string) bool {
+	if path == "/login" || path == "/robots.txt" {
+		return true
+	}
+	return strings.HasPrefix(path, "/.well-known/")
+}
+
+// privateRobotsBody is served in place of the real robots.txt while private
+// mode is active, so crawlers are told to stay out instead of being shown
+// (or blocked from) the site's normal crawl directives.
+const privateRobotsBody = "User-agent: *\nDisallow: /\n"
+
+// withPrivateMode gates next behind a valid, non-revoked admin Session when
+// SECUREBLOG_PRIVATE=1 is set, for every path except privateModeExempt.
+// Feed paths (feed.xml, atom.xml, photos.atom) additionally accept a
+// ?token= query parameter validated against session.ValidateFeedToken, so a
+// feed reader can keep polling without a browser session. cookieKeys may be
+// nil if the admin-server cookie keys haven't been provisioned yet, and
+// store may be nil if its session store hasn't either (nothing has ever
+// logged in); in either case every request is rejected, since there is no
+// session that could possibly be valid.
+func withPrivateMode(next http.Handler, cookieKeys *securecookie.SecureCookie, store *session.Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if privateModeExempt(r.URL.Path) {
+			if r.URL.Path == "/robots.txt" {
+				w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+				w.Write([]byte(privateRobotsBody))
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if feed := feedNameForPath(r.URL.Path); feed != "" {
+			if _, ok := session.ValidateFeedToken(r.URL.Query().Get("token"), feed); ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		if cookieKeys == nil || store == nil || !hasValidSession(r, cookieKeys, store) {
+			http.Error(w, "This site is private.", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// feedNameForPath maps a feed's output path to the "feed" name
+// IssueFeedToken/ValidateFeedToken key tokens under, or "" if path isn't a
+// feed.
+func feedNameForPath(path string) string {
+	switch path {
+	case "/feed.xml":
+		return "rss"
+	case "/atom.xml", "/photos.atom":
+		return "atom"
+	default:
+		return ""
+	}
+}
+
+// hasValidSession reports whether r carries a "session" cookie, encoded
+// with cookieKeys, for a session that is both unexpired and past its TOTP
+// challenge -- and that cmd/admin-server's store doesn't consider revoked
+// (an explicit logout, "revoke all sessions", or a global token-version
+// bump), matching the checks cmd/admin-server's own authenticatedSession
+// applies to the same cookie.
+func hasValidSession(r *http.Request, cookieKeys *securecookie.SecureCookie, store *session.Store) bool {
+	cookie, err := r.Cookie("session")
+	if err != nil {
+		return false
+	}
+
+	value := make(map[string]string)
+	if err := cookieKeys.Decode("session", cookie.Value, &value); err != nil {
+		return false
+	}
+
+	sess, err := session.FromCookieValues(value)
+	if err != nil {
+		return false
+	}
+
+	if !sess.TwoFAVerified.IsVerified() || !session.IsSessionValid(sess, privateSessionTimeout) {
+		return false
+	}
+
+	if revoked, err := store.IsRevoked(sess.SessionID); err != nil || revoked {
+		return false
+	}
+
+	currentTokenVersion, err := store.TokenVersion()
+	if err != nil || sess.TokenVersion != currentTokenVersion {
+		return false
+	}
+
+	return true
+}
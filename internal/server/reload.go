@@ -0,0 +1,158 @@
+package server
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// reloadScriptTag renders the live-reload <script>, appended to HTML
+// responses when injection is active. It only ever ships from this
+// dev-only package, never from the production build output. nonce must
+// match the 'nonce-<value>' source the response's CSP is widened with
+// (see widenCSPForNonce) -- the production CSP this package otherwise
+// reuses has no script-src allowance at all, so an un-nonced inline
+// script would simply be dropped by the browser.
+func reloadScriptTag(nonce string) string {
+	return fmt.Sprintf(`<script nonce="%s">new EventSource("/__dev/events").onmessage=()=>location.reload();</script>`, nonce)
+}
+
+// generateNonce returns a fresh base64 CSP nonce, unique per injected
+// response so a stolen nonce from one response can't be replayed to justify
+// a script in another.
+func generateNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating CSP nonce: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// reloadBroker fans out a "rebuild happened" event to every open browser
+// tab's Server-Sent Events connection.
+type reloadBroker struct {
+	mu      sync.Mutex
+	clients map[chan struct{}]struct{}
+}
+
+func newReloadBroker() *reloadBroker {
+	return &reloadBroker{clients: make(map[chan struct{}]struct{})}
+}
+
+// broadcast wakes every subscribed client so it reloads the page.
+func (b *reloadBroker) broadcast() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.clients {
+		select {
+		case ch <- struct{}{}:
+		default: // client hasn't drained the last event yet; it'll still reload
+		}
+	}
+}
+
+// serveSSE handles the /__dev/events endpoint: it streams one "reload"
+// message per broadcast until the client disconnects.
+func (b *reloadBroker) serveSSE(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	ch := make(chan struct{}, 1)
+	b.mu.Lock()
+	b.clients[ch] = struct{}{}
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		delete(b.clients, ch)
+		b.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ch:
+			w.Write([]byte("event: reload\ndata: ok\n\n"))
+			flusher.Flush()
+		}
+	}
+}
+
+// ServeOutput runs next and, if the request carries devHeader (set by Dev's
+// own mux, never by the production build), injects the reload script into
+// HTML responses just before </body>, under a fresh per-response CSP nonce.
+// Non-HTML responses and requests without the header pass through
+// untouched.
+func ServeOutput(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	if r.Header.Get(devHeader) != "1" {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	rec := httptest.NewRecorder()
+	next.ServeHTTP(rec, r)
+
+	for k, v := range rec.Header() {
+		w.Header()[k] = v
+	}
+
+	body := rec.Body.Bytes()
+	if isHTML(rec.Header().Get("Content-Type")) {
+		nonce, err := generateNonce()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		body = injectBeforeBodyClose(body, []byte(reloadScriptTag(nonce)))
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		widenCSPForNonce(w.Header(), nonce)
+	}
+
+	w.WriteHeader(rec.Code)
+	w.Write(body)
+}
+
+// widenCSPForNonce appends a script-src allowing only the given nonce to
+// headers' existing Content-Security-Policy, so the just-injected reload
+// script is the sole inline script the browser will execute -- everything
+// else stays governed by the production policy's default-src 'none'.
+func widenCSPForNonce(headers http.Header, nonce string) {
+	csp := headers.Get("Content-Security-Policy")
+	if csp == "" {
+		return
+	}
+	headers.Set("Content-Security-Policy", csp+"; script-src 'nonce-"+nonce+"'")
+}
+
+func isHTML(contentType string) bool {
+	return strings.HasPrefix(contentType, "text/html")
+}
+
+// injectBeforeBodyClose inserts script right before the last "</body>" in
+// body, or appends it at the end if there is no such tag.
+func injectBeforeBodyClose(body, script []byte) []byte {
+	idx := bytes.LastIndex(body, []byte("</body>"))
+	if idx == -1 {
+		return append(body, script...)
+	}
+	out := make([]byte, 0, len(body)+len(script))
+	out = append(out, body[:idx]...)
+	out = append(out, script...)
+	out = append(out, body[idx:]...)
+	return out
+}
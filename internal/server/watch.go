@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// debounce coalesces a burst of filesystem events (e.g. an editor's
+// save-via-rename) into a single rebuild.
+const debounce = 150 * time.Millisecond
+
+// devWatcher rebuilds the site and notifies broker whenever a watched
+// directory changes.
+type devWatcher struct {
+	fs     *fsnotify.Watcher
+	cfg    Config
+	broker *reloadBroker
+}
+
+// newWatcher creates a devWatcher over every directory in watchDirs(cfg)
+// (recursively, since fsnotify doesn't watch subtrees on its own).
+func newWatcher(cfg Config, broker *reloadBroker) (*devWatcher, error) {
+	fs, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dir := range watchDirs(cfg) {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || !info.IsDir() {
+				return err
+			}
+			return fs.Add(path)
+		})
+		if err != nil {
+			fs.Close()
+			return nil, err
+		}
+	}
+
+	return &devWatcher{fs: fs, cfg: cfg, broker: broker}, nil
+}
+
+func (w *devWatcher) Close() error {
+	return w.fs.Close()
+}
+
+// run blocks, rebuilding (debounced) on every filesystem event and
+// notifying the browser once the rebuild succeeds.
+func (w *devWatcher) run() {
+	var timer *time.Timer
+	for {
+		select {
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) == 0 {
+				continue
+			}
+			if timer == nil {
+				timer = time.AfterFunc(debounce, w.rebuildAndNotify)
+			} else {
+				timer.Reset(debounce)
+			}
+		case err, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("secureblog dev: watcher error: %v", err)
+		}
+	}
+}
+
+func (w *devWatcher) rebuildAndNotify() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := rebuild(ctx, w.cfg); err != nil {
+		log.Printf("secureblog dev: rebuild failed: %v", err)
+		return
+	}
+	w.broker.broadcast()
+}
@@ -0,0 +1,130 @@
+package session
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FeedToken lets a feed reader poll a feed gated by SECUREBLOG_PRIVATE
+// without holding a browser session cookie: it's handed out once (via the
+// "secureblog admin token issue" CLI) and presented on every request as
+// ?token=... against the feed path it was issued for.
+type FeedToken struct {
+	Token    string `json:"token"`
+	Username string `json:"username"`
+	Feed     string `json:"feed"` // e.g. "rss", "atom"
+}
+
+// feedTokensPath returns the path feed tokens are persisted to,
+// ~/.secureblog/feed-tokens.json, outside the repo/build tree -- the same
+// convention cmd/admin-server uses for its CSRF secret and backup codes.
+func feedTokensPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("resolving home directory: %w", err)
+	}
+	return filepath.Join(home, ".secureblog", "feed-tokens.json"), nil
+}
+
+func loadFeedTokens() ([]FeedToken, error) {
+	path, err := feedTokensPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	var tokens []FeedToken
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return tokens, nil
+}
+
+func saveFeedTokens(tokens []FeedToken) error {
+	path, err := feedTokensPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Dir(path), err)
+	}
+
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// IssueFeedToken mints a fresh opaque token for username's feed, persists
+// it, and returns it. Issuing again for the same (username, feed) pair
+// replaces the previous token, revoking it.
+func IssueFeedToken(username, feed string) (string, error) {
+	tokens, err := loadFeedTokens()
+	if err != nil {
+		return "", err
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("generating feed token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	filtered := tokens[:0]
+	for _, t := range tokens {
+		if t.Username != username || t.Feed != feed {
+			filtered = append(filtered, t)
+		}
+	}
+	filtered = append(filtered, FeedToken{Token: token, Username: username, Feed: feed})
+
+	if err := saveFeedTokens(filtered); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ValidateFeedToken reports whether token grants access to feed, and if so,
+// which user it was issued to.
+func ValidateFeedToken(token, feed string) (username string, ok bool) {
+	tokens, err := loadFeedTokens()
+	if err != nil {
+		return "", false
+	}
+
+	for _, t := range tokens {
+		if t.Feed == feed && subtle.ConstantTimeCompare([]byte(t.Token), []byte(token)) == 1 {
+			return t.Username, true
+		}
+	}
+	return "", false
+}
+
+// RevokeFeedToken removes token so it no longer grants access to any feed.
+func RevokeFeedToken(token string) error {
+	tokens, err := loadFeedTokens()
+	if err != nil {
+		return err
+	}
+
+	filtered := tokens[:0]
+	for _, t := range tokens {
+		if subtle.ConstantTimeCompare([]byte(t.Token), []byte(token)) != 1 {
+			filtered = append(filtered, t)
+		}
+	}
+	return saveFeedTokens(filtered)
+}
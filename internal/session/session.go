@@ -0,0 +1,221 @@
+// Package session holds the admin login session type and the helpers that
+// validate it, shared between cmd/admin-server (which issues sessions) and
+// any other process that needs to recognize one -- most notably the
+// SECUREBLOG_PRIVATE gate in internal/server. Neither of those can import
+// the other (cmd/admin-server is package main), so this is the common
+// ground they both depend on instead.
+package session
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/securecookie"
+)
+
+// TwoFactorMethod identifies which second-factor mechanism satisfied a
+// login's 2FA challenge -- TOTP, a WebAuthn credential, or a backup code --
+// so a high-risk endpoint can require the phishing-resistant one
+// specifically rather than accepting any of them. The zero value means no
+// factor has been verified yet (used only while a login is still pending
+// its challenge); TwoFactorNone means 2FA isn't enabled at all, so the
+// challenge was trivially satisfied.
+type TwoFactorMethod string
+
+const (
+	TwoFactorNone       TwoFactorMethod = "none"
+	TwoFactorTOTP       TwoFactorMethod = "totp"
+	TwoFactorWebAuthn   TwoFactorMethod = "webauthn"
+	TwoFactorBackupCode TwoFactorMethod = "backup_code"
+)
+
+// IsVerified reports whether m represents a completed 2FA challenge (or the
+// trivial case where none was required).
+func (m TwoFactorMethod) IsVerified() bool {
+	return m != ""
+}
+
+// IsPhishingResistant reports whether m can't be replayed against a
+// phishing origin. Only WebAuthn binds the assertion to the origin that
+// requested it; a TOTP code or backup code is a bearer secret an attacker
+// who phished the admin can relay themselves.
+func (m TwoFactorMethod) IsPhishingResistant() bool {
+	return m == TwoFactorWebAuthn
+}
+
+// Session is the data encoded into the encrypted "session" cookie issued by
+// cmd/admin-server on successful login. There is no server-side session
+// table beyond a revocation list keyed by SessionID -- the session itself
+// lives entirely in the cookie.
+type Session struct {
+	SessionID     string          `json:"session_id"`
+	Username      string          `json:"username"`
+	LoginTime     time.Time       `json:"login_time"`
+	LastActive    time.Time       `json:"last_active"`
+	IPAddress     string          `json:"ip_address"`
+	UserAgent     string          `json:"user_agent"`
+	TwoFAVerified TwoFactorMethod `json:"two_fa_verified"`
+	TokenVersion  int64           `json:"token_version"`
+}
+
+// IsSessionValid checks if session is still valid (not expired).
+func IsSessionValid(session Session, timeout time.Duration) bool {
+	// Check if session has timed out
+	if time.Since(session.LastActive) > timeout {
+		return false
+	}
+
+	// Check if login is too old (max 24 hours regardless of activity)
+	if time.Since(session.LoginTime) > 24*time.Hour {
+		return false
+	}
+
+	return true
+}
+
+// UpdateSessionActivity updates the last active time.
+func UpdateSessionActivity(session *Session) {
+	session.LastActive = time.Now()
+}
+
+// GetClientIP extracts real client IP (handling proxies).
+func GetClientIP(r *http.Request) string {
+	// For localhost, this should always be 127.0.0.1
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		// Take the first IP in the chain
+		ips := strings.Split(forwarded, ",")
+		return strings.TrimSpace(ips[0])
+	}
+
+	if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
+		return realIP
+	}
+
+	// Split host:port to get just the IP
+	ip := strings.Split(r.RemoteAddr, ":")[0]
+	return ip
+}
+
+// ValidateClientIP ensures connection is from localhost only. This backs
+// cmd/admin-server's IP restriction, which assumes the admin UI is only
+// ever reached through a loopback-bound listener or an SSH tunnel -- it is
+// NOT appropriate for gating ordinary site visitors (see the
+// SECUREBLOG_PRIVATE middleware in internal/server, which deliberately
+// does not call this).
+func ValidateClientIP(ip string) bool {
+	allowedIPs := []string{"127.0.0.1", "::1", "localhost"}
+
+	for _, allowed := range allowedIPs {
+		if ip == allowed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cookieKeysLength is securecookie's recommended hash-key and block-key
+// sizes, in that order.
+var cookieKeysLength = [2]int{64, 32}
+
+// LoadOrCreateCookieKeys returns the persisted securecookie hash and block
+// keys used to encode/decode the "session" cookie, generating and saving a
+// fresh pair on first run. Persisting them (rather than the ephemeral
+// securecookie.GenerateRandomKey cmd/admin-server used to call per process
+// start) is what lets a second process -- the SECUREBLOG_PRIVATE middleware
+// -- decode a cookie admin-server issued, and what keeps admin-server's own
+// sessions surviving a restart.
+func LoadOrCreateCookieKeys() (hashKey, blockKey []byte, err error) {
+	path, err := cookieKeysPath()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		hashKey, blockKey, perr := parseCookieKeys(data)
+		if perr == nil {
+			return hashKey, blockKey, nil
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	hashKey = securecookie.GenerateRandomKey(cookieKeysLength[0])
+	blockKey = securecookie.GenerateRandomKey(cookieKeysLength[1])
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, nil, fmt.Errorf("creating %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, encodeCookieKeys(hashKey, blockKey), 0600); err != nil {
+		return nil, nil, fmt.Errorf("writing %s: %w", path, err)
+	}
+	return hashKey, blockKey, nil
+}
+
+func cookieKeysPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("resolving home directory: %w", err)
+	}
+	return filepath.Join(home, ".secureblog", "session-cookie-keys"), nil
+}
+
+// encodeCookieKeys/parseCookieKeys store the two keys as
+// base64(hashKey)\nbase64(blockKey), the simplest format that survives a
+// round trip without ambiguity.
+func encodeCookieKeys(hashKey, blockKey []byte) []byte {
+	return []byte(base64.StdEncoding.EncodeToString(hashKey) + "\n" + base64.StdEncoding.EncodeToString(blockKey) + "\n")
+}
+
+func parseCookieKeys(data []byte) (hashKey, blockKey []byte, err error) {
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		return nil, nil, fmt.Errorf("malformed session cookie key file")
+	}
+	hashKey, err = base64.StdEncoding.DecodeString(lines[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("decoding hash key: %w", err)
+	}
+	blockKey, err = base64.StdEncoding.DecodeString(lines[1])
+	if err != nil {
+		return nil, nil, fmt.Errorf("decoding block key: %w", err)
+	}
+	return hashKey, blockKey, nil
+}
+
+// FromCookieValues reassembles a Session from the string map
+// cmd/admin-server's setSessionCookie encodes into the cookie. It is the
+// shared decode step; callers still need to securecookie.Decode the raw
+// cookie value into this map themselves since only they hold the keys.
+func FromCookieValues(value map[string]string) (Session, error) {
+	loginTime, err := time.Parse(time.RFC3339, value["loginTime"])
+	if err != nil {
+		return Session{}, fmt.Errorf("parsing loginTime: %w", err)
+	}
+	lastActive, err := time.Parse(time.RFC3339, value["lastActive"])
+	if err != nil {
+		return Session{}, fmt.Errorf("parsing lastActive: %w", err)
+	}
+	tokenVersion, err := strconv.ParseInt(value["tokenVersion"], 10, 64)
+	if err != nil {
+		return Session{}, fmt.Errorf("parsing tokenVersion: %w", err)
+	}
+
+	return Session{
+		SessionID:     value["sessionID"],
+		Username:      value["username"],
+		LoginTime:     loginTime,
+		LastActive:    lastActive,
+		IPAddress:     value["ipAddress"],
+		UserAgent:     value["userAgent"],
+		TwoFAVerified: TwoFactorMethod(value["twoFAVerified"]),
+		TokenVersion:  tokenVersion,
+	}, nil
+}
@@ -0,0 +1,111 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// withTempHome points os.UserHomeDir's result at a fresh temp dir for the
+// duration of the test, so LoadOrCreateCookieKeys/feed token persistence
+// don't touch the real ~/.secureblog.
+func withTempHome(t *testing.T) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home) // os.UserHomeDir on Windows
+}
+
+func TestIsSessionValid(t *testing.T) {
+	now := time.Now()
+
+	fresh := Session{LoginTime: now, LastActive: now}
+	if !IsSessionValid(fresh, 30*time.Minute) {
+		t.Errorf("IsSessionValid(fresh) = false, want true")
+	}
+
+	idle := Session{LoginTime: now, LastActive: now.Add(-time.Hour)}
+	if IsSessionValid(idle, 30*time.Minute) {
+		t.Errorf("IsSessionValid(idle past timeout) = true, want false")
+	}
+
+	stale := Session{LoginTime: now.Add(-25 * time.Hour), LastActive: now}
+	if IsSessionValid(stale, 30*time.Minute) {
+		t.Errorf("IsSessionValid(login > 24h old) = true, want false")
+	}
+}
+
+func TestLoadOrCreateCookieKeysPersists(t *testing.T) {
+	withTempHome(t)
+
+	hashKey1, blockKey1, err := LoadOrCreateCookieKeys()
+	if err != nil {
+		t.Fatalf("LoadOrCreateCookieKeys() error = %v", err)
+	}
+
+	hashKey2, blockKey2, err := LoadOrCreateCookieKeys()
+	if err != nil {
+		t.Fatalf("LoadOrCreateCookieKeys() second call error = %v", err)
+	}
+
+	if string(hashKey1) != string(hashKey2) || string(blockKey1) != string(blockKey2) {
+		t.Errorf("LoadOrCreateCookieKeys() returned different keys on a second call, want the persisted pair")
+	}
+}
+
+func TestFeedTokenIssueValidateRevoke(t *testing.T) {
+	withTempHome(t)
+
+	token, err := IssueFeedToken("alice", "rss")
+	if err != nil {
+		t.Fatalf("IssueFeedToken() error = %v", err)
+	}
+
+	if username, ok := ValidateFeedToken(token, "rss"); !ok || username != "alice" {
+		t.Errorf("ValidateFeedToken() = (%q, %v), want (\"alice\", true)", username, ok)
+	}
+	if _, ok := ValidateFeedToken(token, "atom"); ok {
+		t.Errorf("ValidateFeedToken() succeeded for the wrong feed")
+	}
+
+	if err := RevokeFeedToken(token); err != nil {
+		t.Fatalf("RevokeFeedToken() error = %v", err)
+	}
+	if _, ok := ValidateFeedToken(token, "rss"); ok {
+		t.Errorf("ValidateFeedToken() succeeded after RevokeFeedToken")
+	}
+}
+
+func TestIssueFeedTokenReplacesPrevious(t *testing.T) {
+	withTempHome(t)
+
+	first, err := IssueFeedToken("bob", "atom")
+	if err != nil {
+		t.Fatalf("IssueFeedToken() error = %v", err)
+	}
+	second, err := IssueFeedToken("bob", "atom")
+	if err != nil {
+		t.Fatalf("IssueFeedToken() error = %v", err)
+	}
+
+	if _, ok := ValidateFeedToken(first, "atom"); ok {
+		t.Errorf("first token still valid after re-issuing for the same user/feed")
+	}
+	if _, ok := ValidateFeedToken(second, "atom"); !ok {
+		t.Errorf("second (current) token rejected")
+	}
+}
+
+func TestFeedTokensPathUnderHome(t *testing.T) {
+	withTempHome(t)
+
+	path, err := feedTokensPath()
+	if err != nil {
+		t.Fatalf("feedTokensPath() error = %v", err)
+	}
+	home, _ := os.UserHomeDir()
+	if filepath.Dir(path) != filepath.Join(home, ".secureblog") {
+		t.Errorf("feedTokensPath() = %q, want it under %s", path, filepath.Join(home, ".secureblog"))
+	}
+}
@@ -0,0 +1,192 @@
+// store.go - the only server-side state behind an otherwise stateless,
+// encrypted-cookie session: a small persisted revocation list (for explicit
+// logouts) and a global token version (for "kill all sessions"). Everything
+// else about a session -- who, when, from where -- lives entirely in the
+// encrypted cookie itself.
+//
+// This lives in internal/session rather than cmd/admin-server (which
+// issues and revokes sessions) so the SECUREBLOG_PRIVATE gate in
+// internal/server can check the same revocation list: a logout or "revoke
+// all sessions" there must also invalidate that cookie here, and
+// cmd/admin-server (package main) can't be imported.
+package session
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	revokedBucket = []byte("revoked")
+	metaBucket    = []byte("meta")
+	tokenVerKey   = []byte("tokenVersion")
+)
+
+// Store persists session revocations and the global token version to a
+// local bolt file, independent of the in-memory process lifetime.
+type Store struct {
+	db *bolt.DB
+}
+
+// storePath returns the path the session store is persisted to,
+// ~/.secureblog/sessions.db, outside the repo/build tree.
+func storePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("resolving home directory: %w", err)
+	}
+	return filepath.Join(home, ".secureblog", "sessions.db"), nil
+}
+
+// OpenStore opens (creating if necessary) the bolt file backing session
+// revocations and the global token version.
+func OpenStore() (*Store, error) {
+	path, err := storePath()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("creating %s: %w", filepath.Dir(path), err)
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("opening session store: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(revokedBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(metaBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("initializing session store buckets: %w", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// OpenStoreReadOnly opens the bolt file backing session revocations without
+// creating it, for a process (the SECUREBLOG_PRIVATE gate) that only ever
+// reads the revocation list cmd/admin-server maintains. No admin having
+// ever logged in yet (no file to open) is reported via the returned error,
+// the same way a missing cookie-keys file is handled by the caller.
+func OpenStoreReadOnly() (*Store, error) {
+	path, err := storePath()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("opening session store: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close releases the underlying bolt file.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// TokenVersion returns the current global token version. Every session
+// cookie embeds the version it was issued under; a mismatch means it was
+// issued before the last "kill all sessions" and is no longer valid.
+func (s *Store) TokenVersion() (int64, error) {
+	var version int64
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(metaBucket).Get(tokenVerKey)
+		if v != nil {
+			version = int64(binary.BigEndian.Uint64(v))
+		}
+		return nil
+	})
+	return version, err
+}
+
+// BumpTokenVersion increments and persists the global token version,
+// invalidating every cookie issued under an earlier version.
+func (s *Store) BumpTokenVersion() (int64, error) {
+	var version int64
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(metaBucket)
+		v := b.Get(tokenVerKey)
+		if v != nil {
+			version = int64(binary.BigEndian.Uint64(v))
+		}
+		version++
+
+		buf := make([]byte, 8)
+		binary.BigEndian.PutUint64(buf, uint64(version))
+		return b.Put(tokenVerKey, buf)
+	})
+	return version, err
+}
+
+// Revoke marks sessionID as revoked until expiresAt, used for explicit
+// logouts -- after expiresAt the session would have expired on its own, so
+// the entry is eligible for pruning.
+func (s *Store) Revoke(sessionID string, expiresAt time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		ts, err := expiresAt.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(revokedBucket).Put([]byte(sessionID), ts)
+	})
+}
+
+// IsRevoked reports whether sessionID is on the revocation list and hasn't
+// aged past its recorded expiry.
+func (s *Store) IsRevoked(sessionID string) (bool, error) {
+	var revoked bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(revokedBucket).Get([]byte(sessionID))
+		if v == nil {
+			return nil
+		}
+		var expiresAt time.Time
+		if err := expiresAt.UnmarshalBinary(v); err != nil {
+			// Can't parse the stored expiry: fail safe and still treat it
+			// as revoked until pruned.
+			revoked = true
+			return nil
+		}
+		revoked = time.Now().Before(expiresAt)
+		return nil
+	})
+	return revoked, err
+}
+
+// PruneExpired removes revocation entries whose expiry has passed, keeping
+// the bolt file from growing unbounded across many logouts.
+func (s *Store) PruneExpired() error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(revokedBucket)
+		var expiredKeys [][]byte
+		err := b.ForEach(func(k, v []byte) error {
+			var expiresAt time.Time
+			if err := expiresAt.UnmarshalBinary(v); err != nil || time.Now().After(expiresAt) {
+				expiredKeys = append(expiredKeys, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range expiredKeys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
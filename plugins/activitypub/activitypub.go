@@ -0,0 +1,283 @@
+// Package activitypub generates the static half of ActivityPub federation
+// for the blog: an actor document, a WebFinger responder, and a Create
+// activity per post, all as plain files an OutputPlugin can write alongside
+// atom.xml and sitemap.xml. The live half -- receiving Follow/Undo/Like
+// activities at an inbox and signing outbound deliveries to followers -- is
+// implemented in inbox.go/deliver.go as handlers and functions ready to
+// mount on whatever process actually serves the site; this package does not
+// start one of its own (see internal/server's doc comment: the only HTTP
+// server this repo runs today is the local dev preview).
+package activitypub
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"secureblog/internal/plugin"
+	"strings"
+	"time"
+)
+
+// Plugin generates ActivityPub actor/outbox/object documents and a
+// WebFinger response for a single-author blog.
+type Plugin struct {
+	config      map[string]interface{}
+	siteURL     string
+	username    string
+	displayName string
+	summary     string
+}
+
+// Actor is a minimal ActivityStreams Person actor document.
+type Actor struct {
+	Context           []string  `json:"@context"`
+	ID                string    `json:"id"`
+	Type              string    `json:"type"`
+	PreferredUsername string    `json:"preferredUsername"`
+	Name              string    `json:"name"`
+	Summary           string    `json:"summary,omitempty"`
+	Inbox             string    `json:"inbox"`
+	Outbox            string    `json:"outbox"`
+	Followers         string    `json:"followers"`
+	PublicKey         PublicKey `json:"publicKey"`
+}
+
+// PublicKey is an actor document's embedded publicKey object, per the
+// security/v1 context.
+type PublicKey struct {
+	ID           string `json:"id"`
+	Owner        string `json:"owner"`
+	PublicKeyPem string `json:"publicKeyPem"`
+}
+
+// Tag is an ActivityStreams hashtag tag on a Note/Article.
+type Tag struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// Note is the object of a post's Create activity.
+type Note struct {
+	Context      string   `json:"@context"`
+	ID           string   `json:"id"`
+	Type         string   `json:"type"`
+	Published    string   `json:"published"`
+	AttributedTo string   `json:"attributedTo"`
+	To           []string `json:"to"`
+	CC           []string `json:"cc,omitempty"`
+	Content      string   `json:"content"`
+	URL          string   `json:"url"`
+	Tag          []Tag    `json:"tag,omitempty"`
+}
+
+// CreateActivity wraps a Note/Article the way a post is announced in the
+// outbox and delivered to followers.
+type CreateActivity struct {
+	Context   string   `json:"@context"`
+	ID        string   `json:"id"`
+	Type      string   `json:"type"`
+	Actor     string   `json:"actor"`
+	Published string   `json:"published"`
+	To        []string `json:"to"`
+	CC        []string `json:"cc,omitempty"`
+	Object    Note     `json:"object"`
+}
+
+// OrderedCollection is the outbox: every post's Create activity, newest
+// first.
+type OrderedCollection struct {
+	Context      string           `json:"@context"`
+	ID           string           `json:"id"`
+	Type         string           `json:"type"`
+	TotalItems   int              `json:"totalItems"`
+	OrderedItems []CreateActivity `json:"orderedItems"`
+}
+
+// WebFinger is the JRD served at /.well-known/webfinger.
+type WebFinger struct {
+	Subject string          `json:"subject"`
+	Links   []WebFingerLink `json:"links"`
+}
+
+// WebFingerLink points a WebFinger subject at its ActivityPub actor.
+type WebFingerLink struct {
+	Rel  string `json:"rel"`
+	Type string `json:"type"`
+	Href string `json:"href"`
+}
+
+const activityStreamsContext = "https://www.w3.org/ns/activitystreams"
+const securityContext = "https://w3id.org/security/v1"
+const publicAudience = activityStreamsContext + "#Public"
+
+// New returns an unconfigured activitypub output plugin; call Init before
+// Generate.
+func New() *Plugin {
+	return &Plugin{}
+}
+
+func (p *Plugin) Name() string {
+	return "activitypub"
+}
+
+func (p *Plugin) Version() string {
+	return "1.0.0"
+}
+
+// Init reads the site's public URL, actor username, display name, and bio
+// out of config (the same config.yaml block every other output plugin
+// reads its settings from). A missing siteURL leaves the actor unresolvable
+// to remote servers, so Generate refuses to run without one.
+func (p *Plugin) Init(config map[string]interface{}) error {
+	p.config = config
+	p.siteURL = strings.TrimRight(stringConfig(config, "url", "https://example.com"), "/")
+	p.username = stringConfig(config, "username", "blog")
+	p.displayName = stringConfig(config, "displayName", "Secure Blog")
+	p.summary = stringConfig(config, "summary", "")
+	return nil
+}
+
+// Priority runs after the feed plugins (50/51), since the outbox mirrors
+// the same post list they've already rendered.
+func (p *Plugin) Priority() int {
+	return 55
+}
+
+func stringConfig(config map[string]interface{}, key, fallback string) string {
+	if v, ok := config[key].(string); ok && v != "" {
+		return v
+	}
+	return fallback
+}
+
+// Generate writes actor.json, one Note document per post under
+// activitypub/, the aggregated outbox.json, and the static WebFinger
+// response.
+func (p *Plugin) Generate(posts []plugin.Post, outputDir string) error {
+	actorURL := p.siteURL + "/actor.json"
+	inboxURL := p.siteURL + "/inbox"
+	outboxURL := p.siteURL + "/outbox.json"
+	followersURL := p.siteURL + "/followers.json"
+	keyID := actorURL + "#main-key"
+
+	key, err := LoadOrGenerateKey(KeyPath)
+	if err != nil {
+		return err
+	}
+	pubPem, err := EncodePublicKeyPEM(&key.PublicKey)
+	if err != nil {
+		return err
+	}
+
+	actor := Actor{
+		Context:           []string{activityStreamsContext, securityContext},
+		ID:                actorURL,
+		Type:              "Person",
+		PreferredUsername: p.username,
+		Name:              p.displayName,
+		Summary:           p.summary,
+		Inbox:             inboxURL,
+		Outbox:            outboxURL,
+		Followers:         followersURL,
+		PublicKey: PublicKey{
+			ID:           keyID,
+			Owner:        actorURL,
+			PublicKeyPem: pubPem,
+		},
+	}
+	if err := writeJSON(filepath.Join(outputDir, "actor.json"), actor); err != nil {
+		return err
+	}
+
+	apDir := filepath.Join(outputDir, "activitypub")
+	if err := os.MkdirAll(apDir, 0755); err != nil {
+		return fmt.Errorf("creating %s: %w", apDir, err)
+	}
+
+	collection := OrderedCollection{
+		Context: activityStreamsContext,
+		ID:      outboxURL,
+		Type:    "OrderedCollection",
+	}
+	for _, post := range posts {
+		published, _ := parsePostTime(post.Date)
+		postURL := fmt.Sprintf("%s/%s.html", p.siteURL, post.Slug)
+		noteID := fmt.Sprintf("%s/activitypub/%s.json", p.siteURL, post.Slug)
+
+		note := Note{
+			Context:      activityStreamsContext,
+			ID:           noteID,
+			Type:         "Article",
+			Published:    published.Format(time.RFC3339),
+			AttributedTo: actorURL,
+			To:           []string{publicAudience},
+			CC:           []string{followersURL},
+			Content:      string(post.Content),
+			URL:          postURL,
+		}
+		for _, tag := range post.Tags {
+			note.Tag = append(note.Tag, Tag{Type: "Hashtag", Name: "#" + tag})
+		}
+		if err := writeJSON(filepath.Join(apDir, post.Slug+".json"), note); err != nil {
+			return err
+		}
+
+		collection.OrderedItems = append(collection.OrderedItems, CreateActivity{
+			Context:   activityStreamsContext,
+			ID:        noteID + "#create",
+			Type:      "Create",
+			Actor:     actorURL,
+			Published: note.Published,
+			To:        note.To,
+			CC:        note.CC,
+			Object:    note,
+		})
+	}
+	collection.TotalItems = len(collection.OrderedItems)
+	if err := writeJSON(filepath.Join(outputDir, "outbox.json"), collection); err != nil {
+		return err
+	}
+
+	return p.writeWebFinger(outputDir, actorURL)
+}
+
+// writeWebFinger writes a single static .well-known/webfinger response.
+// A real multi-account server varies this by the resource= query
+// parameter, but a static site has exactly one actor, so one file (served
+// regardless of the query string) is all WebFinger needs here.
+func (p *Plugin) writeWebFinger(outputDir, actorURL string) error {
+	host := strings.TrimPrefix(strings.TrimPrefix(p.siteURL, "https://"), "http://")
+	finger := WebFinger{
+		Subject: fmt.Sprintf("acct:%s@%s", p.username, host),
+		Links: []WebFingerLink{
+			{Rel: "self", Type: "application/activity+json", Href: actorURL},
+		},
+	}
+
+	dir := filepath.Join(outputDir, ".well-known")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating %s: %w", dir, err)
+	}
+	return writeJSON(filepath.Join(dir, "webfinger"), finger)
+}
+
+// parsePostTime parses a post's Date field, duplicating plugins/atom's
+// helper of the same name: each output plugin keeps its own copy rather
+// than sharing one across packages.
+func parsePostTime(date string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, date); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", date)
+}
+
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding %s: %w", path, err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+var _ plugin.OutputPlugin = (*Plugin)(nil)
@@ -0,0 +1,70 @@
+package activitypub
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"secureblog/internal/policy"
+	"time"
+)
+
+// Deliver signs activity and POSTs it to every follower's inbox, gated by
+// the same HTTP policy checks fetchActor uses. A delivery failure to one
+// follower doesn't stop delivery to the rest; every per-follower error is
+// collected and returned together so the caller (the publish step) can log
+// them without losing a site-wide retry to one unreachable server.
+func Deliver(pol *policy.Policy, store *FollowerStore, keyID string, priv *rsa.PrivateKey, activity interface{}) error {
+	followers, err := store.List()
+	if err != nil {
+		return fmt.Errorf("listing followers: %w", err)
+	}
+
+	body, err := json.Marshal(activity)
+	if err != nil {
+		return fmt.Errorf("encoding activity: %w", err)
+	}
+
+	var deliveryErrors []error
+	for _, f := range followers {
+		if err := deliverOne(pol, f.Inbox, keyID, priv, body); err != nil {
+			deliveryErrors = append(deliveryErrors, fmt.Errorf("delivering to %s: %w", f.Inbox, err))
+		}
+	}
+	if len(deliveryErrors) > 0 {
+		return fmt.Errorf("%d of %d deliveries failed: %w", len(deliveryErrors), len(followers), errors.Join(deliveryErrors...))
+	}
+	return nil
+}
+
+func deliverOne(pol *policy.Policy, inbox, keyID string, priv *rsa.PrivateKey, body []byte) error {
+	if err := pol.AllowHTTPMethod(http.MethodPost); err != nil {
+		return err
+	}
+	if err := pol.AllowHTTPURL(inbox); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, inbox, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("building request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/activity+json")
+	req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+
+	if err := SignRequest(req, keyID, priv, body); err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("remote inbox returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FollowersPath is where accepted followers are persisted between builds,
+// alongside the rest of this build's small JSON caches (see
+// plugins/atom's updatedCachePath).
+const FollowersPath = ".secureblog-cache/activitypub-followers.json"
+
+// Follower is one remote actor who has successfully Followed this blog.
+type Follower struct {
+	ActorID string `json:"actorId"`
+	Inbox   string `json:"inbox"`
+}
+
+// FollowerStore is a small JSON-file-backed set of followers, read and
+// rewritten in full on every change. The inbox handler calls Add/Remove as
+// Follow/Undo activities arrive; Generate's publish step calls List to fan
+// out new posts.
+type FollowerStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFollowerStore opens a follower store backed by the JSON file at path.
+func NewFollowerStore(path string) *FollowerStore {
+	return &FollowerStore{path: path}
+}
+
+func (s *FollowerStore) load() ([]Follower, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading follower store: %w", err)
+	}
+	var followers []Follower
+	if err := json.Unmarshal(data, &followers); err != nil {
+		return nil, fmt.Errorf("parsing follower store: %w", err)
+	}
+	return followers, nil
+}
+
+func (s *FollowerStore) save(followers []Follower) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("creating follower store directory: %w", err)
+	}
+	data, err := json.MarshalIndent(followers, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding follower store: %w", err)
+	}
+	return os.WriteFile(s.path, data, 0644)
+}
+
+// Add upserts a follower by ActorID, so a duplicate Follow from the same
+// actor doesn't create a second delivery target.
+func (s *FollowerStore) Add(actorID, inbox string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	followers, err := s.load()
+	if err != nil {
+		return err
+	}
+	for i, f := range followers {
+		if f.ActorID == actorID {
+			followers[i].Inbox = inbox
+			return s.save(followers)
+		}
+	}
+	return s.save(append(followers, Follower{ActorID: actorID, Inbox: inbox}))
+}
+
+// Remove deletes actorID from the store, if present.
+func (s *FollowerStore) Remove(actorID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	followers, err := s.load()
+	if err != nil {
+		return err
+	}
+	kept := followers[:0]
+	for _, f := range followers {
+		if f.ActorID != actorID {
+			kept = append(kept, f)
+		}
+	}
+	return s.save(kept)
+}
+
+// List returns every currently accepted follower.
+func (s *FollowerStore) List() ([]Follower, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
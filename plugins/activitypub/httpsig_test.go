@@ -0,0 +1,72 @@
+package activitypub
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerifyRequestRoundTrip(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating test key: %v", err)
+	}
+
+	body := []byte(`{"type":"Follow","actor":"https://remote.example/users/alice"}`)
+	req, err := http.NewRequest(http.MethodPost, "https://blog.example/inbox", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("building request: %v", err)
+	}
+	req.Host = "blog.example"
+	req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+
+	keyID := "https://remote.example/users/alice#main-key"
+	if err := SignRequest(req, keyID, priv, body); err != nil {
+		t.Fatalf("SignRequest() error = %v", err)
+	}
+
+	resolve := func(gotKeyID string) (*rsa.PublicKey, error) {
+		if gotKeyID != keyID {
+			t.Fatalf("resolvePublicKey called with %q, want %q", gotKeyID, keyID)
+		}
+		return &priv.PublicKey, nil
+	}
+
+	gotVerifiedKeyID, err := VerifyRequest(req, body, resolve)
+	if err != nil {
+		t.Fatalf("VerifyRequest() error = %v", err)
+	}
+	if gotVerifiedKeyID != keyID {
+		t.Errorf("VerifyRequest() keyID = %q, want %q", gotVerifiedKeyID, keyID)
+	}
+}
+
+func TestVerifyRequestRejectsTamperedBody(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating test key: %v", err)
+	}
+
+	body := []byte(`{"type":"Follow"}`)
+	req, err := http.NewRequest(http.MethodPost, "https://blog.example/inbox", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("building request: %v", err)
+	}
+	req.Host = "blog.example"
+	req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+
+	keyID := "https://remote.example/users/alice#main-key"
+	if err := SignRequest(req, keyID, priv, body); err != nil {
+		t.Fatalf("SignRequest() error = %v", err)
+	}
+
+	resolve := func(string) (*rsa.PublicKey, error) { return &priv.PublicKey, nil }
+
+	tampered := []byte(`{"type":"Undo"}`)
+	if _, err := VerifyRequest(req, tampered, resolve); err == nil {
+		t.Fatal("VerifyRequest() accepted a tampered body")
+	}
+}
@@ -0,0 +1,155 @@
+package activitypub
+
+import (
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"secureblog/internal/policy"
+)
+
+// inboxActivity is the subset of an incoming activity's fields the inbox
+// handler needs to route it; Object is left raw since Follow/Undo/Like
+// carry different shapes there.
+type inboxActivity struct {
+	Type   string          `json:"type"`
+	Actor  string          `json:"actor"`
+	Object json.RawMessage `json:"object"`
+}
+
+// InboxHandler verifies an incoming activity's HTTP Signature against its
+// actor's published key, then applies the Follow/Undo/Like/Create side
+// effects this blog supports. Anything else is accepted but ignored, per
+// ActivityPub's recommendation to 2xx activities a server doesn't act on
+// rather than reject them.
+//
+// Mounting this handler on a live server is a deployment decision outside
+// this package's scope (see the package doc comment); it's provided here,
+// fully implemented and independently testable, for whichever process ends
+// up serving the site to wire in.
+func InboxHandler(store *FollowerStore, pol *policy.Policy) http.HandlerFunc {
+	resolve := actorPublicKeyResolver(pol)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Could not read request body", http.StatusBadRequest)
+			return
+		}
+
+		keyID, err := VerifyRequest(r, body, resolve)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		var activity inboxActivity
+		if err := json.Unmarshal(body, &activity); err != nil {
+			http.Error(w, "Malformed activity", http.StatusBadRequest)
+			return
+		}
+
+		// A valid signature only proves who signed the request, not who the
+		// activity claims to be about -- without this check, any actor
+		// could sign a Follow/Undo with their own key but set "actor" to a
+		// victim's URI and silently (un)register that victim's inbox.
+		if actorURL, _, _ := splitKeyID(keyID); actorURL != activity.Actor {
+			http.Error(w, "Signature keyId does not match activity actor", http.StatusForbidden)
+			return
+		}
+
+		switch activity.Type {
+		case "Follow":
+			if err := store.Add(activity.Actor, actorInboxOrSelf(pol, activity.Actor)); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		case "Undo":
+			if err := store.Remove(activity.Actor); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		case "Like", "Create":
+			// Accepted, no local side effect: this blog doesn't surface
+			// remote likes or replies today.
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// actorInboxOrSelf fetches actorID's actor document to find its inbox URL,
+// falling back to actorID itself (some minimal implementations deliver
+// straight to the actor URI) if the fetch fails.
+func actorInboxOrSelf(pol *policy.Policy, actorID string) string {
+	actor, err := fetchActor(pol, actorID)
+	if err != nil || actor.Inbox == "" {
+		return actorID
+	}
+	return actor.Inbox
+}
+
+// actorPublicKeyResolver returns a resolvePublicKey func (see VerifyRequest)
+// that fetches the actor document naming keyID and decodes its
+// publicKey.publicKeyPem. keyID is expected in the conventional
+// "<actor URL>#main-key" form this package itself generates.
+func actorPublicKeyResolver(pol *policy.Policy) func(keyID string) (*rsa.PublicKey, error) {
+	return func(keyID string) (*rsa.PublicKey, error) {
+		actorURL, _, _ := splitKeyID(keyID)
+		actor, err := fetchActor(pol, actorURL)
+		if err != nil {
+			return nil, err
+		}
+		return decodePublicKeyPEM(actor.PublicKey.PublicKeyPem)
+	}
+}
+
+// splitKeyID separates a "<actor URL>#main-key"-style keyId into the actor
+// URL and its fragment.
+func splitKeyID(keyID string) (actorURL, fragment string, ok bool) {
+	for i := len(keyID) - 1; i >= 0; i-- {
+		if keyID[i] == '#' {
+			return keyID[:i], keyID[i+1:], true
+		}
+	}
+	return keyID, "", false
+}
+
+// fetchActor retrieves and parses the actor document at actorURL, gated by
+// the same HTTP policy checks internal/resources.Fetcher uses for every
+// other plugin-initiated remote fetch.
+func fetchActor(pol *policy.Policy, actorURL string) (*Actor, error) {
+	if err := pol.AllowHTTPMethod(http.MethodGet); err != nil {
+		return nil, err
+	}
+	if err := pol.AllowHTTPURL(actorURL); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, actorURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building actor request: %w", err)
+	}
+	req.Header.Set("Accept", "application/activity+json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching actor %s: %w", actorURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching actor %s: status %d", actorURL, resp.StatusCode)
+	}
+
+	var actor Actor
+	if err := json.NewDecoder(resp.Body).Decode(&actor); err != nil {
+		return nil, fmt.Errorf("parsing actor %s: %w", actorURL, err)
+	}
+	return &actor, nil
+}
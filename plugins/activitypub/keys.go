@@ -0,0 +1,66 @@
+package activitypub
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// KeyPath is the default location of the actor's RSA key pair, persisted
+// alongside the build's Ed25519 signing key under .transparency/ (see
+// internal/security.SigningKeyPath). HTTP Signatures require RSA, not
+// Ed25519, so this is a separate key rather than a reuse of the signing key.
+const KeyPath = ".transparency/activitypub.key"
+
+// rsaKeyBits is 2048, the minimum width other ActivityPub implementations
+// (Mastodon, etc.) expect an actor's publicKeyPem to be.
+const rsaKeyBits = 2048
+
+// LoadOrGenerateKey reads the PEM-encoded RSA private key at path,
+// generating and persisting a new one if none exists yet. This mirrors
+// internal/security.LoadOrGenerateSigningKey's generate-on-first-read
+// pattern, substituting PKCS#1 PEM for hex since RSA keys don't fit as
+// compactly as Ed25519's raw 64 bytes.
+func LoadOrGenerateKey(path string) (*rsa.PrivateKey, error) {
+	if data, err := os.ReadFile(path); err == nil {
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("parsing activitypub key %s: not a PEM file", path)
+		}
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing activitypub key %s: %w", path, err)
+		}
+		return key, nil
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	if err != nil {
+		return nil, fmt.Errorf("generating activitypub key: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("creating key directory: %w", err)
+	}
+	block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		return nil, fmt.Errorf("persisting activitypub key: %w", err)
+	}
+
+	return key, nil
+}
+
+// EncodePublicKeyPEM renders pub as the PKIX PEM string an actor document's
+// publicKey.publicKeyPem field expects.
+func EncodePublicKeyPEM(pub *rsa.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", fmt.Errorf("marshaling public key: %w", err)
+	}
+	block := &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	return string(pem.EncodeToMemory(block)), nil
+}
@@ -0,0 +1,253 @@
+// Package analytics generates a privacy-respecting stats page as a
+// PostBuild hook. Where the numbers come from is pluggable: Backend
+// abstracts over Cloudflare's GraphQL Analytics API, a local Caddy/Nginx
+// access-log parser, and a Plausible/Umami-compatible pull API, selected
+// by the "backend" config key. The plugin only knows how to ask a Backend
+// for a date range and render the result; it never talks to an API or a
+// log file directly.
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"path/filepath"
+	"secureblog/internal/plugin"
+	"time"
+)
+
+// AnalyticsData is the stats page's view model, populated by whichever
+// Backend is configured.
+type AnalyticsData struct {
+	PageViews      int64
+	UniqueVisitors int64
+	Bandwidth      int64
+	Threats        int64
+	CacheHitRate   float64
+	TopPages       []PageStats
+	Countries      map[string]int64
+	ReferrersTop   []ReferrerStats
+	StatusCodes    map[string]int64
+	P95Latency     time.Duration
+	LastUpdated    string
+}
+
+// PageStats is one entry in AnalyticsData.TopPages.
+type PageStats struct {
+	Path  string
+	Views int64
+}
+
+// ReferrerStats is one entry in AnalyticsData.ReferrersTop.
+type ReferrerStats struct {
+	Referrer string
+	Views    int64
+}
+
+// Backend fetches aggregate analytics for [since, until] from one
+// analytics source.
+type Backend interface {
+	Fetch(ctx context.Context, since, until time.Time) (*AnalyticsData, error)
+}
+
+// CloudflareAnalyticsPlugin renders a stats page from whichever Backend
+// Init configures. The name predates the pluggable backend; it's kept so
+// existing plugin-config references to "cloudflare-analytics" keep
+// working.
+type CloudflareAnalyticsPlugin struct {
+	config       map[string]interface{}
+	backend      Backend
+	enabled      bool
+	templatePath string
+}
+
+// NewCloudflare creates the analytics plugin. Call Init to select and
+// configure a Backend before registering it with a PluginManager.
+func NewCloudflare() *CloudflareAnalyticsPlugin {
+	return &CloudflareAnalyticsPlugin{}
+}
+
+func (p *CloudflareAnalyticsPlugin) Name() string { return "cloudflare-analytics" }
+
+func (p *CloudflareAnalyticsPlugin) Version() string { return "2.0.0" }
+
+// Priority runs analytics late in the build pipeline, after content and
+// output plugins have produced everything the stats page links back to.
+func (p *CloudflareAnalyticsPlugin) Priority() int { return 80 }
+
+// Init selects a Backend from config["backend"] ("cloudflare_graphql",
+// "access_log", or "plausible"). A missing or unrecognized backend leaves
+// the plugin disabled, so PostBuild writes the placeholder stats page
+// instead of failing the build.
+func (p *CloudflareAnalyticsPlugin) Init(config map[string]interface{}) error {
+	p.config = config
+	p.templatePath = stringOr(config, "stats_template", "templates/stats.html.tmpl")
+
+	backend, err := newBackend(config)
+	if err != nil {
+		return fmt.Errorf("configuring analytics backend: %w", err)
+	}
+	p.backend = backend
+	p.enabled = backend != nil
+	return nil
+}
+
+// PreBuild does nothing; analytics only has something to report after the
+// build has produced output, in PostBuild.
+func (p *CloudflareAnalyticsPlugin) PreBuild(sourceDir string) error { return nil }
+
+// PostBuild fetches the last 30 days of analytics from the configured
+// backend and writes stats.html, falling back to a placeholder page when
+// no backend is configured or the backend reports no traffic at all.
+func (p *CloudflareAnalyticsPlugin) PostBuild(outputDir string) error {
+	if !p.enabled {
+		return p.createPlaceholderStats(outputDir)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	until := time.Now().UTC()
+	since := until.AddDate(0, 0, -30)
+
+	data, err := p.backend.Fetch(ctx, since, until)
+	if err != nil {
+		return fmt.Errorf("fetching analytics: %w", err)
+	}
+	if data.PageViews == 0 && data.UniqueVisitors == 0 {
+		return p.createPlaceholderStats(outputDir)
+	}
+
+	data.LastUpdated = time.Now().UTC().Format("2006-01-02 15:04:05 UTC")
+	return p.generateStatsPage(data, outputDir)
+}
+
+// newBackend constructs the Backend named by config["backend"]. It
+// returns (nil, nil) -- disabled, not an error -- when the key is absent,
+// matching the plugin's original behavior of falling back to placeholder
+// stats when no credentials are configured.
+func newBackend(config map[string]interface{}) (Backend, error) {
+	switch stringOr(config, "backend", "") {
+	case "":
+		return nil, nil
+	case "cloudflare_graphql":
+		return newCloudflareGraphQLBackend(config)
+	case "access_log":
+		return newAccessLogBackend(config)
+	case "plausible":
+		return newPlausibleBackend(config)
+	default:
+		return nil, fmt.Errorf("unknown analytics backend %q", config["backend"])
+	}
+}
+
+// generateStatsPage renders data through templatePath into outputDir's
+// stats.html.
+func (p *CloudflareAnalyticsPlugin) generateStatsPage(data *AnalyticsData, outputDir string) error {
+	tmpl, err := template.New(filepath.Base(p.templatePath)).Funcs(template.FuncMap{
+		"formatNumber": formatNumber,
+		"formatBytes":  formatBytes,
+	}).ParseFiles(p.templatePath)
+	if err != nil {
+		return fmt.Errorf("parsing stats template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return fmt.Errorf("rendering stats template: %w", err)
+	}
+
+	return ioutil.WriteFile(filepath.Join(outputDir, "stats.html"), buf.Bytes(), 0644)
+}
+
+// createPlaceholderStats writes a static stats page explaining that no
+// backend is configured yet, used whenever analytics is disabled or the
+// configured backend reports zero traffic.
+func (p *CloudflareAnalyticsPlugin) createPlaceholderStats(outputDir string) error {
+	html := `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <meta http-equiv="Content-Security-Policy" content="default-src 'none'; style-src 'unsafe-inline'">
+    <title>Blog Statistics</title>
+    <style>
+        * { margin: 0; padding: 0; box-sizing: border-box; }
+        body {
+            font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif;
+            line-height: 1.6;
+            color: #e0e0e0;
+            background: #0a0a0a;
+            max-width: 800px;
+            margin: 0 auto;
+            padding: 20px;
+        }
+        h1 { color: #00ff41; border-bottom: 2px solid #00ff41; padding-bottom: 10px; margin-bottom: 2em; }
+        .privacy-notice {
+            background: #1a1a1a;
+            border: 1px solid #00ff41;
+            border-radius: 4px;
+            padding: 1.5em;
+            margin: 2em 0;
+        }
+        .privacy-notice h2 { color: #00ff41; margin-bottom: 1em; }
+        a { color: #00ff41; text-decoration: none; }
+        a:hover { text-decoration: underline; }
+    </style>
+</head>
+<body>
+    <h1>Blog Statistics</h1>
+
+    <div class="privacy-notice">
+        <h2>Privacy-First Analytics</h2>
+        <p>This blog supports privacy-preserving statistics from Cloudflare's GraphQL Analytics API, a local access-log parser, or a Plausible/Umami-compatible endpoint:</p>
+        <ul>
+            <li>No JavaScript tracking</li>
+            <li>No cookies or fingerprinting</li>
+            <li>No personal data collection</li>
+            <li>GDPR/CCPA compliant by design</li>
+        </ul>
+        <p>Statistics will appear here once an analytics backend is configured.</p>
+    </div>
+
+    <p><a href="/">&larr; Back to Blog</a></p>
+</body>
+</html>`
+
+	return ioutil.WriteFile(filepath.Join(outputDir, "stats.html"), []byte(html), 0644)
+}
+
+// stringOr reads a string value out of a plugin config map, returning
+// def if the key is missing or not a string.
+func stringOr(config map[string]interface{}, key, def string) string {
+	if v, ok := config[key].(string); ok && v != "" {
+		return v
+	}
+	return def
+}
+
+func formatNumber(n int64) string {
+	if n >= 1000000 {
+		return fmt.Sprintf("%.1fM", float64(n)/1000000)
+	} else if n >= 1000 {
+		return fmt.Sprintf("%.1fK", float64(n)/1000)
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+var _ plugin.BuildPlugin = (*CloudflareAnalyticsPlugin)(nil)
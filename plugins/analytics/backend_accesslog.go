@@ -0,0 +1,198 @@
+package analytics
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// accessLogBackend parses Caddy/Nginx-style JSON access logs and
+// aggregates page views, status codes, referrers, bandwidth, and a
+// unique-visitor estimate. It never stores a raw client address: each
+// line's address is truncated (last octet/80 bits zeroed, the same scheme
+// Plugin.anonymizeIP uses) before being folded into a HyperLogLog sketch,
+// so counting uniques never requires keeping real IPs on disk or in
+// memory.
+type accessLogBackend struct {
+	logPath string
+}
+
+func newAccessLogBackend(config map[string]interface{}) (*accessLogBackend, error) {
+	logPath := stringOr(config, "log_path", "")
+	if logPath == "" {
+		return nil, fmt.Errorf("analytics.backend=access_log requires log_path")
+	}
+	return &accessLogBackend{logPath: logPath}, nil
+}
+
+// accessLogLine covers the overlap between Caddy's and Nginx's JSON
+// access log formats: both have a flat top-level status/size/duration,
+// and either a flat uri/remote_addr/referer (Nginx's typical json_combined
+// format) or Caddy's nested "request" object. Fields absent from one
+// format are simply left zero.
+type accessLogLine struct {
+	Request struct {
+		ClientIP string `json:"client_ip"`
+		URI      string `json:"uri"`
+		Headers  struct {
+			Referer []string `json:"Referer"`
+		} `json:"headers"`
+	} `json:"request"`
+	RemoteAddr string  `json:"remote_addr"`
+	URI        string  `json:"uri"`
+	Status     int     `json:"status"`
+	Size       int64   `json:"size"`
+	Duration   float64 `json:"duration"`
+	Referer    string  `json:"referer"`
+}
+
+func (b *accessLogBackend) Fetch(ctx context.Context, since, until time.Time) (*AnalyticsData, error) {
+	f, err := os.Open(b.logPath)
+	if err != nil {
+		return nil, fmt.Errorf("opening access log: %w", err)
+	}
+	defer f.Close()
+
+	pageViews := map[string]int64{}
+	referrers := map[string]int64{}
+	statusCodes := map[string]int64{}
+	hll := newHyperLogLog()
+	var total, bandwidth int64
+	var latencies []float64
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		var line accessLogLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			continue // skip malformed lines rather than failing the whole report
+		}
+
+		uri := line.URI
+		if uri == "" {
+			uri = line.Request.URI
+		}
+		ip := line.RemoteAddr
+		if ip == "" {
+			ip = line.Request.ClientIP
+		}
+		referer := line.Referer
+		if referer == "" && len(line.Request.Headers.Referer) > 0 {
+			referer = line.Request.Headers.Referer[0]
+		}
+
+		total++
+		pageViews[uri]++
+		bandwidth += line.Size
+		statusCodes[strconv.Itoa(line.Status)]++
+		if referer != "" {
+			referrers[refererHost(referer)]++
+		}
+		if line.Duration > 0 {
+			latencies = append(latencies, line.Duration)
+		}
+		hll.Add(truncateIP(ip))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading access log: %w", err)
+	}
+
+	return &AnalyticsData{
+		PageViews:      total,
+		UniqueVisitors: hll.Estimate(),
+		Bandwidth:      bandwidth,
+		TopPages:       topPages(pageViews, 10),
+		ReferrersTop:   topReferrers(referrers, 10),
+		StatusCodes:    statusCodes,
+		P95Latency:     time.Duration(percentile(latencies, 0.95) * float64(time.Second)),
+	}, nil
+}
+
+// truncateIP zeroes the last octet of an IPv4 address or the last 80 bits
+// of an IPv6 address, so the HyperLogLog sketch never sees a real client
+// address.
+func truncateIP(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return "unknown"
+	}
+
+	if v4 := ip.To4(); v4 != nil {
+		v4[3] = 0
+		return v4.String()
+	}
+	for i := 6; i < 16; i++ {
+		ip[i] = 0
+	}
+	return ip.String()
+}
+
+// refererHost keeps only the scheme+host of an external referer, dropping
+// path/query/fragment.
+func refererHost(ref string) string {
+	if idx := strings.IndexAny(ref, "?#"); idx != -1 {
+		ref = ref[:idx]
+	}
+	if idx := strings.Index(ref, "://"); idx != -1 {
+		afterProto := ref[idx+3:]
+		if slashIdx := strings.Index(afterProto, "/"); slashIdx != -1 {
+			ref = ref[:idx+3+slashIdx]
+		}
+	}
+	return ref
+}
+
+func topPages(counts map[string]int64, limit int) []PageStats {
+	pages := make([]PageStats, 0, len(counts))
+	for path, views := range counts {
+		pages = append(pages, PageStats{Path: path, Views: views})
+	}
+	sort.Slice(pages, func(i, j int) bool { return pages[i].Views > pages[j].Views })
+	if len(pages) > limit {
+		pages = pages[:limit]
+	}
+	return pages
+}
+
+func topReferrers(counts map[string]int64, limit int) []ReferrerStats {
+	referrers := make([]ReferrerStats, 0, len(counts))
+	for ref, views := range counts {
+		referrers = append(referrers, ReferrerStats{Referrer: ref, Views: views})
+	}
+	sort.Slice(referrers, func(i, j int) bool { return referrers[i].Views > referrers[j].Views })
+	if len(referrers) > limit {
+		referrers = referrers[:limit]
+	}
+	return referrers
+}
+
+// percentile returns the p-th percentile (0..1) of values, sorting a copy
+// so the caller's slice order is unaffected.
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	idx := int(p * float64(len(sorted)-1))
+	return sorted[idx]
+}
@@ -0,0 +1,166 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// cloudflareGraphQLEndpoint is Cloudflare's GraphQL Analytics API, which
+// replaced the deprecated per-zone `/analytics/dashboard` REST endpoint
+// this backend used to call.
+const cloudflareGraphQLEndpoint = "https://api.cloudflare.com/client/v4/graphql"
+
+// cloudflareGraphQLBackend fetches aggregate HTTP request and adaptive
+// firewall event totals, scoped to either a zone or (for multi-zone
+// sites) an account.
+type cloudflareGraphQLBackend struct {
+	apiToken  string
+	zoneID    string
+	accountID string
+	client    *http.Client
+}
+
+func newCloudflareGraphQLBackend(config map[string]interface{}) (*cloudflareGraphQLBackend, error) {
+	token := stringOr(config, "cf_api_token", "")
+	if token == "" {
+		return nil, fmt.Errorf("analytics.backend=cloudflare_graphql requires cf_api_token")
+	}
+	zoneID := stringOr(config, "cf_zone_id", "")
+	accountID := stringOr(config, "cf_account_id", "")
+	if zoneID == "" && accountID == "" {
+		return nil, fmt.Errorf("analytics.backend=cloudflare_graphql requires cf_zone_id or cf_account_id")
+	}
+
+	return &cloudflareGraphQLBackend{
+		apiToken:  token,
+		zoneID:    zoneID,
+		accountID: accountID,
+		client:    &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+func (b *cloudflareGraphQLBackend) Fetch(ctx context.Context, since, until time.Time) (*AnalyticsData, error) {
+	query, variables := b.buildQuery(since, until)
+
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return nil, fmt.Errorf("encoding graphql query: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cloudflareGraphQLEndpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("building graphql request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+b.apiToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("calling cloudflare graphql api: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cloudflare graphql api returned %s", resp.Status)
+	}
+
+	var result cloudflareGraphQLResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("parsing graphql response: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		return nil, fmt.Errorf("cloudflare graphql api error: %s", result.Errors[0].Message)
+	}
+
+	return result.toAnalyticsData(), nil
+}
+
+// buildQuery selects the zone- or account-scoped query variant (they
+// share a shape, differing only in which filter field scopes the query)
+// and its variables, combining httpRequests1dGroups (traffic/bandwidth)
+// with firewallEventsAdaptiveGroups (threats blocked).
+func (b *cloudflareGraphQLBackend) buildQuery(since, until time.Time) (string, map[string]interface{}) {
+	scopeField, scopeFilterKey, scopeID := "zones", "zoneTag", b.zoneID
+	if scopeID == "" {
+		scopeField, scopeFilterKey, scopeID = "accounts", "accountTag", b.accountID
+	}
+
+	query := fmt.Sprintf(`query Report($scopeTag: string!, $since: Date!, $until: Date!) {
+  viewer {
+    %s(filter: {%s: $scopeTag}) {
+      httpRequests1dGroups(limit: 1, filter: {date_geq: $since, date_leq: $until}) {
+        sum { requests pageViews bytes threats }
+        uniq { uniques }
+      }
+      firewallEventsAdaptiveGroups(limit: 1, filter: {date_geq: $since, date_leq: $until}) {
+        count
+      }
+    }
+  }
+}`, scopeField, scopeFilterKey)
+
+	variables := map[string]interface{}{
+		"scopeTag": scopeID,
+		"since":    since.Format("2006-01-02"),
+		"until":    until.Format("2006-01-02"),
+	}
+	return query, variables
+}
+
+type cloudflareGraphQLResponse struct {
+	Data struct {
+		Viewer struct {
+			Zones    []cloudflareScopeResult `json:"zones"`
+			Accounts []cloudflareScopeResult `json:"accounts"`
+		} `json:"viewer"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+type cloudflareScopeResult struct {
+	HTTPRequests1dGroups []struct {
+		Sum struct {
+			Requests  int64 `json:"requests"`
+			PageViews int64 `json:"pageViews"`
+			Bytes     int64 `json:"bytes"`
+			Threats   int64 `json:"threats"`
+		} `json:"sum"`
+		Uniq struct {
+			Uniques int64 `json:"uniques"`
+		} `json:"uniq"`
+	} `json:"httpRequests1dGroups"`
+	FirewallEventsAdaptiveGroups []struct {
+		Count int64 `json:"count"`
+	} `json:"firewallEventsAdaptiveGroups"`
+}
+
+func (r *cloudflareGraphQLResponse) toAnalyticsData() *AnalyticsData {
+	scope := r.Data.Viewer.Zones
+	if len(scope) == 0 {
+		scope = r.Data.Viewer.Accounts
+	}
+	data := &AnalyticsData{}
+	if len(scope) == 0 {
+		return data
+	}
+
+	s := scope[0]
+	if len(s.HTTPRequests1dGroups) > 0 {
+		g := s.HTTPRequests1dGroups[0]
+		data.PageViews = g.Sum.PageViews
+		data.Bandwidth = g.Sum.Bytes
+		data.UniqueVisitors = g.Uniq.Uniques
+	}
+	var threats int64
+	for _, fg := range s.FirewallEventsAdaptiveGroups {
+		threats += fg.Count
+	}
+	data.Threats = threats
+	return data
+}
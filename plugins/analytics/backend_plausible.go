@@ -0,0 +1,148 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// plausibleBackend fetches aggregate and breakdown stats from a
+// Plausible- or Umami-compatible pull API: both implement the same
+// /api/v1/stats/aggregate and /api/v1/stats/breakdown shape documented by
+// Plausible.
+type plausibleBackend struct {
+	apiBase string
+	apiKey  string
+	siteID  string
+	client  *http.Client
+}
+
+func newPlausibleBackend(config map[string]interface{}) (*plausibleBackend, error) {
+	apiKey := stringOr(config, "plausible_api_key", "")
+	if apiKey == "" {
+		return nil, fmt.Errorf("analytics.backend=plausible requires plausible_api_key")
+	}
+	siteID := stringOr(config, "plausible_site_id", "")
+	if siteID == "" {
+		return nil, fmt.Errorf("analytics.backend=plausible requires plausible_site_id")
+	}
+
+	return &plausibleBackend{
+		apiBase: stringOr(config, "plausible_api_base", "https://plausible.io/api/v1/stats"),
+		apiKey:  apiKey,
+		siteID:  siteID,
+		client:  &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+func (b *plausibleBackend) Fetch(ctx context.Context, since, until time.Time) (*AnalyticsData, error) {
+	period := url.Values{
+		"site_id": {b.siteID},
+		"period":  {"custom"},
+		"date":    {since.Format("2006-01-02") + "," + until.Format("2006-01-02")},
+	}
+
+	var aggregate plausibleAggregateResponse
+	aggQuery := cloneValues(period)
+	aggQuery.Set("metrics", "visitors,pageviews,bounce_rate")
+	if err := b.get(ctx, "/aggregate", aggQuery, &aggregate); err != nil {
+		return nil, fmt.Errorf("fetching plausible aggregate: %w", err)
+	}
+
+	var pages plausibleBreakdownResponse
+	pageQuery := cloneValues(period)
+	pageQuery.Set("property", "event:page")
+	pageQuery.Set("metrics", "visitors")
+	pageQuery.Set("limit", "10")
+	if err := b.get(ctx, "/breakdown", pageQuery, &pages); err != nil {
+		return nil, fmt.Errorf("fetching plausible page breakdown: %w", err)
+	}
+
+	var referrers plausibleBreakdownResponse
+	refQuery := cloneValues(period)
+	refQuery.Set("property", "visit:referrer")
+	refQuery.Set("metrics", "visitors")
+	refQuery.Set("limit", "10")
+	if err := b.get(ctx, "/breakdown", refQuery, &referrers); err != nil {
+		return nil, fmt.Errorf("fetching plausible referrer breakdown: %w", err)
+	}
+
+	return &AnalyticsData{
+		PageViews:      int64(aggregate.Results.Pageviews.Value),
+		UniqueVisitors: int64(aggregate.Results.Visitors.Value),
+		TopPages:       pages.toPageStats(),
+		ReferrersTop:   referrers.toReferrerStats(),
+	}, nil
+}
+
+func (b *plausibleBackend) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.apiBase+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("building request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+b.apiKey)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("calling api: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("api returned %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+func cloneValues(v url.Values) url.Values {
+	out := make(url.Values, len(v))
+	for k, vs := range v {
+		out[k] = append([]string(nil), vs...)
+	}
+	return out
+}
+
+type plausibleAggregateResponse struct {
+	Results struct {
+		Visitors  plausibleMetric `json:"visitors"`
+		Pageviews plausibleMetric `json:"pageviews"`
+	} `json:"results"`
+}
+
+type plausibleMetric struct {
+	Value float64 `json:"value"`
+}
+
+type plausibleBreakdownResponse struct {
+	Results []struct {
+		Page     string `json:"page"`
+		Referrer string `json:"referrer"`
+		Visitors int64  `json:"visitors"`
+	} `json:"results"`
+}
+
+func (r *plausibleBreakdownResponse) toPageStats() []PageStats {
+	stats := make([]PageStats, 0, len(r.Results))
+	for _, row := range r.Results {
+		stats = append(stats, PageStats{Path: row.Page, Views: row.Visitors})
+	}
+	return stats
+}
+
+func (r *plausibleBreakdownResponse) toReferrerStats() []ReferrerStats {
+	stats := make([]ReferrerStats, 0, len(r.Results))
+	for _, row := range r.Results {
+		referrer := row.Referrer
+		if referrer == "" {
+			referrer = "Direct / None"
+		}
+		stats = append(stats, ReferrerStats{Referrer: referrer, Views: row.Visitors})
+	}
+	return stats
+}
@@ -0,0 +1,331 @@
+// Package atom generates a first-class Atom 1.0 feed, as an alternative to
+// (or alongside) the legacy RSS 2.0 output from plugins/rss.
+package atom
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"secureblog/internal/plugin"
+	"strings"
+	"time"
+)
+
+// updatedCachePath persists, per post slug, the content hash and <updated>
+// timestamp last written for it, so a post whose rendered content hasn't
+// changed keeps its existing <updated> value instead of churning every
+// subscriber's feed reader on every rebuild. Variable rather than const so
+// tests can point it at a temp directory.
+var updatedCachePath = ".secureblog-cache/atom-updated.json"
+
+// AtomPlugin generates an Atom 1.0 feed at /atom.xml.
+type AtomPlugin struct {
+	config map[string]interface{}
+}
+
+// Feed is the Atom 1.0 <feed> root element (RFC 4287).
+type Feed struct {
+	XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string   `xml:"title"`
+	ID      string   `xml:"id"`
+	Updated string   `xml:"updated"`
+	Links   []Link   `xml:"link"`
+	Author  *Author  `xml:"author,omitempty"`
+	Entries []Entry  `xml:"entry"`
+}
+
+// Link is an Atom <link> element.
+type Link struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+// Author is an Atom <author> element.
+type Author struct {
+	Name string `xml:"name"`
+}
+
+// Category is an Atom <category> element.
+type Category struct {
+	Term string `xml:"term,attr"`
+}
+
+// Text is an Atom text construct (<summary>/<content>) with a type attr.
+type Text struct {
+	Type string `xml:",attr"`
+	Body string `xml:",chardata"`
+}
+
+// Entry is an Atom <entry> element.
+type Entry struct {
+	Title      string     `xml:"title"`
+	ID         string     `xml:"id"`
+	Updated    string     `xml:"updated"`
+	Published  string     `xml:"published"`
+	Author     *Author    `xml:"author,omitempty"`
+	Links      []Link     `xml:"link"`
+	Categories []Category `xml:"category,omitempty"`
+	Summary    *Text      `xml:"summary,omitempty"`
+	Content    *Text      `xml:"content,omitempty"`
+}
+
+// updatedEntry is one record in the persisted updated-timestamp cache.
+type updatedEntry struct {
+	Hash    string `json:"hash"`
+	Updated string `json:"updated"`
+}
+
+func New() *AtomPlugin {
+	return &AtomPlugin{}
+}
+
+func (p *AtomPlugin) Name() string {
+	return "atom-generator"
+}
+
+func (p *AtomPlugin) Version() string {
+	return "1.0.0"
+}
+
+func (p *AtomPlugin) Init(config map[string]interface{}) error {
+	p.config = config
+	return nil
+}
+
+func (p *AtomPlugin) Priority() int {
+	return 50
+}
+
+func (p *AtomPlugin) Generate(posts []plugin.Post, outputDir string) error {
+	siteTitle := "Secure Blog"
+	siteURL := "/"
+	author := "Secure Blog"
+	tagDate := "2024-01-01"
+	includeContent := true
+	includeSummary := true
+
+	if v, ok := p.config["title"].(string); ok {
+		siteTitle = v
+	}
+	if v, ok := p.config["url"].(string); ok {
+		siteURL = v
+	}
+	if v, ok := p.config["author"].(string); ok {
+		author = v
+	}
+	if v, ok := p.config["tagDate"].(string); ok {
+		tagDate = v
+	}
+	if v, ok := p.config["content"].(bool); ok {
+		includeContent = v
+	}
+	if v, ok := p.config["summary"].(bool); ok {
+		includeSummary = v
+	}
+
+	domain := tagDomain(p.config, siteURL)
+
+	// feedToken, set only when SECUREBLOG_PRIVATE gates this site, lets a
+	// feed reader resubscribe at a URL that carries its own access token
+	// (see internal/session.IssueFeedToken and internal/server's
+	// SECUREBLOG_PRIVATE middleware) instead of needing a browser session.
+	feedToken, _ := p.config["feedToken"].(string)
+
+	cache, err := loadUpdatedCache(updatedCachePath)
+	if err != nil {
+		return err
+	}
+
+	feed := Feed{
+		Title:  siteTitle,
+		ID:     fmt.Sprintf("tag:%s,%s:/", domain, tagDate),
+		Author: &Author{Name: author},
+		Links: []Link{
+			{Href: siteURL, Rel: "alternate", Type: "text/html"},
+			{Href: selfFeedURL(siteURL, "/atom.xml", feedToken), Rel: "self", Type: "application/atom+xml"},
+		},
+	}
+
+	photoFeed := Feed{
+		Title:  siteTitle + " Photos",
+		ID:     fmt.Sprintf("tag:%s,%s:/photos", domain, tagDate),
+		Author: &Author{Name: author},
+		Links: []Link{
+			{Href: siteURL, Rel: "alternate", Type: "text/html"},
+			{Href: selfFeedURL(siteURL, "/photos.atom", feedToken), Rel: "self", Type: "application/atom+xml"},
+		},
+	}
+
+	var feedUpdated, photoFeedUpdated string
+	for _, post := range posts {
+		hash := sha256.Sum256([]byte(post.Content))
+		hashHex := hex.EncodeToString(hash[:])
+
+		updated := cache[post.Slug]
+		if updated.Hash != hashHex {
+			updated = updatedEntry{Hash: hashHex, Updated: time.Now().UTC().Format(time.RFC3339)}
+			cache[post.Slug] = updated
+		}
+		if updated.Updated > feedUpdated {
+			feedUpdated = updated.Updated
+		}
+
+		published, _ := parsePostTime(post.Date)
+
+		entry := Entry{
+			Title:     post.Title,
+			ID:        fmt.Sprintf("tag:%s,%s:%s", domain, tagDate, post.Slug),
+			Updated:   updated.Updated,
+			Published: published.Format(time.RFC3339),
+			Author:    &Author{Name: author},
+			Links: []Link{
+				{Href: fmt.Sprintf("%s/%s.html", siteURL, post.Slug), Rel: "alternate", Type: "text/html"},
+			},
+		}
+		for _, tag := range post.Tags {
+			entry.Categories = append(entry.Categories, Category{Term: tag})
+		}
+		if isProtectedPost(post) {
+			entry.Summary = &Text{Type: "text", Body: "This post is password-protected."}
+		} else {
+			if includeContent {
+				entry.Content = &Text{Type: "html", Body: string(post.Content)}
+			}
+			if includeSummary {
+				entry.Summary = &Text{Type: "text", Body: summarize(string(post.Content))}
+			}
+		}
+
+		feed.Entries = append(feed.Entries, entry)
+
+		if isPhotoPost(post) {
+			photoFeed.Entries = append(photoFeed.Entries, entry)
+			if updated.Updated > photoFeedUpdated {
+				photoFeedUpdated = updated.Updated
+			}
+		}
+	}
+	if feedUpdated == "" {
+		feedUpdated = time.Now().UTC().Format(time.RFC3339)
+	}
+	feed.Updated = feedUpdated
+	if photoFeedUpdated == "" {
+		photoFeedUpdated = feedUpdated
+	}
+	photoFeed.Updated = photoFeedUpdated
+
+	if err := saveUpdatedCache(updatedCachePath, cache); err != nil {
+		return err
+	}
+
+	if err := writeFeed(feed, filepath.Join(outputDir, "atom.xml")); err != nil {
+		return err
+	}
+	return writeFeed(photoFeed, filepath.Join(outputDir, "photos.atom"))
+}
+
+// isPhotoPost reports whether a post's front matter marked it as photo
+// content, so image-only clients can subscribe to photos.atom without
+// pulling the whole feed.
+func isPhotoPost(post plugin.Post) bool {
+	photo, _ := post.Metadata["photo"].(bool)
+	return photo
+}
+
+// isProtectedPost reports whether a post's front matter set a passphrase,
+// so the feed omits its body instead of shipping the encrypted-content
+// placeholder as though it were real content.
+func isProtectedPost(post plugin.Post) bool {
+	protected, _ := post.Metadata["protected"].(bool)
+	return protected
+}
+
+// writeFeed marshals feed as Atom 1.0 XML and writes it to path.
+func writeFeed(feed Feed, path string) error {
+	output, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, []byte(xml.Header+string(output)), 0644)
+}
+
+// selfFeedURL builds the feed's own rel="self" URL, appending ?token= when
+// token is non-empty so a feed reader can resubscribe at a URL that already
+// carries its SECUREBLOG_PRIVATE access token.
+func selfFeedURL(siteURL, path, token string) string {
+	href := strings.TrimRight(siteURL, "/") + path
+	if token == "" {
+		return href
+	}
+	return href + "?token=" + url.QueryEscape(token)
+}
+
+// tagDomain picks the authority used in RFC 4151 tag: URIs: an explicit
+// config override, or the host parsed out of the site URL.
+func tagDomain(config map[string]interface{}, siteURL string) string {
+	if v, ok := config["domain"].(string); ok && v != "" {
+		return v
+	}
+	if u, err := url.Parse(siteURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return "example.com"
+}
+
+// parsePostTime parses a post's Date field, which may be an RFC 3339
+// timestamp (the default BuilderV2 assigns from file mtime) or a bare
+// front-matter date like "2024-01-02".
+func parsePostTime(date string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, date); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", date)
+}
+
+// summarize takes a short plain-text-ish prefix of rendered HTML content
+// for the <summary> text construct.
+func summarize(html string) string {
+	const maxLen = 280
+	if len(html) <= maxLen {
+		return html
+	}
+	return html[:maxLen] + "..."
+}
+
+func loadUpdatedCache(path string) (map[string]updatedEntry, error) {
+	cache := make(map[string]updatedEntry)
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return make(map[string]updatedEntry), nil
+	}
+	return cache, nil
+}
+
+func saveUpdatedCache(path string, cache map[string]updatedEntry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+var _ plugin.OutputPlugin = (*AtomPlugin)(nil)
@@ -0,0 +1,135 @@
+package atom
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"secureblog/internal/plugin"
+	"testing"
+)
+
+func TestGenerateWritesValidFeedWithTagURIs(t *testing.T) {
+	dir := t.TempDir()
+	updatedCache := filepath.Join(dir, "cache.json")
+
+	p := New()
+	if err := p.Init(map[string]interface{}{
+		"title":   "Test Blog",
+		"url":     "https://example.com",
+		"domain":  "example.com",
+		"tagDate": "2020-01-01",
+	}); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	prev := updatedCachePath
+	updatedCachePath = updatedCache
+	defer func() { updatedCachePath = prev }()
+
+	posts := []plugin.Post{
+		{Slug: "hello", Title: "Hello", Content: "<p>hi</p>", Date: "2024-01-02T00:00:00Z", Tags: []string{"go"}},
+	}
+
+	if err := p.Generate(posts, dir); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "atom.xml"))
+	if err != nil {
+		t.Fatalf("reading atom.xml: %v", err)
+	}
+
+	var feed Feed
+	if err := xml.Unmarshal(data, &feed); err != nil {
+		t.Fatalf("atom.xml is not valid XML: %v", err)
+	}
+
+	if len(feed.Entries) != 1 {
+		t.Fatalf("len(Entries) = %d, want 1", len(feed.Entries))
+	}
+	entry := feed.Entries[0]
+	wantID := "tag:example.com,2020-01-01:hello"
+	if entry.ID != wantID {
+		t.Errorf("entry ID = %q, want %q", entry.ID, wantID)
+	}
+	if len(entry.Categories) != 1 || entry.Categories[0].Term != "go" {
+		t.Errorf("entry Categories = %+v, want [{go}]", entry.Categories)
+	}
+}
+
+func TestGenerateFiltersPhotosFeedToPhotoPosts(t *testing.T) {
+	dir := t.TempDir()
+	updatedCache := filepath.Join(dir, "cache.json")
+
+	p := New()
+	if err := p.Init(map[string]interface{}{"domain": "example.com"}); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	prev := updatedCachePath
+	updatedCachePath = updatedCache
+	defer func() { updatedCachePath = prev }()
+
+	posts := []plugin.Post{
+		{Slug: "hello", Title: "Hello", Content: "<p>hi</p>", Date: "2024-01-02T00:00:00Z"},
+		{Slug: "sunset", Title: "Sunset", Content: "<p>pic</p>", Date: "2024-01-03T00:00:00Z",
+			Metadata: map[string]interface{}{"photo": true}},
+	}
+
+	if err := p.Generate(posts, dir); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "photos.atom"))
+	if err != nil {
+		t.Fatalf("reading photos.atom: %v", err)
+	}
+
+	var feed Feed
+	if err := xml.Unmarshal(data, &feed); err != nil {
+		t.Fatalf("photos.atom is not valid XML: %v", err)
+	}
+	if len(feed.Entries) != 1 || feed.Entries[0].ID != "tag:example.com,2024-01-01:sunset" {
+		t.Errorf("photos.atom Entries = %+v, want only the sunset entry", feed.Entries)
+	}
+}
+
+func TestGenerateKeepsUpdatedStableForUnchangedContent(t *testing.T) {
+	dir := t.TempDir()
+	updatedCache := filepath.Join(dir, "cache.json")
+	prev := updatedCachePath
+	updatedCachePath = updatedCache
+	defer func() { updatedCachePath = prev }()
+
+	p := New()
+	p.Init(map[string]interface{}{"domain": "example.com"})
+
+	posts := []plugin.Post{
+		{Slug: "hello", Title: "Hello", Content: "<p>hi</p>", Date: "2024-01-02T00:00:00Z"},
+	}
+
+	if err := p.Generate(posts, dir); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	first, err := os.ReadFile(filepath.Join(dir, "atom.xml"))
+	if err != nil {
+		t.Fatalf("reading atom.xml: %v", err)
+	}
+	var firstFeed Feed
+	xml.Unmarshal(first, &firstFeed)
+
+	if err := p.Generate(posts, dir); err != nil {
+		t.Fatalf("second Generate() error = %v", err)
+	}
+	second, err := os.ReadFile(filepath.Join(dir, "atom.xml"))
+	if err != nil {
+		t.Fatalf("reading atom.xml: %v", err)
+	}
+	var secondFeed Feed
+	xml.Unmarshal(second, &secondFeed)
+
+	if firstFeed.Entries[0].Updated != secondFeed.Entries[0].Updated {
+		t.Errorf("Updated changed for unchanged content: %q != %q",
+			firstFeed.Entries[0].Updated, secondFeed.Entries[0].Updated)
+	}
+}
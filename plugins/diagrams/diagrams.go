@@ -0,0 +1,92 @@
+// Package diagrams renders fenced ```mermaid``` and ```kroki-<backend>```
+// code blocks in Markdown source into inline, sanitized SVG at build
+// time, so diagrams work without the output site ever shipping
+// client-side JavaScript. Rendering itself happens in a sandboxed
+// subprocess (see renderer.go) with no network access, and the resulting
+// SVG is run through the same kind of allowlist sanitizer as the rest of
+// post content before it's inlined.
+package diagrams
+
+import (
+	"fmt"
+	"regexp"
+
+	"secureblog/internal/plugin"
+	"secureblog/internal/policy"
+	"secureblog/internal/security"
+)
+
+// diagramRenderer renders one fenced block's source to SVG. Renderer is
+// the real, sandboxed implementation; tests substitute a stub.
+type diagramRenderer interface {
+	Render(lang string, src []byte) ([]byte, error)
+}
+
+// fencedDiagramRe matches a fenced code block whose info string is
+// "mermaid" or "kroki-<backend>", capturing the language tag and the
+// block's raw source.
+var fencedDiagramRe = regexp.MustCompile("(?ms)^```(mermaid|kroki-[a-z0-9_-]+)\\n(.*?)\\n```[ \\t]*$")
+
+// DiagramsPlugin replaces diagram code fences with inline SVG.
+type DiagramsPlugin struct {
+	renderer  diagramRenderer
+	sanitizer *security.Sanitizer
+}
+
+// New creates a DiagramsPlugin. Call Init before use.
+func New() *DiagramsPlugin {
+	return &DiagramsPlugin{}
+}
+
+func (p *DiagramsPlugin) Name() string { return "diagrams" }
+
+func (p *DiagramsPlugin) Version() string { return "1.0.0" }
+
+// Priority runs before the markdown plugin (priority 10): diagram fences
+// must be replaced with raw SVG before the CommonMark engine sees them,
+// since a fenced code block's contents would otherwise be rendered as
+// escaped, highlighted text rather than a diagram.
+func (p *DiagramsPlugin) Priority() int { return 5 }
+
+// Init builds the plugin's sandboxed renderer. config["policy"] may
+// supply a *policy.Policy gating which renderer binaries may be spawned
+// (see Renderer.Render); omitting it falls back to policy.Default(),
+// which denies every binary until the site's config explicitly allows
+// one.
+func (p *DiagramsPlugin) Init(config map[string]interface{}) error {
+	pol, _ := config["policy"].(*policy.Policy)
+	if pol == nil {
+		pol = policy.Default()
+	}
+	p.renderer = NewRenderer(pol)
+	p.sanitizer = security.NewSanitizer(SVGPolicy())
+	return nil
+}
+
+// ProcessContent replaces every mermaid/kroki fenced code block in
+// content with its rendered, sanitized SVG.
+func (p *DiagramsPlugin) ProcessContent(content []byte, metadata map[string]interface{}) ([]byte, error) {
+	var firstErr error
+	replaced := fencedDiagramRe.ReplaceAllFunc(content, func(match []byte) []byte {
+		if firstErr != nil {
+			return match
+		}
+
+		groups := fencedDiagramRe.FindSubmatch(match)
+		lang, src := string(groups[1]), groups[2]
+
+		svg, err := p.renderer.Render(lang, src)
+		if err != nil {
+			firstErr = fmt.Errorf("diagrams: rendering %s block: %w", lang, err)
+			return match
+		}
+
+		return []byte("\n" + p.sanitizer.Sanitize(string(svg)) + "\n")
+	})
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return replaced, nil
+}
+
+var _ plugin.ContentPlugin = (*DiagramsPlugin)(nil)
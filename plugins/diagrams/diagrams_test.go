@@ -0,0 +1,69 @@
+package diagrams
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"secureblog/internal/security"
+)
+
+// stubRenderer returns a fixed SVG (or error) for any diagram source, so
+// ProcessContent can be tested without a real mmdc/dot binary.
+type stubRenderer struct {
+	svg []byte
+	err error
+}
+
+func (r stubRenderer) Render(lang string, src []byte) ([]byte, error) {
+	return r.svg, r.err
+}
+
+var errRender = errors.New("render failed")
+
+func newTestPlugin(r diagramRenderer) *DiagramsPlugin {
+	return &DiagramsPlugin{renderer: r, sanitizer: security.NewSanitizer(SVGPolicy())}
+}
+
+func TestProcessContentReplacesFencedDiagram(t *testing.T) {
+	p := newTestPlugin(stubRenderer{svg: []byte(`<svg onload="evil()"><rect width="10" height="10"/><script>bad()</script></svg>`)})
+
+	content := []byte("before\n\n```mermaid\ngraph TD; A-->B;\n```\n\nafter")
+	got, err := p.ProcessContent(content, nil)
+	if err != nil {
+		t.Fatalf("ProcessContent() error = %v", err)
+	}
+
+	gotStr := string(got)
+	if strings.Contains(gotStr, "```") {
+		t.Errorf("ProcessContent() left a fenced block in place: %s", gotStr)
+	}
+	if strings.Contains(gotStr, "onload") || strings.Contains(gotStr, "<script") {
+		t.Errorf("ProcessContent() did not strip dangerous SVG content: %s", gotStr)
+	}
+	if !strings.Contains(gotStr, "<rect") {
+		t.Errorf("ProcessContent() dropped safe SVG content: %s", gotStr)
+	}
+}
+
+func TestProcessContentPropagatesRenderError(t *testing.T) {
+	p := newTestPlugin(stubRenderer{err: errRender})
+
+	content := []byte("```mermaid\ngraph TD; A-->B;\n```")
+	if _, err := p.ProcessContent(content, nil); err == nil {
+		t.Fatal("ProcessContent() error = nil, want the renderer's error")
+	}
+}
+
+func TestProcessContentIgnoresUnrelatedFences(t *testing.T) {
+	p := newTestPlugin(stubRenderer{err: errRender}) // must never be called
+
+	content := []byte("```go\nfmt.Println(\"hi\")\n```")
+	got, err := p.ProcessContent(content, nil)
+	if err != nil {
+		t.Fatalf("ProcessContent() error = %v", err)
+	}
+	if string(got) != string(content) {
+		t.Errorf("ProcessContent() = %q, want unchanged %q", got, content)
+	}
+}
@@ -0,0 +1,180 @@
+package diagrams
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"secureblog/internal/policy"
+	"secureblog/plugins"
+)
+
+// renderTimeout bounds how long a single diagram render may run. A
+// malformed or adversarial diagram source must never be able to hang (or
+// busy-loop) a build.
+const renderTimeout = 10 * time.Second
+
+// maxDiagramOutput caps how much SVG a single render may produce, so a
+// pathological diagram can't exhaust memory buffering the result.
+const maxDiagramOutput = 4 << 20 // 4MB
+
+// command describes how to invoke one diagram backend: the binary and the
+// args that make it read its source from stdin and write SVG to stdout.
+type command struct {
+	bin  string
+	args []string
+}
+
+// defaultCommands maps a fenced code block's language tag to the local
+// CLI that renders it. "mermaid" shells to the Mermaid CLI; each
+// "kroki-<backend>" tag shells to the same local renderer Kroki itself
+// dispatches to for that backend (e.g. Graphviz's own "dot"), so sources
+// written for Kroki's fenced-block naming convention render identically
+// without this build ever reaching the network.
+var defaultCommands = map[string]command{
+	"mermaid":         {"mmdc", []string{"-i", "-", "-o", "-", "--outputFormat", "svg"}},
+	"kroki-graphviz":  {"dot", []string{"-Tsvg"}},
+	"kroki-plantuml":  {"plantuml", []string{"-pipe", "-tsvg"}},
+	"kroki-blockdiag": {"blockdiag", []string{"-Tsvg", "-o", "-", "-"}},
+}
+
+// execSandbox runs one diagram-rendering command with its source on
+// stdin, isolated from the network, and returns the SVG it writes to
+// stdout.
+type execSandbox interface {
+	Run(ctx context.Context, bin string, args []string, stdin []byte) ([]byte, error)
+}
+
+// Renderer runs a diagram's source through its backend's CLI inside a
+// sandboxed subprocess and returns the raw (not yet sanitized) SVG it
+// produces.
+type Renderer struct {
+	commands map[string]command
+	sandbox  execSandbox
+	policy   *policy.Policy
+}
+
+// NewRenderer builds a Renderer using the strongest sandbox available on
+// this host: nsjail, then bubblewrap, then plugins.Sandbox's
+// network-namespace-and-dropped-privilege isolation as the last resort
+// when neither dedicated sandboxing tool is installed. pol gates which
+// binaries may actually be spawned (exec.allow in the security policy) --
+// without an explicit allow entry for "mmdc"/"dot"/etc., rendering fails
+// with an actionable error instead of silently running an unreviewed
+// binary.
+func NewRenderer(pol *policy.Policy) *Renderer {
+	commands := make(map[string]command, len(defaultCommands))
+	for k, v := range defaultCommands {
+		commands[k] = v
+	}
+	return &Renderer{commands: commands, sandbox: detectSandbox(pol), policy: pol}
+}
+
+// Render renders src (the diagram source from a fenced code block tagged
+// lang) to SVG. The backend binary must be named in the security policy's
+// exec.allow list -- this is checked here regardless of which sandbox
+// mechanism ends up running it, not just in the no-nsjail/bwrap fallback.
+func (r *Renderer) Render(lang string, src []byte) ([]byte, error) {
+	cmd, ok := r.commands[lang]
+	if !ok {
+		return nil, fmt.Errorf("no renderer configured for %q", lang)
+	}
+	if err := r.policy.AllowExec(cmd.bin); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), renderTimeout)
+	defer cancel()
+
+	out, err := r.sandbox.Run(ctx, cmd.bin, cmd.args, src)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) > maxDiagramOutput {
+		return nil, fmt.Errorf("%s produced %d bytes of output, exceeding the %d byte limit", cmd.bin, len(out), maxDiagramOutput)
+	}
+	return out, nil
+}
+
+// detectSandbox picks nsjail or bubblewrap if either is on PATH, falling
+// back to plugins.Sandbox (no external sandboxing tool required, but
+// weaker: network-namespace isolation and a dropped-privilege UID rather
+// than a full chroot and seccomp filter).
+func detectSandbox(pol *policy.Policy) execSandbox {
+	if bin, err := exec.LookPath("nsjail"); err == nil {
+		return nsjailSandbox{bin: bin}
+	}
+	if bin, err := exec.LookPath("bwrap"); err == nil {
+		return bubblewrapSandbox{bin: bin}
+	}
+	return liteSandbox{sandbox: plugins.NewSandbox(pol)}
+}
+
+// nsjailSandbox runs the command under nsjail, which by default gives the
+// child its own network namespace (so it has no network access) plus CPU,
+// memory, and wall-clock limits, and a read-only chroot of the host root.
+type nsjailSandbox struct{ bin string }
+
+func (s nsjailSandbox) Run(ctx context.Context, bin string, args []string, stdin []byte) ([]byte, error) {
+	nsjailArgs := []string{
+		"--mode", "o", // run the command once and exit
+		"--chroot", "/",
+		"--cwd", "/tmp",
+		"--rlimit_as", "512",
+		"--rlimit_cpu", "10",
+		"--time_limit", "10",
+		"--user", "nobody",
+		"--group", "nogroup",
+		"--disable_proc",
+		"--quiet",
+		"--", bin,
+	}
+	nsjailArgs = append(nsjailArgs, args...)
+	return runCapturing(ctx, s.bin, nsjailArgs, stdin)
+}
+
+// bubblewrapSandbox runs the command under bwrap with the network and
+// mount namespaces unshared (no network access, a read-only view of the
+// host root), dying with its parent so a killed build doesn't leak a
+// runaway renderer.
+type bubblewrapSandbox struct{ bin string }
+
+func (s bubblewrapSandbox) Run(ctx context.Context, bin string, args []string, stdin []byte) ([]byte, error) {
+	bwrapArgs := []string{
+		"--unshare-net",
+		"--unshare-pid",
+		"--ro-bind", "/", "/",
+		"--tmpfs", "/tmp",
+		"--die-with-parent",
+		"--new-session",
+		"--", bin,
+	}
+	bwrapArgs = append(bwrapArgs, args...)
+	return runCapturing(ctx, s.bin, bwrapArgs, stdin)
+}
+
+// liteSandbox is the fallback used when neither nsjail nor bubblewrap is
+// installed: plugins.Sandbox's own isolation (CLONE_NEWNET plus the
+// unprivileged "nobody" UID/GID), which denies network access and root
+// privileges but -- unlike nsjail/bwrap -- doesn't chroot the filesystem
+// or install a seccomp filter. Install nsjail or bubblewrap for the full
+// isolation the diagram sandbox is meant to provide.
+type liteSandbox struct{ sandbox *plugins.Sandbox }
+
+func (s liteSandbox) Run(ctx context.Context, bin string, args []string, stdin []byte) ([]byte, error) {
+	return s.sandbox.RunCapture(ctx, bin, args, stdin)
+}
+
+func runCapturing(ctx context.Context, bin string, args []string, stdin []byte) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", bin, err, stderr.String())
+	}
+	return stdout.Bytes(), nil
+}
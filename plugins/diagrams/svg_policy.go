@@ -0,0 +1,69 @@
+package diagrams
+
+import "secureblog/internal/security"
+
+// SVGPolicy allow-lists the SVG elements and attributes Mermaid,
+// Graphviz, and PlantUML commonly emit. <script> and <foreignObject> are
+// left off the element list (so the sanitizer's dangling/unwrap logic
+// drops them), and no attribute list below includes an "on*" event
+// handler, so those are dropped the same way a disallowed element's
+// attributes always are. The Schemes map is empty: every href/src in an
+// SVG produced by a diagram renderer is a same-document fragment
+// reference (e.g. "#arrowhead"), never an external or "javascript:" URL.
+func SVGPolicy() *security.Policy {
+	shapeAttrs := map[string]bool{
+		"class": true, "id": true, "style": true,
+		"x": true, "y": true, "x1": true, "y1": true, "x2": true, "y2": true,
+		"cx": true, "cy": true, "r": true, "rx": true, "ry": true,
+		"width": true, "height": true, "points": true, "d": true,
+		"transform": true, "fill": true, "stroke": true, "stroke-width": true,
+		"stroke-dasharray": true, "opacity": true, "font-family": true,
+		"font-size": true, "font-weight": true, "text-anchor": true,
+		"dominant-baseline": true, "marker-end": true, "marker-start": true,
+	}
+
+	withHref := copyAttrs(shapeAttrs)
+	withHref["href"] = true
+
+	svgAttrs := copyAttrs(shapeAttrs)
+	svgAttrs["viewBox"] = true
+	svgAttrs["xmlns"] = true
+	svgAttrs["version"] = true
+
+	return &security.Policy{
+		Elements: map[string]bool{
+			"svg": true, "g": true, "path": true, "rect": true, "circle": true,
+			"ellipse": true, "line": true, "polyline": true, "polygon": true,
+			"text": true, "tspan": true, "defs": true, "marker": true,
+			"title": true, "desc": true, "clipPath": true, "use": true,
+		},
+		Attributes: map[string]map[string]bool{
+			"svg":      svgAttrs,
+			"g":        shapeAttrs,
+			"path":     shapeAttrs,
+			"rect":     shapeAttrs,
+			"circle":   shapeAttrs,
+			"ellipse":  shapeAttrs,
+			"line":     shapeAttrs,
+			"polyline": shapeAttrs,
+			"polygon":  shapeAttrs,
+			"text":     shapeAttrs,
+			"tspan":    shapeAttrs,
+			"defs":     shapeAttrs,
+			"marker":   shapeAttrs,
+			"title":    {},
+			"desc":     {},
+			"clipPath": shapeAttrs,
+			"use":      withHref,
+		},
+		Schemes: map[string]bool{},
+	}
+}
+
+func copyAttrs(src map[string]bool) map[string]bool {
+	out := make(map[string]bool, len(src)+2)
+	for k, v := range src {
+		out[k] = v
+	}
+	return out
+}
@@ -1,8 +1,10 @@
 package integrity
 
 import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
 	"crypto/sha256"
-	"crypto/subtle"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -12,10 +14,27 @@ import (
 	"time"
 )
 
-// Plugin provides content integrity verification
+// Domain-separated hash prefixes, so a leaf hash can never collide with an
+// internal node hash computed over the same bytes (the classic
+// second-preimage attack against naive Merkle trees).
+const (
+	leafPrefix byte = 0x00
+	nodePrefix byte = 0x01
+)
+
+// Plugin provides content integrity verification via a signed Merkle tree
+// over the build's sorted (relPath, sha256) leaves. Only the tree's root is
+// signed and stored in the manifest; per-asset verification is done against
+// a sibling-hash audit path from the sidecar proofs.json, so an edge/CDN
+// component can verify one asset without fetching every file's hash.
 type Plugin struct {
-	enabled  bool
-	manifest map[string]string
+	enabled     bool
+	signKeyPath string
+
+	manifest map[string]string // relPath -> hex sha256, populated by GenerateManifest
+
+	header ManifestHeader
+	proofs map[string][]string // relPath -> audit path, populated by GenerateManifest/LoadManifest
 }
 
 // Config holds plugin configuration
@@ -25,56 +44,96 @@ type Config struct {
 	SignKeyPath  string `json:"sign_key_path"`
 }
 
+// ManifestHeader is the signed summary written to the manifest file: enough
+// to verify any single asset via its proof without loading the full leaf
+// list, since verification needs only the embedded pubkey.
+type ManifestHeader struct {
+	Root      string `json:"root"`       // hex sha256 Merkle root
+	Height    int    `json:"height"`     // number of levels above the leaves
+	LeafCount int    `json:"leaf_count"` // number of (relPath, hash) leaves
+	Signature string `json:"signature"`  // hex Ed25519 signature over the raw root bytes
+	PubKey    string `json:"pubkey"`     // hex Ed25519 public key
+}
+
 // NewPlugin creates a new integrity plugin
 func NewPlugin(config Config) *Plugin {
 	return &Plugin{
-		enabled:  config.Enabled,
-		manifest: make(map[string]string),
+		enabled:     config.Enabled,
+		signKeyPath: config.SignKeyPath,
+		manifest:    make(map[string]string),
 	}
 }
 
-// GenerateManifest creates integrity manifest for all content
+// GenerateManifest hashes every file under contentDir, then builds the
+// Merkle tree and per-file audit proofs over the result, ready for
+// SaveManifest.
 func (p *Plugin) GenerateManifest(contentDir string) error {
 	if !p.enabled {
 		return nil
 	}
-	
+
 	err := filepath.Walk(contentDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		
+
 		if info.IsDir() {
 			return nil
 		}
-		
+
 		// Skip manifest and signature files
-		if filepath.Base(path) == "integrity-manifest.json" || 
-		   filepath.Base(path) == "integrity-manifest.json.sig" {
+		if isManifestArtifact(filepath.Base(path)) {
 			return nil
 		}
-		
+
 		hash, err := p.hashFile(path)
 		if err != nil {
 			return fmt.Errorf("hashing %s: %w", path, err)
 		}
-		
+
 		relPath, err := filepath.Rel(contentDir, path)
 		if err != nil {
 			return err
 		}
-		
+
 		p.manifest[relPath] = hash
 		return nil
 	})
-	
 	if err != nil {
 		return fmt.Errorf("walking directory: %w", err)
 	}
-	
+
+	root, height, proofs, err := buildMerkleTree(p.manifest)
+	if err != nil {
+		return fmt.Errorf("building merkle tree: %w", err)
+	}
+	p.proofs = proofs
+
+	signature, pub, err := p.signRoot(root)
+	if err != nil {
+		return fmt.Errorf("signing merkle root: %w", err)
+	}
+
+	p.header = ManifestHeader{
+		Root:      hex.EncodeToString(root),
+		Height:    height,
+		LeafCount: len(p.manifest),
+		Signature: hex.EncodeToString(signature),
+		PubKey:    hex.EncodeToString(pub),
+	}
+
 	return nil
 }
 
+func isManifestArtifact(base string) bool {
+	switch base {
+	case "integrity-manifest.json", "integrity-manifest.json.sig", "proofs.json":
+		return true
+	default:
+		return false
+	}
+}
+
 // hashFile computes SHA-256 hash of file
 func (p *Plugin) hashFile(path string) (string, error) {
 	file, err := os.Open(path)
@@ -82,134 +141,228 @@ func (p *Plugin) hashFile(path string) (string, error) {
 		return "", err
 	}
 	defer file.Close()
-	
+
 	hasher := sha256.New()
 	if _, err := io.Copy(hasher, file); err != nil {
 		return "", err
 	}
-	
+
 	return hex.EncodeToString(hasher.Sum(nil)), nil
 }
 
-// SaveManifest writes manifest to file
+// SaveManifest writes the signed manifest header to outputPath and the
+// per-file audit proofs to a "proofs.json" sidecar alongside it.
 func (p *Plugin) SaveManifest(outputPath string) error {
 	if !p.enabled {
 		return nil
 	}
-	
-	// Add metadata
-	manifestWithMeta := map[string]interface{}{
-		"version":   "1.0",
-		"generated": time.Now().UTC().Format(time.RFC3339),
-		"files":     p.manifest,
+
+	manifestWithMeta := struct {
+		Version   string         `json:"version"`
+		Generated string         `json:"generated"`
+		Header    ManifestHeader `json:"header"`
+	}{
+		Version:   "2.0",
+		Generated: time.Now().UTC().Format(time.RFC3339),
+		Header:    p.header,
 	}
-	
+
 	data, err := json.MarshalIndent(manifestWithMeta, "", "  ")
 	if err != nil {
 		return fmt.Errorf("marshaling manifest: %w", err)
 	}
-	
-	err = os.WriteFile(outputPath, data, 0644)
-	if err != nil {
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
 		return fmt.Errorf("writing manifest: %w", err)
 	}
-	
-	return nil
-}
 
-// VerifyFile checks file integrity against manifest
-func (p *Plugin) VerifyFile(path string, content []byte) (bool, error) {
-	if !p.enabled {
-		return true, nil
+	proofData, err := json.MarshalIndent(p.proofs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling proofs: %w", err)
 	}
-	
-	expectedHash, exists := p.manifest[path]
-	if !exists {
-		return false, fmt.Errorf("file not in manifest: %s", path)
+	if err := os.WriteFile(proofsPath(outputPath), proofData, 0644); err != nil {
+		return fmt.Errorf("writing proofs: %w", err)
 	}
-	
-	hasher := sha256.New()
-	hasher.Write(content)
-	actualHash := hex.EncodeToString(hasher.Sum(nil))
-	
-	// Use constant-time comparison to prevent timing attacks
-	if subtle.ConstantTimeCompare([]byte(expectedHash), []byte(actualHash)) != 1 {
-		return false, fmt.Errorf("hash mismatch for %s", path)
-	}
-	
-	return true, nil
+
+	return nil
+}
+
+// proofsPath returns the "proofs.json" sidecar path for a manifest written
+// at manifestPath.
+func proofsPath(manifestPath string) string {
+	return filepath.Join(filepath.Dir(manifestPath), "proofs.json")
 }
 
-// LoadManifest reads manifest from file
+// LoadManifest reads the signed manifest header and its proofs.json sidecar
+// from disk, verifying the root's signature against its embedded pubkey.
 func (p *Plugin) LoadManifest(manifestPath string) error {
 	if !p.enabled {
 		return nil
 	}
-	
+
 	data, err := os.ReadFile(manifestPath)
 	if err != nil {
 		return fmt.Errorf("reading manifest: %w", err)
 	}
-	
+
 	var manifestWithMeta struct {
-		Version   string            `json:"version"`
-		Generated string            `json:"generated"`
-		Files     map[string]string `json:"files"`
+		Version   string         `json:"version"`
+		Generated string         `json:"generated"`
+		Header    ManifestHeader `json:"header"`
 	}
-	
-	err = json.Unmarshal(data, &manifestWithMeta)
-	if err != nil {
+	if err := json.Unmarshal(data, &manifestWithMeta); err != nil {
 		return fmt.Errorf("parsing manifest: %w", err)
 	}
-	
-	p.manifest = manifestWithMeta.Files
+	p.header = manifestWithMeta.Header
+
+	if err := p.verifyHeaderSignature(); err != nil {
+		return err
+	}
+
+	proofData, err := os.ReadFile(proofsPath(manifestPath))
+	if err != nil {
+		return fmt.Errorf("reading proofs: %w", err)
+	}
+	var proofs map[string][]string
+	if err := json.Unmarshal(proofData, &proofs); err != nil {
+		return fmt.Errorf("parsing proofs: %w", err)
+	}
+	p.proofs = proofs
+
 	return nil
 }
 
-// VerifyAll checks all files in directory against manifest
+// verifyHeaderSignature checks that p.header.Signature is a valid Ed25519
+// signature over p.header.Root made by p.header.PubKey, i.e. that the
+// manifest is internally self-consistent.
+func (p *Plugin) verifyHeaderSignature() error {
+	root, err := hex.DecodeString(p.header.Root)
+	if err != nil {
+		return fmt.Errorf("parsing manifest root: %w", err)
+	}
+	signature, err := hex.DecodeString(p.header.Signature)
+	if err != nil {
+		return fmt.Errorf("parsing manifest signature: %w", err)
+	}
+	pub, err := hex.DecodeString(p.header.PubKey)
+	if err != nil {
+		return fmt.Errorf("parsing manifest pubkey: %w", err)
+	}
+	if !ed25519.Verify(ed25519.PublicKey(pub), root, signature) {
+		return fmt.Errorf("manifest root signature verification failed")
+	}
+	return nil
+}
+
+// VerifyFile checks a single file's content against its stored audit proof
+// and the signed root loaded by LoadManifest.
+func (p *Plugin) VerifyFile(relPath string, content []byte) (bool, error) {
+	if !p.enabled {
+		return true, nil
+	}
+
+	proof, ok := p.proofs[relPath]
+	if !ok {
+		return false, fmt.Errorf("file not in manifest: %s", relPath)
+	}
+
+	root, err := hex.DecodeString(p.header.Root)
+	if err != nil {
+		return false, fmt.Errorf("parsing manifest root: %w", err)
+	}
+
+	return VerifyWithProof(relPath, content, proof, root)
+}
+
+// VerifyAll recomputes the Merkle tree over every file currently in
+// contentDir and compares the result to the signed root loaded by
+// LoadManifest, so verification doesn't depend on trusting any individually
+// stored hash.
 func (p *Plugin) VerifyAll(contentDir string) error {
 	if !p.enabled {
 		return nil
 	}
-	
-	verified := make(map[string]bool)
-	
-	// Check all files in manifest exist and match
-	for relPath, expectedHash := range p.manifest {
-		fullPath := filepath.Join(contentDir, relPath)
-		
-		actualHash, err := p.hashFile(fullPath)
-		if err != nil {
-			return fmt.Errorf("file missing or inaccessible: %s", relPath)
-		}
-		
-		if subtle.ConstantTimeCompare([]byte(expectedHash), []byte(actualHash)) != 1 {
-			return fmt.Errorf("integrity check failed for: %s", relPath)
-		}
-		
-		verified[relPath] = true
+
+	if err := p.verifyHeaderSignature(); err != nil {
+		return err
 	}
-	
-	// Check for unexpected files
+
+	current := make(map[string]string)
 	err := filepath.Walk(contentDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil || info.IsDir() {
 			return err
 		}
-		
-		relPath, _ := filepath.Rel(contentDir, path)
-		
-		// Skip manifest files
-		if filepath.Base(path) == "integrity-manifest.json" ||
-		   filepath.Base(path) == "integrity-manifest.json.sig" {
+		if isManifestArtifact(filepath.Base(path)) {
 			return nil
 		}
-		
-		if !verified[relPath] {
-			return fmt.Errorf("unexpected file not in manifest: %s", relPath)
+
+		hash, err := p.hashFile(path)
+		if err != nil {
+			return fmt.Errorf("hashing %s: %w", path, err)
+		}
+		relPath, err := filepath.Rel(contentDir, path)
+		if err != nil {
+			return err
 		}
-		
+		current[relPath] = hash
 		return nil
 	})
-	
-	return err
-}
\ No newline at end of file
+	if err != nil {
+		return fmt.Errorf("walking directory: %w", err)
+	}
+
+	root, _, _, err := buildMerkleTree(current)
+	if err != nil {
+		return fmt.Errorf("building merkle tree: %w", err)
+	}
+
+	expectedRoot, err := hex.DecodeString(p.header.Root)
+	if err != nil {
+		return fmt.Errorf("parsing manifest root: %w", err)
+	}
+	if !bytes.Equal(root, expectedRoot) {
+		return fmt.Errorf("integrity check failed: recomputed merkle root does not match signed root")
+	}
+
+	return nil
+}
+
+// signRoot loads (generating if necessary) the Ed25519 key at
+// p.signKeyPath and signs root.
+func (p *Plugin) signRoot(root []byte) (signature, pub []byte, err error) {
+	priv, pub, err := loadOrGenerateKey(p.signKeyPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ed25519.Sign(priv, root), pub, nil
+}
+
+// loadOrGenerateKey reads an Ed25519 private key from path, generating and
+// persisting a new key pair (public key alongside it, at path+".pub") if
+// none exists yet.
+func loadOrGenerateKey(path string) (ed25519.PrivateKey, ed25519.PublicKey, error) {
+	if data, err := os.ReadFile(path); err == nil {
+		priv, err := hex.DecodeString(string(data))
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing signing key: %w", err)
+		}
+		key := ed25519.PrivateKey(priv)
+		return key, key.Public().(ed25519.PublicKey), nil
+	}
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generating signing key: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, nil, fmt.Errorf("creating signing key directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(priv)), 0600); err != nil {
+		return nil, nil, fmt.Errorf("persisting signing key: %w", err)
+	}
+	if err := os.WriteFile(path+".pub", []byte(hex.EncodeToString(pub)), 0644); err != nil {
+		return nil, nil, fmt.Errorf("persisting public key: %w", err)
+	}
+
+	return priv, pub, nil
+}
@@ -0,0 +1,137 @@
+package integrity
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+)
+
+// leafHash returns the domain-separated leaf hash for a (relPath, content
+// hash) pair. relPath and contentHash are each null-terminated before
+// hashing so no ambiguous concatenation of the two can collide.
+func leafHash(relPath string, contentHash []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{leafPrefix})
+	h.Write([]byte(relPath))
+	h.Write([]byte{0})
+	h.Write(contentHash)
+	return h.Sum(nil)
+}
+
+// nodeHash returns the domain-separated interior node hash for a left/right
+// child pair.
+func nodeHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{nodePrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// buildMerkleTree builds a deterministic Merkle tree over manifest's sorted
+// (relPath, hex sha256) leaves. Odd levels duplicate their last hash to
+// pair up, matching the classic Bitcoin-style scheme. It returns the root,
+// the tree's height (levels above the leaves), and each relPath's
+// sibling-hash audit path as "<side><hex sibling hash>" strings, where side
+// is 'L' if the sibling belongs on the left when recombining or 'R' if it
+// belongs on the right.
+func buildMerkleTree(manifest map[string]string) (root []byte, height int, proofs map[string][]string, err error) {
+	relPaths := make([]string, 0, len(manifest))
+	for relPath := range manifest {
+		relPaths = append(relPaths, relPath)
+	}
+	sort.Strings(relPaths)
+
+	levels := make([][][]byte, 0)
+	leaves := make([][]byte, len(relPaths))
+	for i, relPath := range relPaths {
+		contentHash, err := hex.DecodeString(manifest[relPath])
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("parsing hash for %s: %w", relPath, err)
+		}
+		leaves[i] = leafHash(relPath, contentHash)
+	}
+	levels = append(levels, leaves)
+
+	for level := levels[len(levels)-1]; len(level) > 1; level = levels[len(levels)-1] {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			left := level[i]
+			right := level[i]
+			if i+1 < len(level) {
+				right = level[i+1]
+			}
+			next = append(next, nodeHash(left, right))
+		}
+		levels = append(levels, next)
+	}
+
+	height = len(levels) - 1
+	top := levels[height]
+	if len(top) != 1 {
+		// len(relPaths) == 0: define an empty tree's root as the hash of no leaves.
+		return sha256.New().Sum(nil), 0, map[string][]string{}, nil
+	}
+
+	proofs = make(map[string][]string, len(relPaths))
+	for i, relPath := range relPaths {
+		proof := make([]string, 0, height)
+		pos := i
+		for lvl := 0; lvl < height; lvl++ {
+			level := levels[lvl]
+			var sibling []byte
+			var side byte
+			if pos%2 == 0 {
+				side = 'R'
+				if pos+1 < len(level) {
+					sibling = level[pos+1]
+				} else {
+					sibling = level[pos] // odd level: duplicate-last padding
+				}
+			} else {
+				side = 'L'
+				sibling = level[pos-1]
+			}
+			proof = append(proof, string(side)+hex.EncodeToString(sibling))
+			pos /= 2
+		}
+		proofs[relPath] = proof
+	}
+
+	return levels[height][0], height, proofs, nil
+}
+
+// VerifyWithProof checks that content hashes to a leaf that, combined with
+// proof's sibling hashes in order, reproduces root. This lets a verifier
+// confirm a single asset's integrity from its audit path alone, without
+// loading the full manifest.
+func VerifyWithProof(relPath string, content []byte, proof []string, root []byte) (bool, error) {
+	contentHash := sha256.Sum256(content)
+	cur := leafHash(relPath, contentHash[:])
+
+	for _, step := range proof {
+		if len(step) < 2 {
+			return false, fmt.Errorf("malformed proof step %q", step)
+		}
+		side := step[0]
+		sibling, err := hex.DecodeString(step[1:])
+		if err != nil {
+			return false, fmt.Errorf("malformed proof step %q: %w", step, err)
+		}
+		switch side {
+		case 'L':
+			cur = nodeHash(sibling, cur)
+		case 'R':
+			cur = nodeHash(cur, sibling)
+		default:
+			return false, fmt.Errorf("malformed proof step %q: unknown side %q", step, side)
+		}
+	}
+
+	if !bytes.Equal(cur, root) {
+		return false, fmt.Errorf("merkle proof verification failed for %s", relPath)
+	}
+	return true, nil
+}
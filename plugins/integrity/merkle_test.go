@@ -0,0 +1,82 @@
+package integrity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestBuildMerkleTreeDeterministic(t *testing.T) {
+	manifest := map[string]string{
+		"index.html": "aaaa",
+		"style.css":  "bbbb",
+		"post.html":  "cccc",
+	}
+
+	rootA, heightA, _, err := buildMerkleTree(manifest)
+	if err != nil {
+		t.Fatalf("buildMerkleTree() error = %v", err)
+	}
+	rootB, heightB, _, err := buildMerkleTree(manifest)
+	if err != nil {
+		t.Fatalf("buildMerkleTree() error = %v", err)
+	}
+
+	if hex.EncodeToString(rootA) != hex.EncodeToString(rootB) {
+		t.Errorf("buildMerkleTree() is not deterministic: %x != %x", rootA, rootB)
+	}
+	if heightA != heightB {
+		t.Errorf("height mismatch: %d != %d", heightA, heightB)
+	}
+}
+
+func TestVerifyWithProofAcceptsGenuineContent(t *testing.T) {
+	files := map[string][]byte{
+		"index.html": []byte("<p>hello</p>"),
+		"style.css":  []byte("body{color:red}"),
+		"post.html":  []byte("<p>a post</p>"),
+		"about.html": []byte("<p>about</p>"),
+	}
+
+	manifest := make(map[string]string, len(files))
+	for relPath, content := range files {
+		h := sha256.Sum256(content)
+		manifest[relPath] = hex.EncodeToString(h[:])
+	}
+
+	root, _, proofs, err := buildMerkleTree(manifest)
+	if err != nil {
+		t.Fatalf("buildMerkleTree() error = %v", err)
+	}
+
+	for relPath, content := range files {
+		ok, err := VerifyWithProof(relPath, content, proofs[relPath], root)
+		if err != nil || !ok {
+			t.Errorf("VerifyWithProof(%s) = %v, %v; want true, nil", relPath, ok, err)
+		}
+	}
+}
+
+func TestVerifyWithProofRejectsTamperedContent(t *testing.T) {
+	files := map[string][]byte{
+		"index.html": []byte("<p>hello</p>"),
+		"style.css":  []byte("body{color:red}"),
+		"post.html":  []byte("<p>a post</p>"),
+	}
+
+	manifest := make(map[string]string, len(files))
+	for relPath, content := range files {
+		h := sha256.Sum256(content)
+		manifest[relPath] = hex.EncodeToString(h[:])
+	}
+
+	root, _, proofs, err := buildMerkleTree(manifest)
+	if err != nil {
+		t.Fatalf("buildMerkleTree() error = %v", err)
+	}
+
+	ok, err := VerifyWithProof("index.html", []byte("<p>tampered</p>"), proofs["index.html"], root)
+	if err == nil || ok {
+		t.Errorf("VerifyWithProof() on tampered content = %v, %v; want false, error", ok, err)
+	}
+}
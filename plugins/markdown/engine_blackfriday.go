@@ -0,0 +1,28 @@
+package markdown
+
+import "github.com/russross/blackfriday/v2"
+
+// blackfridayEngine is the original CommonMark renderer. It predates the
+// MarkdownEngine interface and is kept as the default so existing content
+// and cached output don't change until "goldmark" is opted into via
+// config.
+type blackfridayEngine struct{}
+
+func newBlackfridayEngine() *blackfridayEngine {
+	return &blackfridayEngine{}
+}
+
+// Render runs blackfriday with no extensions beyond CommonMark itself, to
+// keep the parser's surface area (and therefore its attack surface) as
+// small as possible; meta is unused, as blackfriday has no concept of it.
+func (e *blackfridayEngine) Render(src []byte, meta map[string]any) ([]byte, error) {
+	rendered := blackfriday.Run(src,
+		blackfriday.WithNoExtensions(),
+		blackfriday.WithRenderer(blackfriday.NewHTMLRenderer(
+			blackfriday.HTMLRendererParameters{
+				Flags: blackfriday.NoreferrerLinks |
+					blackfriday.NofollowLinks |
+					blackfriday.HrefTargetBlank,
+			})))
+	return rendered, nil
+}
@@ -0,0 +1,52 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInitRejectsUnknownEngine(t *testing.T) {
+	p := New()
+	if err := p.Init(map[string]interface{}{"engine": "nope"}); err == nil {
+		t.Fatal("Init() error = nil, want error for unknown engine")
+	}
+}
+
+func TestProcessContentDefaultsToBlackfriday(t *testing.T) {
+	p := New()
+	if err := p.Init(nil); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	got, err := p.ProcessContent([]byte("# Hello"), nil)
+	if err != nil {
+		t.Fatalf("ProcessContent() error = %v", err)
+	}
+	if !strings.Contains(string(got), "<h1>Hello</h1>") {
+		t.Errorf("ProcessContent() = %q, want it to contain an <h1>", got)
+	}
+}
+
+func TestProcessContentGoldmarkRendersGFMAndSanitizes(t *testing.T) {
+	p := New()
+	if err := p.Init(map[string]interface{}{"engine": "goldmark"}); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	src := "# Title\n\n- [x] done\n\n[xss](javascript:alert(1))\n"
+	got, err := p.ProcessContent([]byte(src), nil)
+	if err != nil {
+		t.Fatalf("ProcessContent() error = %v", err)
+	}
+	html := string(got)
+
+	if !strings.Contains(html, `id="title"`) {
+		t.Errorf("ProcessContent() = %q, want an auto-generated heading ID", html)
+	}
+	if !strings.Contains(html, `checked`) {
+		t.Errorf("ProcessContent() = %q, want a checked task list item", html)
+	}
+	if strings.Contains(html, "javascript:") {
+		t.Errorf("ProcessContent() = %q, want javascript: URL rejected by the allow-list", html)
+	}
+}
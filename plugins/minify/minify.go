@@ -0,0 +1,111 @@
+// Package minify implements a BuildPlugin that shrinks rendered HTML, CSS,
+// XML, and SVG output. It must run after template rendering (all files are
+// already written to outputDir) but before the integrity plugin, so the
+// signed manifest's hashes match the bytes actually served.
+package minify
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"secureblog/internal/plugin"
+	"strings"
+)
+
+// Plugin minifies output files in place, per-format, during PostBuild.
+type Plugin struct {
+	HTML bool
+	CSS  bool
+	XML  bool
+	SVG  bool
+}
+
+// New creates a minify plugin with every format enabled by default.
+func New() *Plugin {
+	return &Plugin{HTML: true, CSS: true, XML: true, SVG: true}
+}
+
+func (p *Plugin) Name() string {
+	return "minify"
+}
+
+func (p *Plugin) Version() string {
+	return "1.0.0"
+}
+
+// Init reads per-format toggles from config; a format missing from config
+// is left at its default (enabled).
+func (p *Plugin) Init(config map[string]interface{}) error {
+	if v, ok := config["html"].(bool); ok {
+		p.HTML = v
+	}
+	if v, ok := config["css"].(bool); ok {
+		p.CSS = v
+	}
+	if v, ok := config["xml"].(bool); ok {
+		p.XML = v
+	}
+	if v, ok := config["svg"].(bool); ok {
+		p.SVG = v
+	}
+	return nil
+}
+
+// Priority runs minify between markdown (10) and integrity (100), after
+// every output-generating plugin (RSS, sitemap) has written its files.
+func (p *Plugin) Priority() int {
+	return 90
+}
+
+func (p *Plugin) PreBuild(sourceDir string) error {
+	return nil
+}
+
+// PostBuild walks outputDir and minifies every file whose extension maps
+// to an enabled format, in place.
+func (p *Plugin) PostBuild(outputDir string) error {
+	return filepath.Walk(outputDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		minifier, ok := p.minifierFor(filepath.Ext(path))
+		if !ok {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(path, minifier(content), info.Mode()); err != nil {
+			return fmt.Errorf("minifying %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+func (p *Plugin) minifierFor(ext string) (func([]byte) []byte, bool) {
+	switch strings.ToLower(ext) {
+	case ".html", ".htm":
+		if p.HTML {
+			return HTML, true
+		}
+	case ".css":
+		if p.CSS {
+			return CSS, true
+		}
+	case ".xml", ".rss":
+		if p.XML {
+			return XML, true
+		}
+	case ".svg":
+		if p.SVG {
+			return XML, true
+		}
+	}
+	return nil, false
+}
+
+var _ plugin.BuildPlugin = (*Plugin)(nil)
@@ -0,0 +1,75 @@
+package minify
+
+import (
+	"crypto/sha256"
+	"strings"
+	"testing"
+)
+
+func TestHTMLDeterministic(t *testing.T) {
+	input := []byte("<html>\n  <body>\n    <p>hello</p>\n\n  </body>\n</html>\n")
+
+	a := sha256.Sum256(HTML(input))
+	b := sha256.Sum256(HTML(input))
+
+	if a != b {
+		t.Errorf("HTML() is not deterministic: %x != %x", a, b)
+	}
+}
+
+func TestHTMLPreservesNonceAndStyleContent(t *testing.T) {
+	input := []byte(`<html><head><style nonce="abc123">
+  body   {   color : red ;  }
+</style></head><body></body></html>`)
+
+	out := string(HTML(input))
+
+	if !strings.Contains(out, `nonce="abc123"`) {
+		t.Errorf("HTML() dropped the style nonce attribute: %s", out)
+	}
+	if !strings.Contains(out, "body   {   color : red ;  }") {
+		t.Errorf("HTML() altered <style> content: %s", out)
+	}
+}
+
+func TestHTMLCollapsesWhitespaceBetweenTags(t *testing.T) {
+	input := []byte("<div>\n  <p>a</p>\n\n  <p>b</p>\n</div>")
+	out := string(HTML(input))
+
+	if strings.Contains(out, "\n") {
+		t.Errorf("HTML() left newlines between tags: %q", out)
+	}
+}
+
+func TestCSS(t *testing.T) {
+	input := []byte(`
+/* comment */
+body {
+  color: red;
+  margin: 0 ;
+}
+`)
+	out := string(CSS(input))
+
+	if strings.Contains(out, "/*") {
+		t.Errorf("CSS() left a comment: %q", out)
+	}
+	if strings.Contains(out, "\n") {
+		t.Errorf("CSS() left a newline: %q", out)
+	}
+	if out != "body{color:red;margin:0}" {
+		t.Errorf("CSS() = %q", out)
+	}
+}
+
+func TestXMLStripsCommentsAndWhitespace(t *testing.T) {
+	input := []byte("<rss>\n  <!-- generated -->\n  <channel>\n    <title>x</title>\n  </channel>\n</rss>")
+	out := string(XML(input))
+
+	if strings.Contains(out, "<!--") {
+		t.Errorf("XML() left a comment: %q", out)
+	}
+	if strings.Contains(out, "\n") {
+		t.Errorf("XML() left a newline: %q", out)
+	}
+}
@@ -0,0 +1,118 @@
+package minify
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// protectedBlockRE matches the opening tag of an element whose contents
+// must survive minification byte-for-byte: <script>/<style> because
+// whitespace is meaningful (and, for <style>, because CSPPlugin's nonce
+// injection must keep matching the tag it rewrote), and <pre>/<textarea>
+// because whitespace is always significant to the reader. Go's RE2 engine
+// has no backreferences, so extractProtectedBlocks finds the matching
+// close tag by name itself rather than matching the whole element in one
+// regex.
+var protectedBlockRE = regexp.MustCompile(`(?is)<(script|style|pre|textarea)\b[^>]*>`)
+
+var htmlComment = regexp.MustCompile(`(?s)<!--.*?-->`)
+var tagGap = regexp.MustCompile(`>[ \t\r\n]+<`)
+var whitespaceRun = regexp.MustCompile(`[ \t\r\n]+`)
+
+// HTML minifies html by stripping comments and collapsing inter-tag
+// whitespace, without ever touching the contents (or opening tag, where
+// CSPPlugin writes its nonce="...") of <script>/<style>/<pre>/<textarea>
+// elements.
+func HTML(data []byte) []byte {
+	s := string(data)
+
+	blocks, s := extractProtectedBlocks(s)
+
+	s = htmlComment.ReplaceAllString(s, "")
+	s = tagGap.ReplaceAllString(s, "><")
+	s = whitespaceRun.ReplaceAllString(s, " ")
+	s = strings.TrimSpace(s)
+
+	s = restoreProtectedBlocks(s, blocks)
+
+	return []byte(s)
+}
+
+// extractProtectedBlocks replaces every <script>/<style>/<pre>/<textarea>
+// element (tag and contents, verbatim) with a placeholder, returning the
+// removed blocks in order so they can be restored after whitespace
+// collapsing runs on the rest of the document.
+func extractProtectedBlocks(s string) ([]string, string) {
+	var blocks []string
+	var out strings.Builder
+
+	for {
+		loc := protectedBlockRE.FindStringSubmatchIndex(s)
+		if loc == nil {
+			out.WriteString(s)
+			break
+		}
+		tagName := s[loc[2]:loc[3]]
+		closeTag := "</" + tagName
+		closeIdx := strings.Index(strings.ToLower(s[loc[1]:]), strings.ToLower(closeTag))
+		if closeIdx == -1 {
+			// Malformed/unclosed tag: stop protecting, leave the rest as-is.
+			out.WriteString(s)
+			break
+		}
+		endOfClose := strings.Index(s[loc[1]+closeIdx:], ">")
+		if endOfClose == -1 {
+			out.WriteString(s)
+			break
+		}
+		blockEnd := loc[1] + closeIdx + endOfClose + 1
+
+		out.WriteString(s[:loc[0]])
+		blocks = append(blocks, s[loc[0]:blockEnd])
+		out.WriteString(fmt.Sprintf("\x00MINIFY%d\x00", len(blocks)-1))
+
+		s = s[blockEnd:]
+	}
+
+	return blocks, out.String()
+}
+
+func restoreProtectedBlocks(s string, blocks []string) string {
+	for i, block := range blocks {
+		s = strings.Replace(s, fmt.Sprintf("\x00MINIFY%d\x00", i), block, 1)
+	}
+	return s
+}
+
+var cssComment = regexp.MustCompile(`(?s)/\*.*?\*/`)
+var cssPunctSpace = regexp.MustCompile(`\s*([{}:;,])\s*`)
+
+// CSS minifies a stylesheet by stripping comments, collapsing whitespace,
+// and trimming the space around punctuation.
+func CSS(data []byte) []byte {
+	s := cssComment.ReplaceAllString(string(data), "")
+	s = whitespaceRun.ReplaceAllString(s, " ")
+	s = cssPunctSpace.ReplaceAllString(s, "$1")
+	s = strings.ReplaceAll(s, ";}", "}")
+	return []byte(strings.TrimSpace(s))
+}
+
+var xmlComment = regexp.MustCompile(`(?s)<!--.*?-->`)
+
+// XML minifies XML/SVG/RSS feeds by stripping comments and collapsing
+// inter-tag whitespace. Like HTML, <style> blocks are left untouched so an
+// embedded SVG stylesheet's whitespace (and any nonce attribute) survives.
+func XML(data []byte) []byte {
+	s := string(data)
+
+	blocks, s := extractProtectedBlocks(s)
+
+	s = xmlComment.ReplaceAllString(s, "")
+	s = tagGap.ReplaceAllString(s, "><")
+	s = strings.TrimSpace(s)
+
+	s = restoreProtectedBlocks(s, blocks)
+
+	return []byte(s)
+}
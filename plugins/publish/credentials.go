@@ -0,0 +1,127 @@
+package publish
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// S3Credentials configures an S3-compatible backend: AWS S3, Cloudflare
+// R2, Backblaze B2, MinIO, or anything else that speaks the S3 API and
+// SigV4 signing.
+type S3Credentials struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	Prefix    string
+	AccessKey string
+	SecretKey string
+}
+
+// Validate parses Endpoint as a URL and rejects anything that isn't a
+// bare http(s) origin -- no embedded userinfo, query string, or fragment,
+// since those are easy to smuggle a credential or stray parameter into by
+// accident. Region, bucket, and prefix must all be set: an empty prefix
+// would mean this backend owns the whole bucket, which is never what a
+// site-publish config actually wants.
+func (c S3Credentials) Validate() error {
+	if c.Endpoint == "" {
+		return fmt.Errorf("endpoint is required")
+	}
+	u, err := url.Parse(c.Endpoint)
+	if err != nil {
+		return fmt.Errorf("parsing endpoint: %w", err)
+	}
+	if u.User != nil {
+		return fmt.Errorf("endpoint must not embed credentials")
+	}
+	if u.RawQuery != "" {
+		return fmt.Errorf("endpoint must not include a query string")
+	}
+	if u.Fragment != "" {
+		return fmt.Errorf("endpoint must not include a fragment")
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return fmt.Errorf("endpoint scheme must be http or https, got %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("endpoint must include a host")
+	}
+	if c.Region == "" {
+		return fmt.Errorf("region is required")
+	}
+	if c.Bucket == "" {
+		return fmt.Errorf("bucket is required")
+	}
+	if c.Prefix == "" {
+		return fmt.Errorf("prefix is required")
+	}
+	if c.AccessKey == "" || c.SecretKey == "" {
+		return fmt.Errorf("access key and secret key are required")
+	}
+	return nil
+}
+
+func s3CredentialsFromConfig(config map[string]interface{}) S3Credentials {
+	return S3Credentials{
+		Endpoint:  stringOr(config, "endpoint", ""),
+		Region:    stringOr(config, "region", ""),
+		Bucket:    stringOr(config, "bucket", ""),
+		Prefix:    stringOr(config, "prefix", ""),
+		AccessKey: stringOr(config, "access_key", ""),
+		SecretKey: stringOr(config, "secret_key", ""),
+	}
+}
+
+// RsyncCredentials configures a push over rsync running across SSH. There
+// is no separate SFTP client: rsync itself handles the transfer, SSH
+// handles the channel.
+type RsyncCredentials struct {
+	Host         string
+	Port         int
+	User         string
+	RemotePath   string
+	Prefix       string
+	IdentityFile string
+}
+
+// Validate rejects the values that matter most for a destination that
+// ends up as an argument to an external rsync/ssh process: a host or
+// remote path that starts with "-" would be parsed as a flag instead of
+// an address, and a host or user containing "@"/":"/"/" could redirect
+// the connection somewhere the operator didn't intend.
+func (c RsyncCredentials) Validate() error {
+	if c.Host == "" {
+		return fmt.Errorf("host is required")
+	}
+	if strings.HasPrefix(c.Host, "-") || strings.ContainsAny(c.Host, "@: /") {
+		return fmt.Errorf("invalid host %q", c.Host)
+	}
+	if c.User != "" && strings.ContainsAny(c.User, "@: /") {
+		return fmt.Errorf("invalid user %q", c.User)
+	}
+	if c.RemotePath == "" {
+		return fmt.Errorf("remote path is required")
+	}
+	if strings.HasPrefix(c.RemotePath, "-") {
+		return fmt.Errorf("remote path must not look like a flag")
+	}
+	if c.Prefix == "" {
+		return fmt.Errorf("prefix is required")
+	}
+	if strings.HasPrefix(c.Prefix, "-") {
+		return fmt.Errorf("prefix must not look like a flag")
+	}
+	return nil
+}
+
+func rsyncCredentialsFromConfig(config map[string]interface{}) RsyncCredentials {
+	return RsyncCredentials{
+		Host:         stringOr(config, "host", ""),
+		Port:         intOr(config, "port", 0),
+		User:         stringOr(config, "user", ""),
+		RemotePath:   stringOr(config, "remote_path", ""),
+		Prefix:       stringOr(config, "prefix", ""),
+		IdentityFile: stringOr(config, "identity_file", ""),
+	}
+}
@@ -0,0 +1,116 @@
+// Package publish pushes a signed build to a remote destination. Where it
+// goes is pluggable: Backend abstracts over an S3-compatible object store
+// (AWS S3, Cloudflare R2, Backblaze B2, MinIO) and a plain rsync/SFTP
+// target, selected by config. Every backend can be diffed against a build
+// manifest before anything is written, so publishing to a bucket is as
+// auditable as the existing git push.
+package publish
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"secureblog/internal/security"
+)
+
+// Backend pushes a signed build to one publish destination. Push must be
+// idempotent: calling it again after a partial failure should converge on
+// the same end state instead of erroring on objects already in place.
+type Backend interface {
+	Name() string
+
+	// List returns every object currently present at the backend's
+	// destination, keyed by its path relative to the configured prefix
+	// (matching a BuildManifest's file paths), mapped to a value that
+	// changes whenever the object's content does. It does no writes.
+	List(ctx context.Context) (map[string]string, error)
+
+	// Push uploads everything manifest describes that isn't already
+	// present with a matching entry from List, and removes anything
+	// present at the destination that manifest no longer describes.
+	Push(ctx context.Context, distDir string, manifest *security.BuildManifest) error
+}
+
+// DiffAction describes what a dry-run Plan would do with one object key.
+type DiffAction string
+
+// DiffAction values.
+const (
+	DiffUpload DiffAction = "upload"
+	DiffDelete DiffAction = "delete"
+	DiffKeep   DiffAction = "keep"
+)
+
+// DiffEntry is one line of a dry-run plan: a key and what a real Push
+// would do with it.
+type DiffEntry struct {
+	Key    string     `json:"key"`
+	Action DiffAction `json:"action"`
+}
+
+// Plan compares a build manifest against a backend's current object list
+// (as returned by Backend.List) and reports, per key, whether Push would
+// upload it, delete it, or leave it alone -- without touching the backend.
+func Plan(manifest *security.BuildManifest, remote map[string]string) []DiffEntry {
+	local := make(map[string]string, len(manifest.Files))
+	for _, f := range manifest.Files {
+		local[f.Path] = f.SHA256
+	}
+
+	entries := make([]DiffEntry, 0, len(local)+len(remote))
+	for key, hash := range local {
+		action := DiffUpload
+		if remoteHash, ok := remote[key]; ok && remoteHash == hash {
+			action = DiffKeep
+		}
+		entries = append(entries, DiffEntry{Key: key, Action: action})
+	}
+	for key := range remote {
+		if _, ok := local[key]; !ok {
+			entries = append(entries, DiffEntry{Key: key, Action: DiffDelete})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries
+}
+
+// NewBackend constructs the Backend named by kind ("s3" or "rsync") from
+// config, validating its credentials before returning it.
+func NewBackend(kind string, config map[string]interface{}) (Backend, error) {
+	switch kind {
+	case "s3":
+		creds := s3CredentialsFromConfig(config)
+		if err := creds.Validate(); err != nil {
+			return nil, fmt.Errorf("publish.backend=s3: %w", err)
+		}
+		return newS3Backend(creds), nil
+	case "rsync":
+		creds := rsyncCredentialsFromConfig(config)
+		if err := creds.Validate(); err != nil {
+			return nil, fmt.Errorf("publish.backend=rsync: %w", err)
+		}
+		return newRsyncBackend(creds), nil
+	default:
+		return nil, fmt.Errorf("unknown publish backend %q", kind)
+	}
+}
+
+func stringOr(config map[string]interface{}, key, def string) string {
+	if v, ok := config[key].(string); ok && v != "" {
+		return v
+	}
+	return def
+}
+
+func intOr(config map[string]interface{}, key string, def int) int {
+	switch v := config[key].(type) {
+	case int:
+		return v
+	case float64: // config values decoded from YAML/JSON numbers land here
+		return int(v)
+	default:
+		return def
+	}
+}
@@ -0,0 +1,309 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"secureblog/internal/security"
+)
+
+// s3MetaSHA256Header is the custom object metadata header Push sets on
+// every upload, so List can read back an exact content hash instead of
+// relying on ETag (which is only an MD5 of the body, and not even that
+// for multipart uploads).
+const s3MetaSHA256Header = "X-Amz-Meta-Sha256"
+
+// s3Backend pushes a build to an S3-compatible object store, signing
+// every request with SigV4 the same way AWS S3, Cloudflare R2, Backblaze
+// B2, and MinIO all expect.
+type s3Backend struct {
+	creds  S3Credentials
+	client *http.Client
+}
+
+func newS3Backend(creds S3Credentials) *s3Backend {
+	return &s3Backend{creds: creds, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+func (b *s3Backend) Name() string { return "s3" }
+
+// key maps a manifest-relative path to the object key this backend stores
+// it under (its configured prefix, joined on top).
+func (b *s3Backend) key(relPath string) string {
+	return path.Join(b.creds.Prefix, filepath.ToSlash(relPath))
+}
+
+// List enumerates every object under the bucket's configured prefix and
+// returns each one's manifest-relative path mapped to its content SHA256.
+func (b *s3Backend) List(ctx context.Context) (map[string]string, error) {
+	keys, err := b.listKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing objects: %w", err)
+	}
+
+	result := make(map[string]string, len(keys))
+	for _, key := range keys {
+		hash, err := b.headObjectHash(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("heading %s: %w", key, err)
+		}
+		result[strings.TrimPrefix(key, b.creds.Prefix+"/")] = hash
+	}
+	return result, nil
+}
+
+func (b *s3Backend) listKeys(ctx context.Context) ([]string, error) {
+	var keys []string
+	continuationToken := ""
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", b.creds.Prefix+"/")
+		if continuationToken != "" {
+			query.Set("continuation-token", continuationToken)
+		}
+
+		req, err := b.newRequest(ctx, http.MethodGet, "/"+b.creds.Bucket, query, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := b.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%s: %s", resp.Status, body)
+		}
+
+		var listing s3ListBucketResult
+		if err := xml.Unmarshal(body, &listing); err != nil {
+			return nil, fmt.Errorf("parsing listing: %w", err)
+		}
+		for _, c := range listing.Contents {
+			keys = append(keys, c.Key)
+		}
+		if !listing.IsTruncated {
+			break
+		}
+		continuationToken = listing.NextContinuationToken
+	}
+	return keys, nil
+}
+
+type s3ListBucketResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+}
+
+func (b *s3Backend) headObjectHash(ctx context.Context, key string) (string, error) {
+	req, err := b.newRequest(ctx, http.MethodHead, "/"+b.creds.Bucket+"/"+key, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s", resp.Status)
+	}
+	if sha := resp.Header.Get(s3MetaSHA256Header); sha != "" {
+		return sha, nil
+	}
+	// Fall back to ETag for objects this backend didn't write itself --
+	// it won't match a manifest's SHA256, so Plan will (correctly, if
+	// conservatively) propose re-uploading it.
+	return strings.Trim(resp.Header.Get("ETag"), `"`), nil
+}
+
+// Push uploads every file the manifest describes that isn't already
+// present remotely with a matching SHA256, then deletes any remote object
+// under the prefix the manifest no longer describes.
+func (b *s3Backend) Push(ctx context.Context, distDir string, manifest *security.BuildManifest) error {
+	remote, err := b.List(ctx)
+	if err != nil {
+		return fmt.Errorf("listing existing objects: %w", err)
+	}
+
+	for _, entry := range Plan(manifest, remote) {
+		switch entry.Action {
+		case DiffUpload:
+			if err := b.putObject(ctx, distDir, entry.Key); err != nil {
+				return fmt.Errorf("uploading %s: %w", entry.Key, err)
+			}
+		case DiffDelete:
+			if err := b.deleteObject(ctx, entry.Key); err != nil {
+				return fmt.Errorf("deleting %s: %w", entry.Key, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (b *s3Backend) putObject(ctx context.Context, distDir, relKey string) error {
+	content, err := os.ReadFile(filepath.Join(distDir, filepath.FromSlash(relKey)))
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(content)
+
+	req, err := b.newRequest(ctx, http.MethodPut, "/"+b.creds.Bucket+"/"+b.key(relKey), nil, content)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(s3MetaSHA256Header, hex.EncodeToString(sum[:]))
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, body)
+	}
+	return nil
+}
+
+func (b *s3Backend) deleteObject(ctx context.Context, relKey string) error {
+	req, err := b.newRequest(ctx, http.MethodDelete, "/"+b.creds.Bucket+"/"+b.key(relKey), nil, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, body)
+	}
+	return nil
+}
+
+// newRequest builds a SigV4-signed request against the configured
+// endpoint. query and body may be nil.
+func (b *s3Backend) newRequest(ctx context.Context, method, canonicalPath string, query url.Values, body []byte) (*http.Request, error) {
+	endpoint, err := url.Parse(b.creds.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	reqURL := *endpoint
+	reqURL.Path = canonicalPath
+	if query != nil {
+		reqURL.RawQuery = query.Encode()
+	}
+
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqURL.String(), bodyReader)
+	if err != nil {
+		return nil, err
+	}
+	req.Host = reqURL.Host
+	if body != nil {
+		req.ContentLength = int64(len(body))
+	}
+
+	now := time.Now().UTC()
+	payloadHash := hashHex(body)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	req.Header.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+
+	b.sign(req, now, payloadHash)
+	return req, nil
+}
+
+// sign computes and attaches the SigV4 Authorization header for req.
+func (b *s3Backend) sign(req *http.Request, now time.Time, payloadHash string) {
+	dateStamp := now.Format("20060102")
+	amzDate := now.Format("20060102T150405Z")
+	credentialScope := fmt.Sprintf("%s/%s/s3/aws4_request", dateStamp, b.creds.Region)
+
+	headers := map[string]string{
+		"host":                 req.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	signedHeaderNames := make([]string, 0, len(headers))
+	for k := range headers {
+		signedHeaderNames = append(signedHeaderNames, k)
+	}
+	sort.Strings(signedHeaderNames)
+
+	var canonicalHeaders strings.Builder
+	for _, k := range signedHeaderNames {
+		canonicalHeaders.WriteString(k)
+		canonicalHeaders.WriteString(":")
+		canonicalHeaders.WriteString(headers[k])
+		canonicalHeaders.WriteString("\n")
+	}
+	signedHeaders := strings.Join(signedHeaderNames, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		credentialScope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signature := hex.EncodeToString(hmacSHA256(b.signingKey(dateStamp), stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		b.creds.AccessKey, credentialScope, signedHeaders, signature,
+	))
+}
+
+func (b *s3Backend) signingKey(dateStamp string) []byte {
+	kDate := hmacSHA256([]byte("AWS4"+b.creds.SecretKey), dateStamp)
+	kRegion := hmacSHA256(kDate, b.creds.Region)
+	kService := hmacSHA256(kRegion, "s3")
+	return hmacSHA256(kService, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hashHex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
@@ -4,9 +4,10 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io/ioutil"
+	"net/url"
 	"path/filepath"
 	"secureblog/internal/plugin"
-	"time"
+	"strings"
 )
 
 // RSSPlugin generates RSS feeds
@@ -17,14 +18,25 @@ type RSSPlugin struct {
 type RSS struct {
 	XMLName xml.Name `xml:"rss"`
 	Version string   `xml:"version,attr"`
+	AtomNS  string   `xml:"xmlns:atom,attr,omitempty"`
 	Channel Channel  `xml:"channel"`
 }
 
 type Channel struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	Items       []Item `xml:"item"`
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	Description string    `xml:"description"`
+	SelfLink    *AtomLink `xml:"atom:link,omitempty"`
+	Items       []Item    `xml:"item"`
+}
+
+// AtomLink is the standard <atom:link rel="self"> autodiscovery element
+// (RFC 4287 via the common RSS 2.0 extension), so a feed reader knows
+// where to find this feed's own (possibly token-bearing) URL.
+type AtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
 }
 
 type Item struct {
@@ -61,7 +73,7 @@ func (p *RSSPlugin) Generate(posts []plugin.Post, outputDir string) error {
 	siteTitle := "Secure Blog"
 	siteURL := "/"
 	siteDesc := "A security-focused blog"
-	
+
 	if title, ok := p.config["title"].(string); ok {
 		siteTitle = title
 	}
@@ -83,11 +95,29 @@ func (p *RSSPlugin) Generate(posts []plugin.Post, outputDir string) error {
 		},
 	}
 
+	// feedToken, set only when SECUREBLOG_PRIVATE gates this site, lets a
+	// feed reader resubscribe at a URL that carries its own access token
+	// (see internal/session.IssueFeedToken and internal/server's
+	// SECUREBLOG_PRIVATE middleware) instead of needing a browser session.
+	if token, ok := p.config["feedToken"].(string); ok && token != "" {
+		rss.AtomNS = "http://www.w3.org/2005/Atom"
+		rss.Channel.SelfLink = &AtomLink{
+			Href: fmt.Sprintf("%s/feed.xml?token=%s", strings.TrimRight(siteURL, "/"), url.QueryEscape(token)),
+			Rel:  "self",
+			Type: "application/rss+xml",
+		}
+	}
+
 	for _, post := range posts {
+		description := string(post.Content)
+		if isProtectedPost(post) {
+			description = "This post is password-protected."
+		}
+
 		item := Item{
 			Title:       post.Title,
 			Link:        fmt.Sprintf("%s/%s.html", siteURL, post.Slug),
-			Description: string(post.Content),
+			Description: description,
 			PubDate:     post.Date,
 			GUID:        fmt.Sprintf("%s/%s", siteURL, post.Slug),
 		}
@@ -103,8 +133,16 @@ func (p *RSSPlugin) Generate(posts []plugin.Post, outputDir string) error {
 	// Write RSS file
 	rssPath := filepath.Join(outputDir, "feed.xml")
 	xmlContent := xml.Header + string(output)
-	
+
 	return ioutil.WriteFile(rssPath, []byte(xmlContent), 0644)
 }
 
-var _ plugin.OutputPlugin = (*RSSPlugin)(nil)
\ No newline at end of file
+// isProtectedPost reports whether a post's front matter set a passphrase,
+// so the feed omits its body instead of shipping the encrypted-content
+// placeholder as though it were real content.
+func isProtectedPost(post plugin.Post) bool {
+	protected, _ := post.Metadata["protected"].(bool)
+	return protected
+}
+
+var _ plugin.OutputPlugin = (*RSSPlugin)(nil)
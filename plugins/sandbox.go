@@ -1,9 +1,14 @@
 package plugins
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"secureblog/internal/policy"
+	"secureblog/internal/security"
 	"strings"
 	"syscall"
 )
@@ -14,15 +19,21 @@ type Sandbox struct {
 	DenyEnv     bool
 	ReadOnly    bool
 	WorkDir     string
+	Policy      *policy.Policy
 }
 
-// NewSandbox creates a secure sandbox for plugin execution
-func NewSandbox() *Sandbox {
+// NewSandbox creates a secure sandbox for plugin execution, enforcing p at
+// every spawn. A nil policy behaves like policy.Default(): deny everything.
+func NewSandbox(p *policy.Policy) *Sandbox {
+	if p == nil {
+		p = policy.Default()
+	}
 	return &Sandbox{
 		DenyNetwork: true,
 		DenyEnv:     true,
 		ReadOnly:    true,
 		WorkDir:     "/tmp/plugin-sandbox",
+		Policy:      p,
 	}
 }
 
@@ -33,28 +44,62 @@ func (s *Sandbox) Execute(pluginPath string, args ...string) error {
 		return fmt.Errorf("plugin validation failed: %w", err)
 	}
 
+	if err := s.Policy.AllowExec(filepath.Base(pluginPath)); err != nil {
+		return err
+	}
+
 	cmd := exec.Command(pluginPath, args...)
-	
+
 	// Set up sandbox environment
 	cmd.Env = s.getSafeEnv()
 	cmd.Dir = s.WorkDir
-	
+
 	// Deny network access using namespace isolation
 	if s.DenyNetwork {
 		cmd.SysProcAttr = &syscall.SysProcAttr{
 			Cloneflags: syscall.CLONE_NEWNET,
 		}
 	}
-	
+
 	// Run with reduced privileges
 	cmd.SysProcAttr.Credential = &syscall.Credential{
 		Uid: 65534, // nobody
 		Gid: 65534, // nogroup
 	}
-	
+
 	return cmd.Run()
 }
 
+// RunCapture runs bin (validated against the sandbox's exec policy) with
+// args inside the same network-denied, reduced-privilege environment as
+// Execute, feeding it stdin and returning its stdout. Unlike Execute,
+// it's for a short-lived helper tool invoked by name (e.g. a diagram
+// renderer) rather than a plugin binary loaded from disk, so it skips
+// validatePlugin's on-disk content scan.
+func (s *Sandbox) RunCapture(ctx context.Context, bin string, args []string, stdin []byte) ([]byte, error) {
+	if err := s.Policy.AllowExec(bin); err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Env = s.getSafeEnv()
+	cmd.Stdin = bytes.NewReader(stdin)
+
+	cmd.SysProcAttr = &syscall.SysProcAttr{}
+	if s.DenyNetwork {
+		cmd.SysProcAttr.Cloneflags = syscall.CLONE_NEWNET
+	}
+	cmd.SysProcAttr.Credential = &syscall.Credential{Uid: 65534, Gid: 65534}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", bin, err, stderr.String())
+	}
+	return stdout.Bytes(), nil
+}
+
 // validatePlugin ensures plugin cannot output dangerous content
 func (s *Sandbox) validatePlugin(pluginPath string) error {
 	// Read plugin binary
@@ -62,7 +107,7 @@ func (s *Sandbox) validatePlugin(pluginPath string) error {
 	if err != nil {
 		return err
 	}
-	
+
 	// Check for dangerous patterns in binary
 	dangerous := []string{
 		"<script",
@@ -74,72 +119,59 @@ func (s *Sandbox) validatePlugin(pluginPath string) error {
 		"document.",
 		"window.",
 	}
-	
+
 	contentStr := string(content)
 	for _, pattern := range dangerous {
 		if strings.Contains(strings.ToLower(contentStr), pattern) {
 			return fmt.Errorf("plugin contains dangerous pattern: %s", pattern)
 		}
 	}
-	
+
 	return nil
 }
 
-// getSafeEnv returns minimal safe environment variables
+// getSafeEnv returns the minimal safe environment variables, plus any
+// variable explicitly allowed by the sandbox's policy (exec.osEnv).
 func (s *Sandbox) getSafeEnv() []string {
-	if s.DenyEnv {
-		// Only essential variables
-		return []string{
-			"PATH=/usr/bin:/bin",
-			"HOME=/tmp",
-			"USER=nobody",
-			"GOWORK=off",     // Disable workspace
-			"CGO_ENABLED=0",  // Disable CGO
-			"GOPROXY=off",    // Disable module proxy
-			"GOSUMDB=off",    // Disable checksum DB
+	if !s.DenyEnv {
+		return os.Environ()
+	}
+
+	// Only essential variables
+	env := []string{
+		"PATH=/usr/bin:/bin",
+		"HOME=/tmp",
+		"USER=nobody",
+		"GOWORK=off",    // Disable workspace
+		"CGO_ENABLED=0", // Disable CGO
+		"GOPROXY=off",   // Disable module proxy
+		"GOSUMDB=off",   // Disable checksum DB
+	}
+
+	for _, kv := range os.Environ() {
+		name := strings.SplitN(kv, "=", 2)[0]
+		if s.Policy.AllowEnv(name) == nil {
+			env = append(env, kv)
 		}
 	}
-	return os.Environ()
+	return env
 }
 
 // OutputFilter validates and sanitizes plugin output
 type OutputFilter struct {
-	blockPatterns []string
+	sanitizer *security.Sanitizer
 }
 
-// NewOutputFilter creates a filter for plugin output
+// NewOutputFilter creates a filter for plugin output, enforcing
+// security.DefaultPolicy. Unlike the old substring-matching filter, this
+// walks a real HTML parse tree, so benign content that merely contains a
+// blocked word (e.g. "on" inside "button") is never false-positived.
 func NewOutputFilter() *OutputFilter {
-	return &OutputFilter{
-		blockPatterns: []string{
-			"<script",
-			"</script",
-			"javascript:",
-			"on[a-z]+\\s*=",
-			"eval\\(",
-			"Function\\(",
-			"setTimeout",
-			"setInterval",
-			"\\.innerHTML",
-			"document\\.",
-			"window\\.",
-			"import\\(",
-		},
-	}
+	return &OutputFilter{sanitizer: security.NewSanitizer(security.DefaultPolicy())}
 }
 
-// Filter removes any JavaScript from plugin output
+// Filter removes any HTML outside the filter's allowlist from plugin
+// output.
 func (f *OutputFilter) Filter(output string) (string, error) {
-	lower := strings.ToLower(output)
-	
-	for _, pattern := range f.blockPatterns {
-		if strings.Contains(lower, strings.ToLower(pattern)) {
-			return "", fmt.Errorf("plugin output contains blocked pattern: %s", pattern)
-		}
-	}
-	
-	// Additional sanitization
-	output = strings.ReplaceAll(output, "<script", "&lt;script")
-	output = strings.ReplaceAll(output, "javascript:", "javascript&#58;")
-	
-	return output, nil
-}
\ No newline at end of file
+	return f.sanitizer.Sanitize(output), nil
+}
@@ -1,25 +1,31 @@
 package sri
 
 import (
-	"crypto/sha256"
-	"crypto/sha384"
-	"crypto/sha512"
-	"encoding/base64"
 	"fmt"
-	"io"
-	"net/http"
 	"regexp"
+	"secureblog/internal/resources"
 	"strings"
 )
 
 // Plugin adds Subresource Integrity (SRI) hashes to external resources
-type Plugin struct{}
+type Plugin struct {
+	Fetcher *resources.Fetcher
+}
+
+// New creates an SRI plugin that fetches resources through f, which gates
+// fetches by security policy and caches bodies for offline rebuilds.
+func New(f *resources.Fetcher) *Plugin {
+	if f == nil {
+		f = resources.NewFetcher(nil)
+	}
+	return &Plugin{Fetcher: f}
+}
 
 // ProcessHTML adds SRI hashes to any external CSS links
 func (p *Plugin) ProcessHTML(html string) string {
 	// Pattern to find external CSS links
 	linkPattern := regexp.MustCompile(`<link[^>]*href=["'](https?://[^"']+\.css)["'][^>]*>`)
-	
+
 	return linkPattern.ReplaceAllStringFunc(html, func(match string) string {
 		// Extract URL
 		urlPattern := regexp.MustCompile(`href=["'](https?://[^"']+)["']`)
@@ -27,56 +33,51 @@ func (p *Plugin) ProcessHTML(html string) string {
 		if len(urlMatch) < 2 {
 			return match
 		}
-		
+
 		url := urlMatch[1]
-		
+
 		// Skip if already has integrity attribute
 		if strings.Contains(match, "integrity=") {
 			return match
 		}
-		
+
 		// Generate SRI hash
-		hash, err := generateSRIHash(url)
+		hash, err := p.generateSRIHash(url)
 		if err != nil {
 			// Log error but don't break the build
 			fmt.Printf("Warning: Could not generate SRI for %s: %v\n", url, err)
 			return match
 		}
-		
+
 		// Add integrity and crossorigin attributes
-		return strings.Replace(match, ">", 
+		return strings.Replace(match, ">",
 			fmt.Sprintf(` integrity="%s" crossorigin="anonymous">`, hash), 1)
 	})
 }
 
-// generateSRIHash fetches resource and generates SHA-384 hash
-func generateSRIHash(url string) (string, error) {
-	resp, err := http.Get(url)
+// generateSRIHash fetches url through the plugin's Fetcher (policy-gated,
+// cached for offline rebuilds) and returns its SRI hash.
+func (p *Plugin) generateSRIHash(url string) (string, error) {
+	res, err := p.Fetcher.Fetch(url)
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
-	
-	// Use SHA-384 as recommended by W3C
-	hasher := sha384.New()
-	if _, err := io.Copy(hasher, resp.Body); err != nil {
-		return "", err
+	if res.Err != nil {
+		return "", res.Err
 	}
-	
-	hash := base64.StdEncoding.EncodeToString(hasher.Sum(nil))
-	return fmt.Sprintf("sha384-%s", hash), nil
+	return res.SRIHash, nil
 }
 
 // GenerateSRIManifest creates a manifest of all external resources with their SRI hashes
-func GenerateSRIManifest(resources []string) map[string]string {
+func (p *Plugin) GenerateSRIManifest(resources []string) map[string]string {
 	manifest := make(map[string]string)
-	
+
 	for _, url := range resources {
-		hash, err := generateSRIHash(url)
+		hash, err := p.generateSRIHash(url)
 		if err == nil {
 			manifest[url] = hash
 		}
 	}
-	
+
 	return manifest
-}
\ No newline at end of file
+}